@@ -0,0 +1,19 @@
+package charts
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// ID identifies the chart view.
+const ID = "chart"
+
+// API defines the dependencies Component requires from the host model.
+type API interface {
+	SetModeClient() tea.Cmd
+	SetModeTracer() tea.Cmd
+	SubscribedTopics() []string
+	FocusedID() string
+	ResetElemPos()
+	SetElemPos(id string, pos int)
+	OverlayHelp(view string) string
+	Width() int
+	Height() int
+}
@@ -0,0 +1,122 @@
+package charts
+
+import (
+	"math"
+	"time"
+)
+
+// brailleBits maps a dot position inside a 2x4 cell to its braille bit.
+var brailleBits = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// canvas is a braille drawing surface of width x height cells.
+type canvas struct {
+	w, h  int
+	cells [][]rune
+	owner [][]int // series index that last drew into each cell
+}
+
+func newCanvas(w, h int) *canvas {
+	c := &canvas{w: w, h: h, cells: make([][]rune, h), owner: make([][]int, h)}
+	for i := range c.cells {
+		c.cells[i] = make([]rune, w)
+		c.owner[i] = make([]int, w)
+	}
+	return c
+}
+
+// set lights the dot at pixel (x, y) where y grows downward.
+func (c *canvas) set(x, y, series int) {
+	if x < 0 || y < 0 || x >= c.w*2 || y >= c.h*4 {
+		return
+	}
+	c.cells[y/4][x/2] |= brailleBits[y%4][x%2]
+	c.owner[y/4][x/2] = series
+}
+
+// line draws a line between two pixels using Bresenham's algorithm.
+func (c *canvas) line(x0, y0, x1, y1, series int) {
+	dx := int(math.Abs(float64(x1 - x0)))
+	dy := -int(math.Abs(float64(y1 - y0)))
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		c.set(x0, y0, series)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// bounds returns the time and value ranges covered by all series.
+func bounds(series []*Series) (t0, t1 time.Time, lo, hi float64, ok bool) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, s := range series {
+		for _, p := range s.Points {
+			if !ok || p.Time.Before(t0) {
+				t0 = p.Time
+			}
+			if !ok || p.Time.After(t1) {
+				t1 = p.Time
+			}
+			ok = true
+			lo = math.Min(lo, p.Value)
+			hi = math.Max(hi, p.Value)
+		}
+	}
+	if ok && lo == hi {
+		lo, hi = lo-1, hi+1
+	}
+	return
+}
+
+// plot draws all series onto a w x h cell canvas. ok is false when there
+// are no points to draw.
+func plot(series []*Series, w, h int) (c *canvas, lo, hi float64, t0, t1 time.Time, ok bool) {
+	c = newCanvas(max(w, 0), max(h, 0))
+	t0, t1, lo, hi, ok = bounds(series)
+	if !ok || w <= 0 || h <= 0 {
+		return c, lo, hi, t0, t1, false
+	}
+	span := t1.Sub(t0).Seconds()
+	px := func(t time.Time) int {
+		if span == 0 {
+			return c.w*2 - 1
+		}
+		return int(math.Round(t.Sub(t0).Seconds() / span * float64(c.w*2-1)))
+	}
+	py := func(v float64) int {
+		return int(math.Round((hi - v) / (hi - lo) * float64(c.h*4-1)))
+	}
+	for si, s := range series {
+		for i, p := range s.Points {
+			x, y := px(p.Time), py(p.Value)
+			if i == 0 {
+				c.set(x, y, si)
+				continue
+			}
+			prev := s.Points[i-1]
+			c.line(px(prev.Time), py(prev.Value), x, y, si)
+		}
+	}
+	return c, lo, hi, t0, t1, true
+}
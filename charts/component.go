@@ -0,0 +1,224 @@
+package charts
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/marang/emqutiti/constants"
+	"github.com/marang/emqutiti/focus"
+	"github.com/marang/emqutiti/history"
+	"github.com/marang/emqutiti/internal/jsonpath"
+	"github.com/marang/emqutiti/ui"
+)
+
+// Component plots numeric values from live messages or a stored trace.
+type Component struct {
+	api     API
+	filters []string
+	path    jsonpath.Path
+	live    []*Series
+	trace   []*Series // non-nil while showing a stored trace
+	samples []Sample  // stored trace messages backing trace
+	title   string
+	form    *chartForm
+	paused  bool
+}
+
+// New creates a chart component.
+func New(api API) *Component { return &Component{api: api} }
+
+// Configure sets the plotted topic filters and JSON path and clears the
+// collected live samples.
+func (c *Component) Configure(filters []string, path string) error {
+	p, err := jsonpath.Compile(path)
+	if err != nil {
+		return err
+	}
+	c.filters = filters
+	c.path = p
+	c.live = make([]*Series, len(filters))
+	for i, f := range filters {
+		c.live[i] = &Series{Filter: f}
+	}
+	return nil
+}
+
+// Add feeds a live message into the matching series.
+func (c *Component) Add(s Sample) {
+	if c.paused {
+		return
+	}
+	for _, sr := range c.live {
+		if !history.MatchFilter(sr.Filter, s.Topic) {
+			continue
+		}
+		if v, ok := Extract(s.Payload, c.path); ok {
+			sr.Add(Point{Time: s.Time, Value: v})
+		}
+	}
+}
+
+// Samples builds series for filters from stored messages.
+func (c *Component) Samples(filters []string, samples []Sample) []*Series {
+	out := make([]*Series, len(filters))
+	for i, f := range filters {
+		out[i] = &Series{Filter: f}
+	}
+	for _, s := range samples {
+		for _, sr := range out {
+			if !history.MatchFilter(sr.Filter, s.Topic) {
+				continue
+			}
+			if v, ok := Extract(s.Payload, c.path); ok {
+				sr.Add(Point{Time: s.Time, Value: v})
+			}
+		}
+	}
+	return out
+}
+
+// OpenLive shows the live chart. When nothing is configured yet the form is
+// prefilled with the subscribed topics.
+func (c *Component) OpenLive() tea.Cmd {
+	c.trace = nil
+	c.samples = nil
+	c.title = "Chart"
+	if len(c.filters) == 0 {
+		return c.openForm(c.api.SubscribedTopics())
+	}
+	return nil
+}
+
+// OpenTrace shows values from a stored trace.
+func (c *Component) OpenTrace(key string, filters []string, samples []Sample) {
+	c.form = nil
+	c.title = fmt.Sprintf("Chart – trace %s", key)
+	c.samples = samples
+	c.trace = c.Samples(filters, samples)
+}
+
+func (c *Component) openForm(filters []string) tea.Cmd {
+	f := newChartForm(filters, c.path.String())
+	c.form = &f
+	return textinput.Blink
+}
+
+func (c *Component) back() tea.Cmd {
+	if c.trace != nil {
+		return c.api.SetModeTracer()
+	}
+	return c.api.SetModeClient()
+}
+
+func (c *Component) Init() tea.Cmd { return nil }
+
+func (c *Component) Update(msg tea.Msg) tea.Cmd {
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return nil
+	}
+	if km.String() == constants.KeyCtrlD {
+		return tea.Quit
+	}
+	if c.form != nil {
+		return c.updateForm(km)
+	}
+	switch km.String() {
+	case constants.KeyEsc:
+		return c.back()
+	case constants.KeyE:
+		filters := c.filters
+		if c.trace != nil {
+			filters = nil
+			for _, s := range c.trace {
+				filters = append(filters, s.Filter)
+			}
+		}
+		return c.openForm(filters)
+	case constants.KeyP:
+		c.paused = !c.paused
+	case constants.KeyC:
+		if c.trace == nil {
+			for _, s := range c.live {
+				s.Points = nil
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Component) updateForm(km tea.KeyMsg) tea.Cmd {
+	switch km.String() {
+	case constants.KeyEsc:
+		c.form = nil
+		if len(c.filters) == 0 && c.trace == nil {
+			return c.back()
+		}
+		return nil
+	case constants.KeyEnter:
+		filters, path := c.form.Filters(), c.form.Path()
+		if len(filters) == 0 {
+			c.form.errMsg = "at least one topic filter required"
+			return nil
+		}
+		if c.trace != nil {
+			p, err := jsonpath.Compile(path)
+			if err != nil {
+				c.form.errMsg = err.Error()
+				return nil
+			}
+			// re-extract the stored samples with the new settings
+			c.path = p
+			c.trace = c.Samples(filters, c.samples)
+			c.form = nil
+			return nil
+		}
+		if err := c.Configure(filters, path); err != nil {
+			c.form.errMsg = err.Error()
+			return nil
+		}
+		c.form = nil
+		return nil
+	}
+	f, cmd := c.form.Update(km)
+	c.form = &f
+	return cmd
+}
+
+func (c *Component) View() string {
+	c.api.ResetElemPos()
+	c.api.SetElemPos(ID, 1)
+	width := c.api.Width() - 2
+	height := max(c.api.Height()-4, 6)
+	var content, help string
+	if c.form != nil {
+		content = c.form.View()
+		help = "[enter] apply  [tab] next field  [esc] cancel"
+	} else {
+		series := c.live
+		if c.trace != nil {
+			series = c.trace
+		}
+		content = Render(series, width-2, height-1)
+		help = "[e] edit  [p] pause  [c] clear  [esc] back"
+		if c.trace != nil {
+			help = "[e] edit  [esc] back"
+		} else if c.paused {
+			help = "paused  " + help
+		}
+	}
+	content += "\n" + ui.InfoStyle.Render(help)
+	view := ui.LegendBox(content, c.title, width, 0, ui.ColBlue, c.api.FocusedID() == ID, -1)
+	return c.api.OverlayHelp(view)
+}
+
+func (c *Component) Focus() tea.Cmd { return nil }
+
+func (c *Component) Blur() {}
+
+// Focusables exposes focusable elements for the chart component.
+func (c *Component) Focusables() map[string]focus.Focusable {
+	return map[string]focus.Focusable{ID: &focus.NullFocusable{}}
+}
@@ -0,0 +1,71 @@
+package charts
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+type stubAPI struct{}
+
+func (stubAPI) SetModeClient() tea.Cmd         { return nil }
+func (stubAPI) SetModeTracer() tea.Cmd         { return nil }
+func (stubAPI) SubscribedTopics() []string     { return []string{"sensors/#"} }
+func (stubAPI) FocusedID() string              { return ID }
+func (stubAPI) ResetElemPos()                  {}
+func (stubAPI) SetElemPos(string, int)         {}
+func (stubAPI) OverlayHelp(view string) string { return view }
+func (stubAPI) Width() int                     { return 80 }
+func (stubAPI) Height() int                    { return 20 }
+
+func TestComponentLiveSeries(t *testing.T) {
+	c := New(stubAPI{})
+	c.OpenLive()
+	if c.form == nil || c.form.Filters()[0] != "sensors/#" {
+		t.Fatalf("expected form prefilled with subscribed topics")
+	}
+	if err := c.Configure([]string{"sensors/+/temp"}, "$.v"); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	base := time.Now()
+	c.Add(Sample{Time: base, Topic: "sensors/a/temp", Payload: `{"v":1}`})
+	c.Add(Sample{Time: base.Add(time.Second), Topic: "sensors/a/temp", Payload: `{"v":3}`})
+	c.Add(Sample{Time: base, Topic: "other", Payload: `{"v":9}`})
+	if len(c.live[0].Points) != 2 {
+		t.Fatalf("expected 2 points, got %d", len(c.live[0].Points))
+	}
+	c.form = nil
+	out := ansi.Strip(c.View())
+	if !strings.Contains(out, "min 1  max 3  avg 2  last 3  n=2") {
+		t.Fatalf("legend missing from view:\n%s", out)
+	}
+	hasBraille := false
+	for _, r := range out {
+		if r > 0x2800 && r <= 0x28ff {
+			hasBraille = true
+		}
+	}
+	if !hasBraille {
+		t.Fatalf("expected braille chart in view:\n%s", out)
+	}
+}
+
+func TestComponentTraceSamples(t *testing.T) {
+	c := New(stubAPI{})
+	base := time.Now()
+	c.OpenTrace("run1", []string{"a"}, []Sample{
+		{Time: base, Topic: "a", Payload: "1"},
+		{Time: base.Add(time.Second), Topic: "a", Payload: "x"},
+		{Time: base.Add(2 * time.Second), Topic: "a", Payload: "2"},
+	})
+	if len(c.trace) != 1 || len(c.trace[0].Points) != 2 {
+		t.Fatalf("unexpected trace series %+v", c.trace)
+	}
+	c.Add(Sample{Time: base, Topic: "a", Payload: "5"})
+	if len(c.trace[0].Points) != 2 {
+		t.Fatalf("live samples must not alter trace series")
+	}
+}
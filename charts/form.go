@@ -0,0 +1,70 @@
+package charts
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/marang/emqutiti/ui"
+)
+
+type chartForm struct {
+	ui.Form
+	errMsg string
+}
+
+const (
+	idxFilters = iota
+	idxPath
+)
+
+// newChartForm builds the form used to configure the plotted value.
+func newChartForm(filters []string, path string) chartForm {
+	fields := []ui.Field{
+		ui.NewTextField(strings.Join(filters, ","), "sensors/+/temp,gw/#"),
+		ui.NewTextField(path, "$.value (empty: whole payload)"),
+	}
+	f := chartForm{Form: ui.Form{Fields: fields, Focus: 0}}
+	f.ApplyFocus()
+	return f
+}
+
+// Update handles input for the focused field.
+func (f chartForm) Update(msg tea.Msg) (chartForm, tea.Cmd) {
+	if km, ok := msg.(tea.KeyMsg); ok {
+		f.CycleFocus(km)
+	}
+	f.ApplyFocus()
+	return f, f.Fields[f.Focus].Update(msg)
+}
+
+// Filters returns the comma separated topic filters.
+func (f chartForm) Filters() []string {
+	var out []string
+	for _, p := range strings.Split(f.Fields[idxFilters].Value(), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Path returns the JSON path expression.
+func (f chartForm) Path() string { return strings.TrimSpace(f.Fields[idxPath].Value()) }
+
+// View renders the form fields.
+func (f chartForm) View() string {
+	labels := []string{"Topic filters", "JSON path"}
+	var b strings.Builder
+	for i, fld := range f.Fields {
+		label := labels[i]
+		if i == f.Focus {
+			label = ui.FocusedStyle.Render(label)
+		}
+		b.WriteString(label + ": " + fld.View() + "\n")
+	}
+	if f.errMsg != "" {
+		b.WriteString("\n" + ui.ErrorStyle.Render(f.errMsg) + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package charts
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/marang/emqutiti/ui"
+)
+
+// seriesColors cycles through colors for multiple series.
+var seriesColors = []lipgloss.Color{ui.ColCyan, ui.ColPink, ui.ColGreen, ui.ColWarn, ui.ColBlue, ui.ColPurple}
+
+// formatValue renders a value compactly for axis labels and stats.
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
+
+// Render draws series as a braille line chart of the given size, including
+// the y-axis range, the covered time span and a min/max/avg legend.
+func Render(series []*Series, width, height int) string {
+	legend := legendLines(series)
+	chartH := height - 1 - len(legend)
+	if chartH < 1 {
+		chartH = 1
+	}
+	_, _, lo, hi, ok := bounds(series)
+	if !ok {
+		return "waiting for numeric values…\n" + strings.Join(legend, "\n")
+	}
+	top, bottom := formatValue(hi), formatValue(lo)
+	labelW := max(len(top), len(bottom))
+	chartW := width - labelW - 1
+	c, _, _, t0, t1, _ := plot(series, chartW, chartH)
+
+	axis := lipgloss.NewStyle().Foreground(ui.ColGray)
+	var rows []string
+	for y := 0; y < c.h; y++ {
+		label := ""
+		switch y {
+		case 0:
+			label = top
+		case c.h - 1:
+			label = bottom
+		}
+		var b strings.Builder
+		b.WriteString(axis.Render(fmt.Sprintf("%*s│", labelW, label)))
+		for x := 0; x < c.w; x++ {
+			r := c.cells[y][x]
+			if r == 0 {
+				b.WriteByte(' ')
+				continue
+			}
+			col := seriesColors[c.owner[y][x]%len(seriesColors)]
+			b.WriteString(lipgloss.NewStyle().Foreground(col).Render(string(0x2800 + r)))
+		}
+		rows = append(rows, b.String())
+	}
+	start := t0.Format("15:04:05")
+	end := t1.Format("15:04:05")
+	gap := max(chartW-len(start)-len(end), 1)
+	rows = append(rows, axis.Render(strings.Repeat(" ", labelW+1)+start+strings.Repeat(" ", gap)+end))
+	rows = append(rows, legend...)
+	return strings.Join(rows, "\n")
+}
+
+// legendLines describes each series with its statistics.
+func legendLines(series []*Series) []string {
+	var out []string
+	for i, s := range series {
+		col := seriesColors[i%len(seriesColors)]
+		st := s.Stats()
+		line := fmt.Sprintf("%s  n=%d", s.Filter, st.Count)
+		if st.Count > 0 {
+			line = fmt.Sprintf("%s  min %s  max %s  avg %s  last %s  n=%d", s.Filter,
+				formatValue(st.Min), formatValue(st.Max), formatValue(st.Avg), formatValue(st.Last), st.Count)
+		}
+		out = append(out, lipgloss.NewStyle().Foreground(col).Render("● ")+line)
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+package charts
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marang/emqutiti/internal/jsonpath"
+)
+
+// maxPoints bounds the number of samples kept per live series.
+const maxPoints = 2048
+
+// Point is a single numeric sample.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Sample is a raw message that may contain a chartable value.
+type Sample struct {
+	Time    time.Time
+	Topic   string
+	Payload string
+}
+
+// Series holds the samples collected for one topic filter.
+type Series struct {
+	Filter string
+	Points []Point
+}
+
+// Add appends a point, dropping the oldest once maxPoints is reached.
+func (s *Series) Add(p Point) {
+	s.Points = append(s.Points, p)
+	if len(s.Points) > maxPoints {
+		s.Points = append([]Point(nil), s.Points[len(s.Points)-maxPoints:]...)
+	}
+}
+
+// Stats summarizes a series.
+type Stats struct {
+	Min, Max, Avg, Last float64
+	Count               int
+}
+
+// Stats returns min, max, average and last value of the series.
+func (s *Series) Stats() Stats {
+	st := Stats{Min: math.Inf(1), Max: math.Inf(-1)}
+	var sum float64
+	for _, p := range s.Points {
+		st.Min = math.Min(st.Min, p.Value)
+		st.Max = math.Max(st.Max, p.Value)
+		sum += p.Value
+	}
+	st.Count = len(s.Points)
+	if st.Count == 0 {
+		return Stats{}
+	}
+	st.Avg = sum / float64(st.Count)
+	st.Last = s.Points[st.Count-1].Value
+	return st
+}
+
+// Extract reads a numeric value from payload. When path is empty the whole
+// payload must be a number; otherwise path is evaluated against JSON.
+// Numeric strings and booleans (1/0) are accepted.
+func Extract(payload string, path jsonpath.Path) (float64, bool) {
+	if path.String() == "" {
+		return toFloat(strings.TrimSpace(payload))
+	}
+	var doc interface{}
+	if err := json.Unmarshal([]byte(payload), &doc); err != nil {
+		return 0, false
+	}
+	vals := path.Eval(doc)
+	if len(vals) == 0 {
+		return 0, false
+	}
+	return toFloat(vals[0])
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case bool:
+		if t {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, false
+		}
+		return f, true
+	}
+	return 0, false
+}
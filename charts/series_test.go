@@ -0,0 +1,48 @@
+package charts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marang/emqutiti/internal/jsonpath"
+)
+
+func TestExtract(t *testing.T) {
+	p, _ := jsonpath.Compile("$.temp")
+	tests := []struct {
+		payload string
+		path    jsonpath.Path
+		want    float64
+		ok      bool
+	}{
+		{"21.5", jsonpath.Path{}, 21.5, true},
+		{" 7 ", jsonpath.Path{}, 7, true},
+		{"abc", jsonpath.Path{}, 0, false},
+		{`{"temp":3}`, p, 3, true},
+		{`{"temp":"4.5"}`, p, 4.5, true},
+		{`{"temp":true}`, p, 1, true},
+		{`{"hum":3}`, p, 0, false},
+		{`not json`, p, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := Extract(tt.payload, tt.path)
+		if ok != tt.ok || got != tt.want {
+			t.Fatalf("Extract(%q) = %v %v, want %v %v", tt.payload, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSeriesStatsAndLimit(t *testing.T) {
+	s := &Series{Filter: "a"}
+	base := time.Now()
+	for i := 0; i < maxPoints+10; i++ {
+		s.Add(Point{Time: base.Add(time.Duration(i) * time.Second), Value: float64(i)})
+	}
+	if len(s.Points) != maxPoints {
+		t.Fatalf("expected %d points, got %d", maxPoints, len(s.Points))
+	}
+	st := s.Stats()
+	if st.Min != 10 || st.Max != float64(maxPoints+9) || st.Last != st.Max || st.Count != maxPoints {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+}
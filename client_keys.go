@@ -63,12 +63,24 @@ func (m *model) HandleClientKey(msg tea.KeyMsg) tea.Cmd {
 		return m.handlePublishRetainKey()
 	case constants.KeyCtrlS:
 		return m.handlePublishKey()
+	case constants.KeyCtrlO:
+		return m.handlePublishFileKey()
+	case constants.KeyCtrlG:
+		return m.handleChartKey()
+	case constants.KeyCtrlU:
+		return m.handleStatsKey()
 	case constants.KeyEnter:
 		return m.handleEnterKey()
 	case constants.KeyP:
 		return m.handleTogglePublishKey()
 	case constants.KeyA:
 		return m.handleArchiveKey()
+	case constants.KeyS:
+		return m.handleHistorySortKey()
+	case constants.KeyC:
+		return m.handleHistoryColumnsKey()
+	case constants.KeyX:
+		return m.handleHistoryExportKey()
 	case constants.KeyDelete:
 		return m.handleDeleteKey()
 	default:
@@ -164,6 +176,26 @@ func (m *model) handlePublishRetainKey() tea.Cmd {
 	return nil
 }
 
+// handlePublishFileKey opens the file publish form for the selected topic.
+func (m *model) handlePublishFileKey() tea.Cmd {
+	topic := ""
+	sel := m.topics.Selected()
+	if sel >= 0 && sel < len(m.topics.Items) {
+		topic = m.topics.Items[sel].Name
+	}
+	return tea.Batch(m.SetMode(constants.ModePublishFile), m.filePublish.Open(topic))
+}
+
+// handleChartKey opens the live chart view.
+func (m *model) handleChartKey() tea.Cmd {
+	return tea.Batch(m.SetMode(constants.ModeChart), m.chart.OpenLive())
+}
+
+// handleStatsKey opens the traffic statistics view.
+func (m *model) handleStatsKey() tea.Cmd {
+	return tea.Batch(m.SetMode(constants.ModeStats), m.stats.Open())
+}
+
 // handleDeleteKey dispatches deletion based on focus.
 func (m *model) handleDeleteKey() tea.Cmd {
 	switch m.ui.focusOrder[m.ui.focusIndex] {
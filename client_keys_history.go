@@ -1,6 +1,7 @@
 package emqutiti
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -92,7 +93,14 @@ func (m *model) handleClearFilterKey() tea.Cmd {
 	return nil
 }
 
-// handleHistoryViewKey opens a detail view for long history payloads.
+// isJSONContainer reports whether s holds a JSON object or array.
+func isJSONContainer(s string) bool {
+	s = strings.TrimSpace(s)
+	return s != "" && (s[0] == '{' || s[0] == '[') && json.Valid([]byte(s))
+}
+
+// handleHistoryViewKey opens a detail view for long or structured history
+// payloads.
 func (m *model) handleHistoryViewKey() tea.Cmd {
 	if m.ui.focusOrder[m.ui.focusIndex] != idHistory {
 		return nil
@@ -102,11 +110,49 @@ func (m *model) handleHistoryViewKey() tea.Cmd {
 		return nil
 	}
 	hi := m.history.List().Items()[idx].(history.Item)
-	if utf8.RuneCountInString(hi.Payload) <= historyPreviewLimit {
+	if utf8.RuneCountInString(hi.Payload) <= historyPreviewLimit && !isJSONContainer(hi.Payload) {
 		return nil
 	}
-	m.history.SetDetailItem(hi)
-	m.history.Detail().SetContent(hi.Payload)
-	m.history.Detail().SetYOffset(0)
+	m.history.OpenDetail(hi)
 	return m.SetMode(constants.ModeHistoryDetail)
 }
+
+// handleHistorySortKey cycles the history sort column.
+func (m *model) handleHistorySortKey() tea.Cmd {
+	if m.ui.focusOrder[m.ui.focusIndex] == idHistory {
+		m.history.CycleSort()
+	}
+	return nil
+}
+
+// handleHistoryColumnsKey opens the extraction column editor.
+func (m *model) handleHistoryColumnsKey() tea.Cmd {
+	if m.ui.focusOrder[m.ui.focusIndex] != idHistory {
+		return nil
+	}
+	return tea.Batch(m.SetMode(constants.ModeHistoryColumns), m.history.StartColumns())
+}
+
+// handleHistoryExportKey writes selected or all shown history items to CSV.
+func (m *model) handleHistoryExportKey() tea.Cmd {
+	if m.ui.focusOrder[m.ui.focusIndex] != idHistory {
+		return nil
+	}
+	items := m.history.Items()
+	var selected []history.Item
+	for _, it := range items {
+		if it.IsSelected != nil && *it.IsSelected {
+			selected = append(selected, it)
+		}
+	}
+	if len(selected) > 0 {
+		items = selected
+	}
+	path := history.ExportPath(m.connections.Active, "history")
+	msg := fmt.Sprintf("Exported %d item(s) to %s", len(items), path)
+	if err := m.history.Export(path, items); err != nil {
+		msg = fmt.Sprintf("export error: %v", err)
+	}
+	m.history.Append("", msg, "log", false, msg)
+	return nil
+}
@@ -4,10 +4,30 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// commands lists the subcommands accepted before the flags.
+var commands = map[string]bool{"daemon": true, "annotate": true, "search": true, "import": true, "profiles": true}
+
 type AppConfig struct {
+	// Command is the subcommand, e.g. "daemon", or empty. Action is the
+	// second word of commands like "profiles export".
+	Command string
+	Action  string
+	// Args are the positional arguments, e.g. profile names.
+	Args []string
+	// Note is the annotation text of the annotate command or the query of
+	// the search command.
+	Note   string
+	NoteAt string
+
+	// ConnectURL is a broker URL given instead of a subcommand, connected
+	// with an unsaved profile.
+	ConnectURL string
+
 	ImportFile  string
 	ProfileName string
 	TraceKey    string
@@ -15,10 +35,78 @@ type AppConfig struct {
 	TraceStart  string
 	TraceEnd    string
 	Timeout     time.Duration
+
+	TriggerTopic string
+	TriggerWhen  string
+	StopTopic    string
+	StopWhen     string
+	StopAfter    int
+	MaxDuration  time.Duration
+	PreTrigger   time.Duration
+
+	ImportTemplate string
+	ImportMapping  string
+	ImportPreset   string
+	ImportPayload  string
+	ReadOptions    string
+	PublishOptions string
+	DryRun         bool
+
+	BundleFile    string
+	BundleSecrets bool
+	OnConflict    string
+	ProfileFormat string
+	// AllowCommands keeps the password commands of imported profiles.
+	AllowCommands bool
+
+	PublishFile  string
+	PublishTopic string
+	ChunkSize    int
+	ChunkTopic   string
+	QoS          int
+	Retain       bool
+	// QoSSet and RetainSet report whether --qos and --retain were given.
+	QoSSet    bool
+	RetainSet bool
+}
+
+// qosFlag is an int flag limited to the MQTT QoS levels, checked before it
+// is converted to a byte.
+type qosFlag int
+
+func (q *qosFlag) String() string {
+	if q == nil {
+		return "0"
+	}
+	return strconv.Itoa(int(*q))
+}
+
+func (q *qosFlag) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	if n < 0 || n > 2 {
+		return fmt.Errorf("qos must be 0, 1 or 2, got %d", n)
+	}
+	*q = qosFlag(n)
+	return nil
 }
 
 func ParseFlags() AppConfig {
 	var cfg AppConfig
+	args := os.Args[1:]
+	if len(args) > 0 && strings.Contains(args[0], "://") {
+		cfg.ConnectURL = args[0]
+		args = args[1:]
+	} else if len(args) > 0 && commands[args[0]] {
+		cfg.Command = args[0]
+		args = args[1:]
+		if cfg.Command == "profiles" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+			cfg.Action = args[0]
+			args = args[1:]
+		}
+	}
 	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	fs.StringVar(&cfg.ImportFile, "import", "", "Launch import wizard with optional file path")
 	fs.StringVar(&cfg.ImportFile, "i", "", "(shorthand)")
@@ -28,10 +116,43 @@ func ParseFlags() AppConfig {
 	fs.StringVar(&cfg.TraceTopics, "topics", "", "Comma-separated topics to trace")
 	fs.StringVar(&cfg.TraceStart, "start", "", "Optional RFC3339 trace start time")
 	fs.StringVar(&cfg.TraceEnd, "end", "", "Optional RFC3339 trace end time")
+	fs.StringVar(&cfg.TriggerTopic, "trigger-topic", "", "Start recording on a message matching this topic filter")
+	fs.StringVar(&cfg.TriggerWhen, "trigger-when", "", "Payload condition for the start message")
+	fs.StringVar(&cfg.StopTopic, "stop-topic", "", "Stop recording on a message matching this topic filter")
+	fs.StringVar(&cfg.StopWhen, "stop-when", "", "Payload condition for the stop message")
+	fs.IntVar(&cfg.StopAfter, "stop-after", 0, "Stop after N recorded messages")
+	fs.DurationVar(&cfg.MaxDuration, "max-duration", 0, "Stop this long after recording began")
+	fs.DurationVar(&cfg.PreTrigger, "pre-trigger", 0, "Keep messages received this long before the start trigger")
+	fs.StringVar(&cfg.NoteAt, "at", "", "Optional RFC3339 time of an annotation")
+	fs.StringVar(&cfg.ImportTemplate, "template", "", "Topic template of a headless import")
+	fs.StringVar(&cfg.ImportMapping, "mapping", "", "importer.toml style file with mapping and template")
+	fs.StringVar(&cfg.ImportPreset, "preset", "", "Saved import preset, or auto to match the file headers")
+	fs.StringVar(&cfg.ImportPayload, "payload", "", "Go template rendering the payload of a headless import")
+	fs.StringVar(&cfg.ReadOptions, "read-options", "", "Import file options, e.g. \"delimiter=; encoding=latin1\"")
+	fs.StringVar(&cfg.PublishOptions, "publish-options", "", "Import publish options, e.g. \"rate=50 burst=10\"")
+	fs.BoolVar(&cfg.DryRun, "dry-run", false, "Print the import messages instead of publishing them")
+	fs.StringVar(&cfg.BundleFile, "out", "emqutiti-profiles.toml", "File profiles are exported to")
+	fs.BoolVar(&cfg.BundleSecrets, "secrets", false, "Export passwords encrypted with a passphrase")
+	fs.StringVar(&cfg.OnConflict, "on-conflict", "skip", "Imported profiles with existing names: skip, overwrite or rename")
+	fs.StringVar(&cfg.ProfileFormat, "from", "", "Format of imported profiles: bundle, mqttx, mqtt-explorer or mosquitto")
+	fs.BoolVar(&cfg.AllowCommands, "allow-commands", false, "Keep the password commands of imported profiles")
 	fs.DurationVar(&cfg.Timeout, "timeout", 0, "Optional overall runtime limit (e.g., 30s)")
+	fs.StringVar(&cfg.PublishFile, "file", "", "Publish the contents of FILE and exit")
+	fs.StringVar(&cfg.PublishTopic, "topic", "", "Topic to publish the file to")
+	fs.IntVar(&cfg.ChunkSize, "chunk-size", 0, "Split the file into chunks of N bytes")
+	fs.StringVar(&cfg.ChunkTopic, "chunk-topic", "", "Per-chunk topic template")
+	fs.Var((*qosFlag)(&cfg.QoS), "qos", "QoS level 0-2 for published messages")
+	fs.BoolVar(&cfg.Retain, "retain", false, "Publish messages with the retained flag")
 	fs.Usage = func() {
 		w := fs.Output()
-		fmt.Fprintf(w, "Usage: %s [flags]\n\n", os.Args[0])
+		fmt.Fprintf(w, "Usage: %s [flags]\n", os.Args[0])
+		fmt.Fprintf(w, "       %s URL  Connect without a saved profile, e.g. 'mqtts://user@host:8883/?topics=a/#'\n", os.Args[0])
+		fmt.Fprintf(w, "       %s daemon [--timeout D] Run all planned traces from config.toml\n", os.Args[0])
+		fmt.Fprintf(w, "       %s annotate --trace KEY [-p NAME] [--at TIME] TEXT  Annotate a trace\n", os.Args[0])
+		fmt.Fprintf(w, "       %s search QUERY  Search all traces, e.g. 'tag=plant topic=sensors/# valve'\n", os.Args[0])
+		fmt.Fprintf(w, "       %s import --file FILE [-p NAME] [--template T] [--dry-run]  Import without the wizard\n", os.Args[0])
+		fmt.Fprintf(w, "       %s profiles export [--out FILE] [--secrets] [NAME...]  Export profiles to share them\n", os.Args[0])
+		fmt.Fprintf(w, "       %s profiles import [--from FORMAT] [--on-conflict P] [--dry-run] FILE  Import profiles\n\n", os.Args[0])
 		fmt.Fprintln(w, "General:")
 		fmt.Fprintln(w, "  -i, --import FILE     Launch import wizard with optional file path (e.g., -i data.csv)")
 		fmt.Fprintln(w, "  -p, --profile NAME    Connection profile name to use (e.g., -p local)")
@@ -41,7 +162,50 @@ func ParseFlags() AppConfig {
 		fmt.Fprintln(w, "      --topics LIST     Comma-separated topics to trace (e.g., --topics \"sensors/#\")")
 		fmt.Fprintln(w, "      --start TIME      Optional RFC3339 trace start time (e.g., --start \"2025-08-05T11:47:00Z\")")
 		fmt.Fprintln(w, "      --end TIME        Optional RFC3339 trace end time (e.g., --end \"2025-08-05T11:49:00Z\")")
+		fmt.Fprintln(w, "      --trigger-topic F Start recording on a message matching filter F")
+		fmt.Fprintln(w, "      --trigger-when C  Start condition: text, /regex/ or JSON comparison (e.g., '$.temp > 30')")
+		fmt.Fprintln(w, "      --stop-topic F    Stop recording on a message matching filter F")
+		fmt.Fprintln(w, "      --stop-when C     Stop condition, same syntax as --trigger-when")
+		fmt.Fprintln(w, "      --stop-after N    Stop after N recorded messages")
+		fmt.Fprintln(w, "      --max-duration D  Stop D after recording began (e.g., --max-duration 5m)")
+		fmt.Fprintln(w, "      --pre-trigger D   Also keep messages from D before the start (e.g., --pre-trigger 10s)")
+		fmt.Fprintln(w, "")
+		fmt.Fprintln(w, "Import (with the import command):")
+		fmt.Fprintln(w, "      --template T      Topic template (e.g., --template \"dev/{id}\"), defaults to the saved one")
+		fmt.Fprintln(w, "      --mapping FILE    Mapping, template and options in the importer.toml format")
+		fmt.Fprintln(w, "      --preset NAME     Saved import preset, or auto for the one matching the headers")
+		fmt.Fprintln(w, "      --payload T       Go template for non-JSON payloads")
+		fmt.Fprintln(w, "      --read-options O  File options (e.g., --read-options \"delimiter=; encoding=latin1\")")
+		fmt.Fprintln(w, "      --publish-options O  Rate and pacing (e.g., --publish-options \"rate=50 burst=10\")")
+		fmt.Fprintln(w, "      --dry-run         Print messages instead of publishing; --file, --qos, --retain below apply")
+		fmt.Fprintln(w, "")
+		fmt.Fprintln(w, "Profiles (with the profiles command):")
+		fmt.Fprintln(w, "      --out FILE        Export file (default emqutiti-profiles.toml)")
+		fmt.Fprintln(w, "      --secrets         Include passwords encrypted with a passphrase from")
+		fmt.Fprintln(w, "                        EMQUTITI_BUNDLE_PASSPHRASE or the terminal")
+		fmt.Fprintln(w, "      --on-conflict P   skip, overwrite or rename imported profiles with existing names")
+		fmt.Fprintln(w, "      --from FORMAT     bundle, mqttx, mqtt-explorer or mosquitto; detected when omitted")
+		fmt.Fprintln(w, "      --allow-commands  Keep password commands of imported profiles, run when connecting")
+		fmt.Fprintln(w, "      --dry-run         Preview imported profiles without saving them")
+		fmt.Fprintln(w, "")
+		fmt.Fprintln(w, "Publish:")
+		fmt.Fprintln(w, "      --file FILE       Publish the contents of FILE, text or binary (e.g., --file fw.bin)")
+		fmt.Fprintln(w, "      --topic TOPIC     Topic to publish to (e.g., --topic devices/42/firmware)")
+		fmt.Fprintln(w, "      --chunk-size N    Split the file into N byte chunks (e.g., --chunk-size 4096)")
+		fmt.Fprintln(w, "      --chunk-topic T   Per-chunk topic template using {topic} {index} {seq} {total} {name}")
+		fmt.Fprintln(w, "      --qos N           QoS level 0-2 for published messages")
+		fmt.Fprintln(w, "      --retain          Publish with the retained flag")
 	}
-	_ = fs.Parse(os.Args[1:])
+	_ = fs.Parse(args)
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "qos":
+			cfg.QoSSet = true
+		case "retain":
+			cfg.RetainSet = true
+		}
+	})
+	cfg.Args = fs.Args()
+	cfg.Note = strings.Join(fs.Args(), " ")
 	return cfg
 }
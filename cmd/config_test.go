@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+)
+
+func TestQoSFlagRange(t *testing.T) {
+	for _, bad := range []string{"-1", "3", "256", "x"} {
+		var q qosFlag
+		if err := q.Set(bad); err == nil {
+			t.Fatalf("expected --qos %s to be rejected", bad)
+		}
+	}
+	old := os.Args
+	defer func() { os.Args = old }()
+	os.Args = []string{"emqutiti", "--file", "fw.bin", "--qos", "2"}
+	if cfg := ParseFlags(); cfg.QoS != 2 || !cfg.QoSSet || cfg.RetainSet {
+		t.Fatalf("expected only qos 2 to be set, got %+v", cfg)
+	}
+	os.Args = []string{"emqutiti", "import", "--file", "data.csv", "--retain=false"}
+	if cfg := ParseFlags(); cfg.QoSSet || !cfg.RetainSet || cfg.Retain {
+		t.Fatalf("expected only retain=false to be set, got %+v", cfg)
+	}
+}
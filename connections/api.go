@@ -33,6 +33,8 @@ type API interface {
 	SetConnectionMessage(string)
 	Active() string
 	BeginAdd()
+	// BeginAddChild opens the form for a new profile inheriting from parent.
+	BeginAddChild(parent string)
 	BeginEdit(index int)
 	BeginDelete(index int)
 	Connect(p Profile) tea.Cmd
@@ -45,6 +47,12 @@ type API interface {
 	SetConnecting(name string)
 	SetConnected(name string)
 	SetDisconnected(name, detail string)
+	// ReloadProfiles rereads the profiles and saved state from config.toml,
+	// e.g. after an import.
+	ReloadProfiles()
+	// AddEphemeral lists an unsaved profile with its topics and returns it
+	// under its final name.
+	AddEphemeral(p Profile, topics []TopicSnapshot) Profile
 }
 
 // Navigator exposes navigation helpers required by the component.
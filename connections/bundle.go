@@ -0,0 +1,370 @@
+package connections
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// BundleVersion is the format version of written bundles.
+const BundleVersion = 1
+
+// bundleIterations is the PBKDF2 work factor deriving the secrets key.
+const bundleIterations = 600_000
+
+// Bundle is a portable set of profiles with their saved topics and payloads
+// for sharing setups. Passwords are only included when encrypted with a
+// passphrase.
+type Bundle struct {
+	Version int `toml:"version"`
+	// Salt derives the key of the encrypted secrets.
+	Salt     string          `toml:"salt,omitempty"`
+	Profiles []BundleProfile `toml:"profiles"`
+}
+
+// BundleProfile is a profile in a bundle.
+type BundleProfile struct {
+	Profile  Profile           `toml:"profile"`
+	Topics   []TopicSnapshot   `toml:"topics,omitempty"`
+	Payloads []PayloadSnapshot `toml:"payloads,omitempty"`
+	// Secret is the password encrypted with the bundle passphrase.
+	Secret string `toml:"secret,omitempty"`
+}
+
+// NewBundle bundles the named profiles and their parents, or all when names
+// is empty, with their saved state. Passwords are encrypted with passphrase or left out
+// when it is empty, together with password commands.
+func NewBundle(profiles []Profile, saved map[string]ConnectionSnapshot, names []string, passphrase string) (Bundle, error) {
+	b := Bundle{Version: BundleVersion}
+	var aead cipher.AEAD
+	if passphrase != "" {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return b, err
+		}
+		var err error
+		if aead, err = bundleCipher(passphrase, salt); err != nil {
+			return b, err
+		}
+		b.Salt = base64.StdEncoding.EncodeToString(salt)
+	}
+	want := map[string]bool{}
+	for _, n := range names {
+		want[n] = true
+	}
+	missing := maps.Clone(want)
+	// children need their parents
+	for _, n := range names {
+		for p := findProfile(profiles, n); p != nil && p.Parent != "" && !want[p.Parent]; p = findProfile(profiles, p.Parent) {
+			want[p.Parent] = true
+		}
+	}
+	refs := savedSecretRefs()
+	for _, p := range profiles {
+		if len(want) > 0 && !want[p.Name] {
+			continue
+		}
+		delete(missing, p.Name)
+		bp := BundleProfile{Profile: p}
+		bp.Profile.Password = ""
+		if aead == nil {
+			dropCommand(&bp.Profile)
+		}
+		if s, ok := saved[p.Name]; ok {
+			bp.Topics, bp.Payloads = s.Topics, s.Payloads
+		}
+		if aead != nil && p.Password != "" && !p.FromEnv {
+			pw := p.Password
+			if pw == refs[p.Name] {
+				var err error
+				if pw, err = ResolveSecret(pw); err != nil {
+					return b, fmt.Errorf("password of %s: %w", p.Name, err)
+				}
+			}
+			secret, err := seal(aead, pw)
+			if err != nil {
+				return b, err
+			}
+			bp.Secret = secret
+		}
+		b.Profiles = append(b.Profiles, bp)
+	}
+	if len(missing) > 0 {
+		return b, fmt.Errorf("unknown profiles %s", strings.Join(slices.Sorted(maps.Keys(missing)), ", "))
+	}
+	return b, nil
+}
+
+// HasSecrets reports whether the bundle carries encrypted passwords.
+func (b Bundle) HasSecrets() bool {
+	for _, p := range b.Profiles {
+		if p.Secret != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Decrypt restores the passwords of the bundle's profiles.
+func (b *Bundle) Decrypt(passphrase string) error {
+	if !b.HasSecrets() {
+		return nil
+	}
+	salt, err := base64.StdEncoding.DecodeString(b.Salt)
+	if err != nil || len(salt) == 0 {
+		return fmt.Errorf("invalid bundle salt")
+	}
+	aead, err := bundleCipher(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	for i := range b.Profiles {
+		p := &b.Profiles[i]
+		if p.Secret == "" {
+			continue
+		}
+		pw, err := open(aead, p.Secret)
+		if err != nil {
+			return fmt.Errorf("wrong passphrase or damaged secret of %s", p.Profile.Name)
+		}
+		p.Profile.Password, p.Secret = pw, ""
+	}
+	return nil
+}
+
+func bundleCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, bundleIterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plain string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(plain), nil)), nil
+}
+
+func open(aead cipher.AEAD, secret string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", errors.New("invalid secret")
+	}
+	n := aead.NonceSize()
+	plain, err := aead.Open(nil, data[:n], data[n:], nil)
+	return string(plain), err
+}
+
+// WriteBundle stores b at path, readable only by the user when it carries
+// secrets.
+func WriteBundle(path string, b Bundle) error {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(b); err != nil {
+		return err
+	}
+	perm := os.FileMode(0o644)
+	if b.HasSecrets() {
+		perm = 0o600
+	}
+	return os.WriteFile(path, buf.Bytes(), perm)
+}
+
+// ReadBundle loads a bundle written by WriteBundle.
+func ReadBundle(path string) (Bundle, error) {
+	var b Bundle
+	if _, err := toml.DecodeFile(path, &b); err != nil {
+		return b, fmt.Errorf("failed to decode bundle: %w", err)
+	}
+	if b.Version == 0 || b.Version > BundleVersion {
+		return b, fmt.Errorf("unsupported bundle version %d", b.Version)
+	}
+	return b, nil
+}
+
+// Conflict decides what happens to imported profiles whose name exists.
+type Conflict int
+
+const (
+	// ConflictSkip keeps the existing profile.
+	ConflictSkip Conflict = iota
+	// ConflictOverwrite replaces the existing profile and its saved state.
+	ConflictOverwrite
+	// ConflictRename imports the profile under a free name.
+	ConflictRename
+)
+
+var conflictNames = []string{"skip", "overwrite", "rename"}
+
+func (c Conflict) String() string { return conflictNames[c] }
+
+// ParseConflict parses skip, overwrite or rename.
+func ParseConflict(s string) (Conflict, error) {
+	for i, n := range conflictNames {
+		if strings.EqualFold(strings.TrimSpace(s), n) {
+			return Conflict(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown conflict policy %q, use skip, overwrite or rename", s)
+}
+
+// MergeResult reports what happened to an imported profile.
+type MergeResult struct {
+	// Name is the profile's name in the bundle, As its name after import.
+	Name   string
+	As     string
+	Action string
+	// Note warns about a child whose bundled parent was skipped.
+	Note string
+}
+
+func (r MergeResult) String() string {
+	s := r.Name + ": " + r.Action
+	if r.Action == "renamed" {
+		s = fmt.Sprintf("%s: imported as %s", r.Name, r.As)
+	}
+	if r.Note != "" {
+		s += " (" + r.Note + ")"
+	}
+	return s
+}
+
+// MergeBundle adds the profiles of b to profiles and saved, resolving name
+// conflicts with policy. Children of renamed profiles follow the new name;
+// children of skipped ones inherit from the existing profile and say so.
+func MergeBundle(profiles []Profile, saved map[string]ConnectionSnapshot, b Bundle, policy Conflict) ([]Profile, map[string]ConnectionSnapshot, []MergeResult) {
+	if saved == nil {
+		saved = map[string]ConnectionSnapshot{}
+	}
+	index := func(name string) int {
+		for i, p := range profiles {
+			if p.Name == name {
+				return i
+			}
+		}
+		return -1
+	}
+	var results []MergeResult
+	renamed := map[string]string{}
+	skipped := map[string]bool{}
+	// imported maps the index of a merged profile to its result.
+	imported := map[int]int{}
+	for _, bp := range b.Profiles {
+		p := bp.Profile
+		res := MergeResult{Name: p.Name, As: p.Name, Action: "added"}
+		i := index(p.Name)
+		switch {
+		case i < 0:
+			profiles = append(profiles, p)
+		case policy == ConflictOverwrite:
+			profiles[i] = p
+			res.Action = "overwritten"
+		case policy == ConflictRename:
+			for n := 2; index(p.Name) >= 0; n++ {
+				p.Name = fmt.Sprintf("%s (%d)", bp.Profile.Name, n)
+			}
+			profiles = append(profiles, p)
+			res.As, res.Action = p.Name, "renamed"
+			renamed[bp.Profile.Name] = p.Name
+		default:
+			skipped[p.Name] = true
+			results = append(results, MergeResult{Name: p.Name, As: p.Name, Action: "skipped"})
+			continue
+		}
+		if i < 0 || policy == ConflictRename {
+			i = len(profiles) - 1
+		}
+		imported[i] = len(results)
+		if len(bp.Topics) > 0 || len(bp.Payloads) > 0 {
+			saved[p.Name] = ConnectionSnapshot{Topics: bp.Topics, Payloads: bp.Payloads}
+		} else if res.Action == "overwritten" {
+			delete(saved, p.Name)
+		}
+		results = append(results, res)
+	}
+	for i, r := range imported {
+		p := &profiles[i]
+		if n, ok := renamed[p.Parent]; ok {
+			p.Parent = n
+		} else if skipped[p.Parent] {
+			results[r].Note = "inherits from the existing " + p.Parent
+		}
+	}
+	return profiles, saved, results
+}
+
+// dropCommand removes the password command of p and an auth mode running it.
+func dropCommand(p *Profile) {
+	p.PasswordCommand = ""
+	if p.AuthMode == AuthCommand {
+		p.AuthMode = ""
+	}
+}
+
+// Commands lists the profiles of b that run a password command, one line
+// each with the command.
+func (b Bundle) Commands() []string {
+	var lines []string
+	for _, bp := range b.Profiles {
+		if c := bp.Profile.PasswordCommand; c != "" {
+			lines = append(lines, fmt.Sprintf("%s runs %q", bp.Profile.Name, c))
+		}
+	}
+	return lines
+}
+
+// ImportBundle merges b into config.toml. Passwords of the imported
+// profiles are moved to the secret store. Password commands are left out
+// unless commands is set, as they run in the shell when connecting.
+func ImportBundle(b Bundle, policy Conflict, commands bool) ([]MergeResult, error) {
+	var results []MergeResult
+	if !commands {
+		b.Profiles = slices.Clone(b.Profiles)
+		for i := range b.Profiles {
+			dropCommand(&b.Profiles[i].Profile)
+		}
+	}
+	err := updateConfig(func(cfg *userConfig) error {
+		imported := map[string]bool{}
+		refs := secretRefs(cfg.Profiles)
+		var merged []Profile
+		merged, cfg.Saved, results = MergeBundle(cfg.Profiles, cfg.Saved, b, policy)
+		for _, r := range results {
+			if r.Action != "skipped" {
+				imported[r.As] = true
+			}
+		}
+		for i := range merged {
+			p := &merged[i]
+			if !imported[p.Name] || p.FromEnv || p.Password == "" || p.Password == refs[p.Name] {
+				continue
+			}
+			ref, err := storePassword(p.Name, p.Username, p.Password)
+			if err != nil {
+				return fmt.Errorf("store password of %s: %w", p.Name, err)
+			}
+			p.Password = ref
+		}
+		cfg.Profiles = merged
+		return nil
+	})
+	return results, err
+}
@@ -0,0 +1,233 @@
+package connections
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/marang/emqutiti/constants"
+	"github.com/marang/emqutiti/ui"
+)
+
+// bundlePrompt collects the settings of a profile export or import in the
+// broker manager.
+type bundlePrompt struct {
+	export bool
+	inputs []textinput.Model
+	focus  int
+	err    string
+	// preview lists the profiles of an import awaiting confirmation.
+	preview []string
+}
+
+// defaultBundleFile is where bundles are written and read by default.
+func defaultBundleFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "emqutiti-profiles.toml"
+	}
+	return filepath.Join(home, "emqutiti-profiles.toml")
+}
+
+func promptInput(prompt, value, placeholder string) textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = prompt
+	ti.Placeholder = placeholder
+	ti.SetValue(value)
+	return ti
+}
+
+func newExportPrompt(selected string) *bundlePrompt {
+	pass := promptInput("Passphrase: ", "", "encrypts passwords, leave empty to export without them")
+	pass.EchoMode = textinput.EchoPassword
+	b := &bundlePrompt{export: true, inputs: []textinput.Model{
+		promptInput("Profiles: ", selected, "comma-separated names, empty for all"),
+		promptInput("File: ", defaultBundleFile(), ""),
+		pass,
+	}}
+	b.inputs[0].Focus()
+	return b
+}
+
+func newImportPrompt() *bundlePrompt {
+	pass := promptInput("Passphrase: ", "", "needed for bundles with passwords")
+	pass.EchoMode = textinput.EchoPassword
+	b := &bundlePrompt{inputs: []textinput.Model{
+		promptInput("File: ", defaultBundleFile(), ""),
+		promptInput("Format: ", "", "bundle, mqttx, mqtt-explorer or mosquitto, empty to detect"),
+		pass,
+		promptInput("On conflict: ", ConflictSkip.String(), "skip, overwrite or rename"),
+	}}
+	b.inputs[0].Focus()
+	return b
+}
+
+func (b *bundlePrompt) setFocus(i int) tea.Cmd {
+	b.inputs[b.focus].Blur()
+	b.focus = (i + len(b.inputs)) % len(b.inputs)
+	return b.inputs[b.focus].Focus()
+}
+
+func (b *bundlePrompt) View() string {
+	title := "Import profiles"
+	if b.export {
+		title = "Export profiles"
+	}
+	lines := []string{title}
+	for _, in := range b.inputs {
+		lines = append(lines, in.View())
+	}
+	if b.err != "" {
+		lines = append(lines, ui.FormError.Render(b.err))
+	}
+	if b.preview != nil {
+		lines = append(lines, b.preview...)
+		lines = append(lines, ui.InfoStyle.Render("[enter] save profiles  [up/down] edit  [esc] cancel"))
+		return strings.Join(lines, "\n")
+	}
+	lines = append(lines, ui.InfoStyle.Render("[enter] next/confirm  [up/down] move  [esc] cancel"))
+	return strings.Join(lines, "\n")
+}
+
+// updateBundle handles input while an export or import prompt is open.
+func (c *Component) updateBundle(msg tea.Msg) tea.Cmd {
+	b := c.bundle
+	if km, ok := msg.(tea.KeyMsg); ok {
+		switch km.String() {
+		case constants.KeyEsc:
+			c.bundle = nil
+			return nil
+		case constants.KeyUp:
+			b.preview = nil
+			return b.setFocus(b.focus - 1)
+		case constants.KeyDown:
+			b.preview = nil
+			return b.setFocus(b.focus + 1)
+		case constants.KeyEnter:
+			if b.focus < len(b.inputs)-1 {
+				return b.setFocus(b.focus + 1)
+			}
+			var note string
+			var err error
+			switch {
+			case b.export:
+				note, err = c.exportBundle()
+			case b.preview == nil:
+				b.preview, err = c.previewImport()
+				if err != nil {
+					b.err = err.Error()
+				} else {
+					b.err = ""
+				}
+				return nil
+			default:
+				note, err = c.importBundle()
+			}
+			if err != nil {
+				b.err = err.Error()
+				return nil
+			}
+			c.bundle = nil
+			c.note = note
+			log.Println(note)
+			return nil
+		}
+		b.preview = nil
+	}
+	var cmd tea.Cmd
+	b.inputs[b.focus], cmd = b.inputs[b.focus].Update(msg)
+	return cmd
+}
+
+func (c *Component) exportBundle() (string, error) {
+	in := c.bundle.inputs
+	var names []string
+	for _, n := range strings.Split(in[0].Value(), ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			names = append(names, n)
+		}
+	}
+	path := strings.TrimSpace(in[1].Value())
+	if path == "" {
+		return "", fmt.Errorf("file is required")
+	}
+	b, err := NewBundle(c.api.Manager().Profiles, LoadState(), names, in[2].Value())
+	if err != nil {
+		return "", err
+	}
+	if err := WriteBundle(path, b); err != nil {
+		return "", err
+	}
+	note := fmt.Sprintf("Exported %d profiles to %s", len(b.Profiles), path)
+	if !b.HasSecrets() {
+		note += " without passwords"
+	}
+	return note, nil
+}
+
+// readImport reads the profiles named in the import prompt.
+func (c *Component) readImport() (Bundle, []string, error) {
+	in := c.bundle.inputs
+	b, notes, err := ReadProfiles(strings.TrimSpace(in[0].Value()), strings.TrimSpace(in[1].Value()))
+	if err != nil {
+		return b, nil, err
+	}
+	if b.HasSecrets() {
+		if in[2].Value() == "" {
+			return b, nil, fmt.Errorf("the bundle has passwords, enter its passphrase")
+		}
+		if err := b.Decrypt(in[2].Value()); err != nil {
+			return b, nil, err
+		}
+	}
+	return b, notes, nil
+}
+
+// previewImport lists the profiles an import would save.
+func (c *Component) previewImport() ([]string, error) {
+	if _, err := ParseConflict(c.bundle.inputs[3].Value()); err != nil {
+		return nil, err
+	}
+	b, notes, err := c.readImport()
+	if err != nil {
+		return nil, err
+	}
+	if len(b.Profiles) == 0 {
+		return nil, fmt.Errorf("no profiles found")
+	}
+	lines := []string{fmt.Sprintf("%d profiles:", len(b.Profiles))}
+	lines = append(lines, b.Preview()...)
+	for _, n := range notes {
+		lines = append(lines, ui.InfoStyle.Render("note: "+n))
+	}
+	for _, c := range b.Commands() {
+		lines = append(lines, ui.InfoStyle.Render("note: "+c+", not imported"))
+	}
+	return lines, nil
+}
+
+func (c *Component) importBundle() (string, error) {
+	policy, err := ParseConflict(c.bundle.inputs[3].Value())
+	if err != nil {
+		return "", err
+	}
+	b, _, err := c.readImport()
+	if err != nil {
+		return "", err
+	}
+	results, err := ImportBundle(b, policy, false)
+	if err != nil {
+		return "", err
+	}
+	c.api.ReloadProfiles()
+	var parts []string
+	for _, r := range results {
+		parts = append(parts, r.String())
+	}
+	return "Imported profiles: " + strings.Join(parts, ", "), nil
+}
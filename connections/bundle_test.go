@@ -0,0 +1,197 @@
+package connections
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/zalando/go-keyring"
+)
+
+func TestBundleSecrets(t *testing.T) {
+	profiles := []Profile{
+		{Name: "lab", Host: "lab.local", Username: "u", Password: "pw"},
+		{Name: "prod", Host: "prod.local", Password: "hunter2"},
+	}
+	saved := map[string]ConnectionSnapshot{"lab": {Topics: []TopicSnapshot{{Title: "a/#", Subscribed: true}}}}
+
+	b, err := NewBundle(profiles, saved, []string{"lab"}, "")
+	if err != nil {
+		t.Fatalf("bundle: %v", err)
+	}
+	if len(b.Profiles) != 1 || b.Profiles[0].Profile.Password != "" || b.HasSecrets() || len(b.Profiles[0].Topics) != 1 {
+		t.Fatalf("unexpected bundle %+v", b)
+	}
+	if _, err := NewBundle(profiles, saved, []string{"nope"}, ""); err == nil {
+		t.Fatalf("expected unknown profile error")
+	}
+
+	b, err = NewBundle(profiles, saved, nil, "correct horse")
+	if err != nil {
+		t.Fatalf("bundle: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "bundle.toml")
+	if err := WriteBundle(path, b); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "hunter2") || strings.Contains(string(data), `"pw"`) {
+		t.Fatalf("bundle leaks passwords:\n%s", data)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0o600 {
+		t.Fatalf("expected private bundle, got %v", fi.Mode())
+	}
+	read, err := ReadBundle(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	wrong := read
+	wrong.Profiles = append([]BundleProfile(nil), read.Profiles...)
+	if err := wrong.Decrypt("wrong"); err == nil {
+		t.Fatalf("expected wrong passphrase error")
+	}
+	if err := read.Decrypt("correct horse"); err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if read.Profiles[0].Profile.Password != "pw" || read.Profiles[1].Profile.Password != "hunter2" {
+		t.Fatalf("passwords not restored: %+v", read.Profiles)
+	}
+}
+
+func TestMergeBundle(t *testing.T) {
+	existing := []Profile{{Name: "lab", Host: "old"}}
+	b := Bundle{Version: BundleVersion, Profiles: []BundleProfile{
+		{Profile: Profile{Name: "lab", Host: "new"}, Payloads: []PayloadSnapshot{{Topic: "t", Payload: "p"}}},
+		{Profile: Profile{Name: "prod", Host: "prod"}},
+	}}
+	for _, tc := range []struct {
+		policy Conflict
+		hosts  string
+		result string
+	}{
+		{ConflictSkip, "old,prod", "lab: skipped,prod: added"},
+		{ConflictOverwrite, "new,prod", "lab: overwritten,prod: added"},
+		{ConflictRename, "old,new,prod", "lab: imported as lab (2),prod: added"},
+	} {
+		profiles, saved, results := MergeBundle(append([]Profile(nil), existing...), nil, b, tc.policy)
+		var hosts, res []string
+		for _, p := range profiles {
+			hosts = append(hosts, p.Host)
+		}
+		for _, r := range results {
+			res = append(res, r.String())
+		}
+		if strings.Join(hosts, ",") != tc.hosts || strings.Join(res, ",") != tc.result {
+			t.Fatalf("%v: got %v %v", tc.policy, hosts, res)
+		}
+		if tc.policy == ConflictRename && len(saved["lab (2)"].Payloads) != 1 {
+			t.Fatalf("expected payloads under the new name, got %v", saved)
+		}
+	}
+	if _, err := ParseConflict("merge"); err == nil {
+		t.Fatalf("expected unknown policy error")
+	}
+}
+
+func TestMergeBundleKeepsChildrenWithTheirParent(t *testing.T) {
+	existing := []Profile{{Name: "lab", Host: "local"}}
+	b := Bundle{Version: BundleVersion, Profiles: []BundleProfile{
+		{Profile: Profile{Name: "sensor", Parent: "lab", ClientID: "s1"}},
+		{Profile: Profile{Name: "lab", Host: "shared"}},
+	}}
+	profiles, _, _ := MergeBundle(append([]Profile(nil), existing...), nil, b, ConflictRename)
+	child := findProfile(profiles, "sensor")
+	if child == nil || child.Parent != "lab (2)" || findProfile(profiles, "lab").Host != "local" {
+		t.Fatalf("child not moved to the renamed parent: %+v", profiles)
+	}
+	if r, err := ResolveProfile(*child, profiles); err != nil || r.Host != "shared" {
+		t.Fatalf("child inherits from %q, %v", r.Host, err)
+	}
+
+	_, _, results := MergeBundle(append([]Profile(nil), existing...), nil, b, ConflictSkip)
+	if got := results[0].String(); got != "sensor: added (inherits from the existing lab)" {
+		t.Fatalf("expected a warning for the skipped parent, got %q", got)
+	}
+}
+
+func TestImportBundleKeepsConfig(t *testing.T) {
+	keyring.MockInit()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	cfgPath := filepath.Join(home, ".config", "emqutiti", "config.toml")
+	os.MkdirAll(filepath.Dir(cfgPath), 0o755)
+	data := "default_profile = \"lab\"\n[[profiles]]\nname = \"lab\"\n[traces.run1]\nprofile = \"lab\"\ntopics = [\"a\"]\n"
+	if err := os.WriteFile(cfgPath, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	b := Bundle{Version: BundleVersion, Profiles: []BundleProfile{
+		{Profile: Profile{Name: "prod", Username: "u", Password: "pw"}, Topics: []TopicSnapshot{{Title: "x"}}},
+	}}
+	if _, err := ImportBundle(b, ConflictSkip, false); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	var raw map[string]interface{}
+	if _, err := toml.DecodeFile(cfgPath, &raw); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if _, ok := raw["traces"]; !ok || raw["default_profile"] != "lab" {
+		t.Fatalf("config tables lost: %v", raw)
+	}
+	c, err := LoadConfig(cfgPath)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(c.Profiles) != 2 || c.Profiles[1].Password != "pw" {
+		t.Fatalf("unexpected profiles %+v", c.Profiles)
+	}
+	if st := LoadState(); len(st["prod"].Topics) != 1 {
+		t.Fatalf("expected saved topics, got %v", st)
+	}
+}
+
+func TestImportBundleDropsPasswordCommands(t *testing.T) {
+	keyring.MockInit()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	profiles := []Profile{
+		{Name: "lab", Host: "lab.local", PasswordCommand: "pass show lab"},
+		{Name: "iot", Host: "iot.local", AuthMode: AuthCommand, PasswordCommand: "get-token"},
+	}
+	b, err := NewBundle(profiles, nil, nil, "")
+	if err != nil {
+		t.Fatalf("bundle: %v", err)
+	}
+	for _, bp := range b.Profiles {
+		if bp.Profile.PasswordCommand != "" || bp.Profile.AuthMode != "" {
+			t.Fatalf("exported a command without secrets: %+v", bp.Profile)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "bundle.toml")
+	data := "version = 1\n[[profiles]]\n[profiles.profile]\nname = \"lab\"\nhost = \"lab.local\"\npassword_command = \"echo pw\"\nauth_mode = \"command\"\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	b, err = ReadBundle(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !strings.Contains(strings.Join(b.Preview(), "\n"), `runs "echo pw"`) || len(b.Commands()) != 1 {
+		t.Fatalf("preview hides the command: %v", b.Preview())
+	}
+	if _, err := ImportBundle(b, ConflictSkip, false); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	p, err := LoadProfile("lab", "")
+	if err != nil || p.PasswordCommand != "" || p.AuthMode != "" {
+		t.Fatalf("imported the command: %+v %v", p, err)
+	}
+	if _, err := ImportBundle(b, ConflictOverwrite, true); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if p, _ := LoadProfile("lab", ""); p.PasswordCommand != "echo pw" || p.AuthMode != AuthCommand {
+		t.Fatalf("expected the allowed command, got %+v", p)
+	}
+}
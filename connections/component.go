@@ -3,8 +3,11 @@ package connections
 import (
 	"fmt"
 	"log"
+	"maps"
+	"slices"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
@@ -80,17 +83,53 @@ func (c *State) RefreshConnectionItems() {
 	c.Manager.refreshList()
 }
 
-// SaveCurrent persists topics and payloads for the active connection.
+// SaveCurrent persists topics and payloads for the active connection. Those
+// of ephemeral profiles are only kept in memory.
 func (c *State) SaveCurrent(topics []TopicSnapshot, payloads []PayloadSnapshot) {
 	if c.Active == "" {
 		return
 	}
 	c.Saved[c.Active] = ConnectionSnapshot{Topics: topics, Payloads: payloads}
-	if err := SaveState(c.Saved); err != nil {
+	persistent := maps.Clone(c.Saved)
+	for _, p := range c.Manager.Profiles {
+		if p.Ephemeral {
+			delete(persistent, p.Name)
+		}
+	}
+	if err := SaveState(persistent); err != nil {
 		log.Printf("Failed to save connection state: %v", err)
 	}
 }
 
+// Reload rereads the profiles and saved state from config.toml. Ephemeral
+// profiles are kept unless a saved profile took their name.
+func (c *State) Reload() {
+	var ephemeral []Profile
+	statuses, errs := map[string]string{}, map[string]string{}
+	for _, p := range c.Manager.Profiles {
+		if p.Ephemeral {
+			ephemeral = append(ephemeral, p)
+			statuses[p.Name], errs[p.Name] = c.Manager.Statuses[p.Name], c.Manager.Errors[p.Name]
+		}
+	}
+	if err := c.Manager.LoadProfiles(""); err != nil {
+		return
+	}
+	saved := LoadState()
+	for _, p := range ephemeral {
+		if slices.ContainsFunc(c.Manager.Profiles, func(q Profile) bool { return q.Name == p.Name }) {
+			continue
+		}
+		c.Manager.Profiles = append(c.Manager.Profiles, p)
+		c.Manager.Statuses[p.Name], c.Manager.Errors[p.Name] = statuses[p.Name], errs[p.Name]
+		if s, ok := c.Saved[p.Name]; ok {
+			saved[p.Name] = s
+		}
+	}
+	c.Saved = saved
+	c.Manager.refreshList()
+}
+
 // RestoreState returns saved topics and payloads for the named connection.
 func (c *State) RestoreState(name string) ([]TopicSnapshot, []PayloadSnapshot) {
 	if data, ok := c.Saved[name]; ok {
@@ -104,6 +143,14 @@ type Component struct {
 	nav     Navigator
 	api     API
 	actions map[string]KeyAction
+	// bundle is the open export or import prompt and note the outcome of
+	// the last one.
+	bundle *bundlePrompt
+	note   string
+	// quick is the open quick connect box.
+	quick *quickConnect
+	// tls is the open TLS diagnostics panel.
+	tls *tlsPanel
 }
 
 func NewComponent(nav Navigator, api API) *Component {
@@ -116,7 +163,7 @@ func NewComponent(nav Navigator, api API) *Component {
 		},
 		constants.KeyCtrlO: func(tea.KeyMsg) tea.Cmd {
 			mgr := c.api.Manager()
-			i := mgr.ConnectionsList.Index()
+			i := mgr.Selected()
 			if i >= 0 {
 				if mgr.DefaultProfileName == mgr.Profiles[i].Name {
 					mgr.ClearDefault()
@@ -130,9 +177,18 @@ func NewComponent(nav Navigator, api API) *Component {
 			c.api.BeginAdd()
 			return c.nav.SetMode(constants.ModeEditConnection)
 		},
+		constants.KeyN: func(tea.KeyMsg) tea.Cmd {
+			mgr := c.api.Manager()
+			i := mgr.Selected()
+			if i >= 0 && i < len(mgr.Profiles) && !mgr.Profiles[i].Ephemeral {
+				c.api.BeginAddChild(mgr.Profiles[i].Name)
+				return c.nav.SetMode(constants.ModeEditConnection)
+			}
+			return nil
+		},
 		constants.KeyE: func(tea.KeyMsg) tea.Cmd {
 			mgr := c.api.Manager()
-			i := mgr.ConnectionsList.Index()
+			i := mgr.Selected()
 			if i >= 0 && i < len(mgr.Profiles) {
 				c.api.BeginEdit(i)
 				return c.nav.SetMode(constants.ModeEditConnection)
@@ -141,7 +197,7 @@ func NewComponent(nav Navigator, api API) *Component {
 		},
 		constants.KeyEnter: func(tea.KeyMsg) tea.Cmd {
 			mgr := c.api.Manager()
-			i := mgr.ConnectionsList.Index()
+			i := mgr.Selected()
 			if i >= 0 && i < len(mgr.Profiles) {
 				p := mgr.Profiles[i]
 				if p.Name == c.api.Active() && mgr.Statuses[p.Name] == "connected" {
@@ -157,7 +213,7 @@ func NewComponent(nav Navigator, api API) *Component {
 		},
 		constants.KeyDelete: func(tea.KeyMsg) tea.Cmd {
 			mgr := c.api.Manager()
-			i := mgr.ConnectionsList.Index()
+			i := mgr.Selected()
 			if i >= 0 {
 				c.api.BeginDelete(i)
 				return c.api.ListenStatus()
@@ -168,6 +224,26 @@ func NewComponent(nav Navigator, api API) *Component {
 			c.api.DisconnectActive()
 			return nil
 		},
+		constants.KeyX: func(tea.KeyMsg) tea.Cmd {
+			mgr := c.api.Manager()
+			selected := ""
+			if i := mgr.Selected(); i >= 0 && i < len(mgr.Profiles) {
+				selected = mgr.Profiles[i].Name
+			}
+			c.bundle, c.note = newExportPrompt(selected), ""
+			return textinput.Blink
+		},
+		constants.KeyI: func(tea.KeyMsg) tea.Cmd {
+			c.bundle, c.note = newImportPrompt(), ""
+			return textinput.Blink
+		},
+		constants.KeyC: func(tea.KeyMsg) tea.Cmd {
+			c.quick, c.note = newQuickConnect(), ""
+			return textinput.Blink
+		},
+		constants.KeyT: func(tea.KeyMsg) tea.Cmd {
+			return c.checkSelectedTLS()
+		},
 	}
 	return c
 }
@@ -177,6 +253,15 @@ func (c *Component) Init() tea.Cmd { return nil }
 // Update processes input when the connections view is active.
 func (c *Component) Update(msg tea.Msg) tea.Cmd {
 	var cmd tea.Cmd
+	if _, ok := msg.(ConnectResult); !ok && c.bundle != nil {
+		return c.updateBundle(msg)
+	}
+	if _, ok := msg.(ConnectResult); !ok && c.quick != nil {
+		return c.updateQuick(msg)
+	}
+	if _, ok := msg.(ConnectResult); !ok && c.tls != nil {
+		return c.updateTLS(msg)
+	}
 	switch msg := msg.(type) {
 	case ConnectResult:
 		c.api.HandleConnectResult(msg)
@@ -207,8 +292,18 @@ func (c *Component) View() string {
 	ch := c.nav.Height() - 6
 	c.api.Manager().ConnectionsList.SetSize(cw, ch)
 	listView := c.api.Manager().ConnectionsList.View()
-	help := ui.InfoStyle.Render("[enter] connect/open client  Ctrl+X disconnect  [a]dd [e]dit [del] delete  Ctrl+O default  Ctrl+R traces")
+	if c.tls != nil {
+		listView = c.tls.View(cw, ch)
+	}
+	help := ui.InfoStyle.Render("[enter] connect/open client  Ctrl+X disconnect  [a]dd [n]ew child [e]dit [del] delete  Ctrl+O default  [c] connect URL  [t] TLS check  [x] export [i] import  Ctrl+R traces")
 	content := lipgloss.JoinVertical(lipgloss.Left, listView, help)
+	if c.bundle != nil {
+		content = lipgloss.JoinVertical(lipgloss.Left, content, c.bundle.View())
+	} else if c.quick != nil {
+		content = lipgloss.JoinVertical(lipgloss.Left, content, c.quick.View())
+	} else if c.note != "" {
+		content = lipgloss.JoinVertical(lipgloss.Left, content, ui.InfoStyle.Render(c.note))
+	}
 	view := ui.LegendBox(content, "Brokers", c.nav.Width()-2, 0, ui.ColBlue, true, -1)
 	return c.api.OverlayHelp(view)
 }
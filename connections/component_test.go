@@ -1,6 +1,8 @@
 package connections
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,8 +16,10 @@ func (t *testNav) Width() int                          { return 80 }
 func (t *testNav) Height() int                         { return 24 }
 
 type testAPI struct {
-	began bool
-	mgr   *Connections
+	began     bool
+	mgr       *Connections
+	connected string
+	parent    string
 }
 
 func (t *testAPI) Manager() *Connections             { return t.mgr }
@@ -28,9 +32,10 @@ func (t *testAPI) ConnectionMessage() string         { return "" }
 func (t *testAPI) SetConnectionMessage(string)       {}
 func (t *testAPI) Active() string                    { return "" }
 func (t *testAPI) BeginAdd()                         { t.began = true }
+func (t *testAPI) BeginAddChild(parent string)       { t.began, t.parent = true, parent }
 func (t *testAPI) BeginEdit(int)                     {}
 func (t *testAPI) BeginDelete(int)                   {}
-func (t *testAPI) Connect(Profile) tea.Cmd           { return nil }
+func (t *testAPI) Connect(p Profile) tea.Cmd         { t.connected = p.Name; return nil }
 func (t *testAPI) HandleConnectResult(ConnectResult) {}
 func (t *testAPI) DisconnectActive()                 {}
 func (t *testAPI) ResizeTraces(int, int)             {}
@@ -40,6 +45,12 @@ func (t *testAPI) OverlayHelp(view string) string    { return view }
 func (t *testAPI) SetConnecting(string)              {}
 func (t *testAPI) SetConnected(string)               {}
 func (t *testAPI) SetDisconnected(string, string)    {}
+func (t *testAPI) ReloadProfiles()                   {}
+func (t *testAPI) AddEphemeral(p Profile, _ []TopicSnapshot) Profile {
+	t.mgr.Profiles = append(t.mgr.Profiles, p)
+	t.mgr.refreshList()
+	return p
+}
 
 func TestAddKeyTriggersBeginAdd(t *testing.T) {
 	mgr := NewConnectionsModel()
@@ -54,3 +65,27 @@ func TestAddKeyTriggersBeginAdd(t *testing.T) {
 		t.Fatalf("expected mode %v, got %v", constants.ModeEditConnection, nav.mode)
 	}
 }
+
+func TestExportPromptWritesBundle(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mgr := NewConnectionsModel()
+	mgr.Profiles = []Profile{{Name: "lab", Password: "pw"}, {Name: "prod"}}
+	mgr.refreshList()
+	c := NewComponent(&testNav{}, &testAPI{mgr: &mgr})
+	c.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	if c.bundle == nil || c.bundle.inputs[0].Value() != "lab" {
+		t.Fatalf("expected export prompt for the selected profile")
+	}
+	path := filepath.Join(t.TempDir(), "lab.toml")
+	c.bundle.inputs[1].SetValue(path)
+	for i := 0; i < 3; i++ {
+		c.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	}
+	if c.bundle != nil || !strings.Contains(c.note, "Exported 1 profiles") {
+		t.Fatalf("expected export to finish, note %q", c.note)
+	}
+	b, err := ReadBundle(path)
+	if err != nil || len(b.Profiles) != 1 || b.HasSecrets() {
+		t.Fatalf("unexpected bundle %+v %v", b, err)
+	}
+}
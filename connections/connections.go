@@ -19,6 +19,7 @@ type Connections struct {
 	Statuses           map[string]string // connection status by name
 	Errors             map[string]string // last connection error message
 	Focused            bool              // Indicates if the broker manager is focused
+	order              []int             // index in Profiles of each list item
 }
 
 // NewConnectionsModel initializes a new ConnectionsModel with default values.
@@ -94,6 +95,11 @@ func (m *Connections) EditConnection(index int, p Profile) {
 			if m.DefaultProfileName == oldName {
 				m.DefaultProfileName = p.Name
 			}
+			for i := range m.Profiles {
+				if m.Profiles[i].Parent == oldName {
+					m.Profiles[i].Parent = p.Name
+				}
+			}
 		}
 		if err := persistProfileChange(&m.Profiles, m.DefaultProfileName, p, index); err != nil {
 			log.Printf("Failed to persist profile %s: %v", p.Name, err)
@@ -121,8 +127,9 @@ func (m *Connections) DeleteConnection(index int) {
 }
 
 // SetDefault marks the profile at index as the default and saves the config.
+// Ephemeral profiles cannot be the default.
 func (m *Connections) SetDefault(index int) {
-	if index >= 0 && index < len(m.Profiles) {
+	if index >= 0 && index < len(m.Profiles) && !m.Profiles[index].Ephemeral {
 		m.DefaultProfileName = m.Profiles[index].Name
 		if err := saveConfig(m.Profiles, m.DefaultProfileName); err != nil {
 			log.Printf("Failed to save default profile: %v", err)
@@ -140,21 +147,48 @@ func (m *Connections) ClearDefault() {
 	m.refreshList()
 }
 
-// refreshList rebuilds the list items from the current profiles.
+// refreshList rebuilds the list items from the current profiles, grouping
+// child profiles below their parents. Profiles keeps its saved order.
 func (m *Connections) refreshList() {
+	var depths []int
+	m.order, depths = groupOrder(m.Profiles)
 	items := []list.Item{}
-	for _, p := range m.Profiles {
+	for i, pi := range m.order {
+		p := m.Profiles[pi]
 		status := m.Statuses[p.Name]
 		detail := m.Errors[p.Name]
 		title := p.Name
 		if p.Name == m.DefaultProfileName {
 			title += " *"
 		}
+		if p.Ephemeral {
+			title += " (unsaved)"
+		}
+		title = treeTitle(title, depths[i])
 		items = append(items, connectionItem{title: title, status: status, detail: detail})
 	}
 	m.ConnectionsList.SetItems(items)
 }
 
+// Selected returns the index in Profiles of the selected list item, or -1.
+func (m *Connections) Selected() int {
+	i := m.ConnectionsList.Index()
+	if i < 0 || i >= len(m.order) {
+		return -1
+	}
+	return m.order[i]
+}
+
+// Select selects the list item of the profile at index.
+func (m *Connections) Select(index int) {
+	for i, pi := range m.order {
+		if pi == index {
+			m.ConnectionsList.Select(i)
+			return
+		}
+	}
+}
+
 // LoadFromConfig loads connection profiles from the config file.
 func LoadFromConfig(filePath string) (*Connections, error) {
 	cfg, err := LoadConfig(filePath)
@@ -0,0 +1,581 @@
+package connections
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Formats accepted by ReadProfiles.
+const (
+	FormatBundle       = "bundle"
+	FormatMQTTX        = "mqttx"
+	FormatMQTTExplorer = "mqtt-explorer"
+	FormatMosquitto    = "mosquitto"
+)
+
+// ReadProfiles reads profiles from a bundle, an MQTTX or MQTT Explorer
+// connection export or a file of mosquitto_sub/mosquitto_pub command lines.
+// An empty format is detected from the content. Settings that cannot be
+// carried over are reported as notes.
+func ReadProfiles(path, format string) (Bundle, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Bundle{}, nil, err
+	}
+	if format == "" {
+		format = DetectFormat(data)
+	}
+	switch format {
+	case FormatBundle:
+		b, err := ReadBundle(path)
+		return b, nil, err
+	case FormatMQTTX:
+		return parseMQTTX(data)
+	case FormatMQTTExplorer:
+		return parseMQTTExplorer(data)
+	case FormatMosquitto:
+		return parseMosquitto(string(data))
+	}
+	return Bundle{}, nil, fmt.Errorf("unknown format %q, use %s, %s, %s or %s", format, FormatBundle, FormatMQTTX, FormatMQTTExplorer, FormatMosquitto)
+}
+
+// DetectFormat guesses the format of a profile file.
+func DetectFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		return FormatMQTTX
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var probe struct {
+			Connections json.RawMessage `json:"connections"`
+		}
+		if json.Unmarshal(trimmed, &probe) == nil && len(probe.Connections) > 0 {
+			return FormatMQTTX
+		}
+		return FormatMQTTExplorer
+	case bytes.Contains(trimmed, []byte("mosquitto_")) || bytes.HasPrefix(trimmed, []byte("-")):
+		return FormatMosquitto
+	}
+	return FormatBundle
+}
+
+// names hands out unique profile names within one import.
+type names map[string]bool
+
+func (n names) unique(name string) string {
+	base := name
+	for i := 2; n[name]; i++ {
+		name = fmt.Sprintf("%s (%d)", base, i)
+	}
+	n[name] = true
+	return name
+}
+
+// mqttVersion maps protocol versions such as 3.1.1 or 5.0 to the values of
+// the profile form.
+func mqttVersion(v string) string {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(v)), "mqttv") {
+	case "5", "5.0":
+		return "5"
+	case "3.1.1", "311", "4":
+		return "4"
+	case "3.1", "31", "3":
+		return "3"
+	}
+	return ""
+}
+
+// certPath returns v when it names a file. Inline PEM data cannot be stored
+// in a profile and is reported instead.
+func certPath(name, what, v string, notes *[]string) string {
+	if strings.Contains(v, "-----BEGIN") {
+		*notes = append(*notes, fmt.Sprintf("%s: inline %s not imported, save it to a file and set its path", name, what))
+		return ""
+	}
+	return v
+}
+
+type mqttxConnection struct {
+	Name               string `json:"name"`
+	ClientID           string `json:"clientId"`
+	Host               string `json:"host"`
+	Port               int    `json:"port"`
+	Path               string `json:"path"`
+	Username           string `json:"username"`
+	Password           string `json:"password"`
+	Protocol           string `json:"protocol"`
+	SSL                bool   `json:"ssl"`
+	RejectUnauthorized *bool  `json:"rejectUnauthorized"`
+	CA                 string `json:"ca"`
+	Cert               string `json:"cert"`
+	Key                string `json:"key"`
+	MQTTVersion        string `json:"mqttVersion"`
+	Clean              bool   `json:"clean"`
+	Keepalive          int    `json:"keepalive"`
+	ConnectTimeout     int    `json:"connectTimeout"`
+	Reconnect          bool   `json:"reconnect"`
+	ReconnectPeriod    int    `json:"reconnectPeriod"`
+	Subscriptions      []struct {
+		Topic string `json:"topic"`
+	} `json:"subscriptions"`
+	Will *struct {
+		Topic   string `json:"lastWillTopic"`
+		Payload string `json:"lastWillPayload"`
+		QoS     int    `json:"lastWillQos"`
+		Retain  bool   `json:"lastWillRetain"`
+	} `json:"will"`
+	Properties *struct {
+		SessionExpiry       int  `json:"sessionExpiryInterval"`
+		ReceiveMaximum      int  `json:"receiveMaximum"`
+		MaximumPacketSize   int  `json:"maximumPacketSize"`
+		TopicAliasMaximum   int  `json:"topicAliasMaximum"`
+		RequestResponseInfo bool `json:"requestResponseInfo"`
+		RequestProblemInfo  bool `json:"requestProblemInfo"`
+	} `json:"properties"`
+}
+
+// parseMQTTX reads an MQTTX data export, a list of connections or an object
+// with a connections list.
+func parseMQTTX(data []byte) (Bundle, []string, error) {
+	var conns []mqttxConnection
+	if err := json.Unmarshal(data, &conns); err != nil {
+		var wrapped struct {
+			Connections []mqttxConnection `json:"connections"`
+		}
+		if err2 := json.Unmarshal(data, &wrapped); err2 != nil {
+			return Bundle{}, nil, fmt.Errorf("invalid MQTTX export: %w", err)
+		}
+		conns = wrapped.Connections
+	}
+	b := Bundle{Version: BundleVersion}
+	var notes []string
+	used := names{}
+	for _, c := range conns {
+		name := used.unique(firstNonEmpty(c.Name, c.Host))
+		p := Profile{
+			Name:           name,
+			Host:           c.Host,
+			Port:           c.Port,
+			ClientID:       c.ClientID,
+			Username:       c.Username,
+			Password:       c.Password,
+			MQTTVersion:    mqttVersion(c.MQTTVersion),
+			CleanStart:     c.Clean,
+			KeepAlive:      c.Keepalive,
+			ConnectTimeout: c.ConnectTimeout,
+			AutoReconnect:  c.Reconnect,
+		}
+		if c.ReconnectPeriod > 0 {
+			p.ReconnectPeriod = (c.ReconnectPeriod + 999) / 1000
+		}
+		p.Schema, p.SSL = schemeFor(c.Protocol, c.SSL)
+		if c.RejectUnauthorized != nil && !*c.RejectUnauthorized {
+			p.SkipTLSVerify = true
+		}
+		p.CACertPath = certPath(name, "CA certificate", c.CA, &notes)
+		p.ClientCertPath = certPath(name, "client certificate", c.Cert, &notes)
+		p.ClientKeyPath = certPath(name, "client key", c.Key, &notes)
+		if c.Path != "" && c.Path != "/" && strings.HasPrefix(p.Schema, "ws") {
+			notes = append(notes, fmt.Sprintf("%s: websocket path %s is not supported", name, c.Path))
+		}
+		if w := c.Will; w != nil && w.Topic != "" {
+			p.LastWillEnabled = true
+			p.LastWillTopic, p.LastWillPayload, p.LastWillQos, p.LastWillRetain = w.Topic, w.Payload, w.QoS, w.Retain
+		}
+		if pr := c.Properties; pr != nil {
+			p.SessionExpiry = pr.SessionExpiry
+			p.ReceiveMaximum = pr.ReceiveMaximum
+			p.MaximumPacketSize = pr.MaximumPacketSize
+			p.TopicAliasMaximum = pr.TopicAliasMaximum
+			p.RequestResponseInfo = pr.RequestResponseInfo
+			p.RequestProblemInfo = pr.RequestProblemInfo
+		}
+		bp := BundleProfile{Profile: p}
+		for _, s := range c.Subscriptions {
+			bp.Topics = append(bp.Topics, TopicSnapshot{Title: s.Topic, Subscribed: true})
+		}
+		b.Profiles = append(b.Profiles, bp)
+	}
+	return b, notes, nil
+}
+
+type explorerConnection struct {
+	Name           string          `json:"name"`
+	Host           string          `json:"host"`
+	Port           int             `json:"port"`
+	Protocol       string          `json:"protocol"`
+	BasePath       string          `json:"basePath"`
+	Encryption     bool            `json:"encryption"`
+	CertValidation *bool           `json:"certValidation"`
+	ClientID       string          `json:"clientId"`
+	Username       string          `json:"username"`
+	Password       string          `json:"password"`
+	Subscriptions  json.RawMessage `json:"subscriptions"`
+	SelfSigned     json.RawMessage `json:"selfSignedCertificate"`
+	ClientCert     json.RawMessage `json:"clientCertificate"`
+	ClientKey      json.RawMessage `json:"clientKey"`
+}
+
+// parseMQTTExplorer reads MQTT Explorer's connection settings: an object of
+// connections keyed by id, optionally under ConnectionManager_connections.
+func parseMQTTExplorer(data []byte) (Bundle, []string, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Bundle{}, nil, fmt.Errorf("invalid MQTT Explorer settings: %w", err)
+	}
+	if inner, ok := raw["ConnectionManager_connections"]; ok {
+		raw = nil
+		if err := json.Unmarshal(inner, &raw); err != nil {
+			return Bundle{}, nil, fmt.Errorf("invalid MQTT Explorer settings: %w", err)
+		}
+	}
+	ids := make([]string, 0, len(raw))
+	for id := range raw {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	b := Bundle{Version: BundleVersion}
+	var notes []string
+	used := names{}
+	for _, id := range ids {
+		var c explorerConnection
+		if err := json.Unmarshal(raw[id], &c); err != nil || c.Host == "" {
+			continue
+		}
+		name := used.unique(firstNonEmpty(c.Name, c.Host))
+		p := Profile{
+			Name:     name,
+			Host:     c.Host,
+			Port:     c.Port,
+			ClientID: c.ClientID,
+			Username: c.Username,
+			Password: c.Password,
+		}
+		p.Schema, p.SSL = schemeFor(c.Protocol, c.Encryption)
+		if c.CertValidation != nil && !*c.CertValidation {
+			p.SkipTLSVerify = true
+		}
+		if embedded(c.SelfSigned) || embedded(c.ClientCert) || embedded(c.ClientKey) {
+			notes = append(notes, fmt.Sprintf("%s: embedded certificates not imported, set their paths in the profile", name))
+		}
+		if c.BasePath != "" && strings.HasPrefix(p.Schema, "ws") {
+			notes = append(notes, fmt.Sprintf("%s: websocket path %s is not supported", name, c.BasePath))
+		}
+		bp := BundleProfile{Profile: p}
+		for _, t := range explorerTopics(c.Subscriptions) {
+			bp.Topics = append(bp.Topics, TopicSnapshot{Title: t, Subscribed: true})
+		}
+		b.Profiles = append(b.Profiles, bp)
+	}
+	if len(b.Profiles) == 0 {
+		return b, notes, fmt.Errorf("no connections found in MQTT Explorer settings")
+	}
+	return b, notes, nil
+}
+
+// embedded reports whether an MQTT Explorer certificate field is set.
+func embedded(raw json.RawMessage) bool {
+	return len(raw) > 0 && string(raw) != "null"
+}
+
+// explorerTopics reads subscriptions stored as strings by older and as
+// objects by newer MQTT Explorer versions.
+func explorerTopics(raw json.RawMessage) []string {
+	var topics []string
+	if json.Unmarshal(raw, &topics) == nil {
+		return topics
+	}
+	topics = nil
+	var subs []struct {
+		Topic string `json:"topic"`
+	}
+	json.Unmarshal(raw, &subs)
+	for _, s := range subs {
+		topics = append(topics, s.Topic)
+	}
+	return topics
+}
+
+// schemeFor maps a client's protocol name and TLS flag to a profile schema.
+func schemeFor(protocol string, tls bool) (string, bool) {
+	switch strings.ToLower(protocol) {
+	case "mqtts", "ssl", "tls":
+		return "mqtts", true
+	case "wss":
+		return "wss", true
+	case "ws":
+		if tls {
+			return "wss", true
+		}
+		return "ws", false
+	}
+	if tls {
+		return "mqtts", true
+	}
+	return "mqtt", false
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// mosquittoValueFlags take a value that has no profile setting.
+var mosquittoValueFlags = map[string]bool{
+	"-A": true, "-C": true, "-D": true, "-F": true, "-T": true, "-U": true, "-W": true,
+	"-e": true, "-f": true, "-s": true, "--capath": true, "--ciphers": true, "--tls-version": true,
+	"--proxy": true, "--psk": true, "--psk-identity": true, "--tls-alpn": true, "--tls-engine": true,
+	"--keyform": true, "--tls-engine-kpass-sha1": true, "--unix": true, "--repeat": true, "--repeat-delay": true,
+}
+
+// parseMosquitto reads one mosquitto_sub or mosquitto_pub command per line.
+// Lines ending in a backslash continue on the next one.
+func parseMosquitto(text string) (Bundle, []string, error) {
+	b := Bundle{Version: BundleVersion}
+	var notes []string
+	used := names{}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		n := i + 1
+		line := strings.TrimSpace(lines[i])
+		for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
+			i++
+			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(lines[i])
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		args, err := splitArgs(line)
+		if err != nil {
+			return b, notes, fmt.Errorf("line %d: %w", n, err)
+		}
+		bp, lineNotes, err := mosquittoProfile(args)
+		if err != nil {
+			return b, notes, fmt.Errorf("line %d: %w", n, err)
+		}
+		bp.Profile.Name = used.unique(bp.Profile.Name)
+		for _, note := range lineNotes {
+			notes = append(notes, fmt.Sprintf("line %d: %s", n, note))
+		}
+		b.Profiles = append(b.Profiles, bp)
+	}
+	if len(b.Profiles) == 0 {
+		return b, notes, fmt.Errorf("no mosquitto commands found")
+	}
+	return b, notes, nil
+}
+
+func mosquittoProfile(args []string) (BundleProfile, []string, error) {
+	var notes []string
+	publish := false
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		publish = strings.Contains(args[0], "mosquitto_pub")
+		args = args[1:]
+	}
+	p := Profile{Host: "localhost", CleanStart: true}
+	var topics []string
+	var message string
+	tls := false
+	for i := 0; i < len(args); i++ {
+		flag := args[i]
+		value := func() (string, error) {
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("%s needs a value", flag)
+			}
+			i++
+			return args[i], nil
+		}
+		var v string
+		var err error
+		switch flag {
+		case "-h", "--host", "-p", "--port", "-u", "--username", "-P", "--pw", "-i", "--id",
+			"-I", "--id-prefix", "-t", "--topic", "-k", "--keepalive", "-V", "--protocol-version",
+			"--cafile", "--cert", "--key", "-L", "--url", "-m", "--message", "-x", "--session-expiry-interval",
+			"--will-topic", "--will-payload", "--will-qos", "-q", "--qos":
+			if v, err = value(); err != nil {
+				return BundleProfile{}, notes, err
+			}
+		}
+		switch flag {
+		case "-h", "--host":
+			p.Host = v
+		case "-p", "--port":
+			if p.Port, err = strconv.Atoi(v); err != nil {
+				return BundleProfile{}, notes, fmt.Errorf("invalid port %q", v)
+			}
+		case "-u", "--username":
+			p.Username = v
+		case "-P", "--pw":
+			p.Password = v
+		case "-i", "--id":
+			p.ClientID = v
+		case "-I", "--id-prefix":
+			p.ClientID, p.RandomIDSuffix = v, true
+		case "-t", "--topic":
+			topics = append(topics, v)
+		case "-k", "--keepalive":
+			p.KeepAlive, _ = strconv.Atoi(v)
+		case "-V", "--protocol-version":
+			p.MQTTVersion = mqttVersion(v)
+		case "-c", "--disable-clean-session":
+			p.CleanStart = false
+		case "--cafile":
+			p.CACertPath, tls = v, true
+		case "--cert":
+			p.ClientCertPath, tls = v, true
+		case "--key":
+			p.ClientKeyPath = v
+		case "--insecure":
+			p.SkipTLSVerify, tls = true, true
+		case "-x", "--session-expiry-interval":
+			p.SessionExpiry, _ = strconv.Atoi(v)
+		case "--will-topic":
+			p.LastWillEnabled, p.LastWillTopic = true, v
+		case "--will-payload":
+			p.LastWillPayload = v
+		case "--will-qos":
+			p.LastWillQos, _ = strconv.Atoi(v)
+		case "--will-retain":
+			p.LastWillRetain = true
+		case "-m", "--message":
+			message = v
+		case "-q", "--qos":
+			// QoS applies per subscription or message, not per profile.
+		case "-L", "--url":
+			u, err := url.Parse(v)
+			if err != nil {
+				return BundleProfile{}, notes, fmt.Errorf("invalid url %q", v)
+			}
+			p.Host = u.Hostname()
+			if port := u.Port(); port != "" {
+				p.Port, _ = strconv.Atoi(port)
+			}
+			if u.User != nil {
+				p.Username = u.User.Username()
+				p.Password, _ = u.User.Password()
+			}
+			if t := strings.TrimPrefix(u.Path, "/"); t != "" {
+				topics = append(topics, t)
+			}
+			if u.Scheme == "mqtts" {
+				tls = true
+			}
+		default:
+			if mosquittoValueFlags[flag] {
+				i++
+			}
+			if strings.HasPrefix(flag, "-") && flag != "-v" && flag != "-d" && flag != "-N" && flag != "-R" && flag != "-r" && flag != "--retain" {
+				notes = append(notes, fmt.Sprintf("option %s ignored", flag))
+			}
+		}
+	}
+	p.Schema, p.SSL = schemeFor("", tls)
+	if p.Port == 0 {
+		p.Port = 1883
+		if tls {
+			p.Port = 8883
+		}
+	}
+	p.Name = p.Host
+	if p.Username != "" {
+		p.Name = p.Username + "@" + p.Host
+	}
+	bp := BundleProfile{Profile: p}
+	for _, t := range topics {
+		if publish {
+			bp.Topics = append(bp.Topics, TopicSnapshot{Title: t, Publish: true})
+			if message != "" {
+				bp.Payloads = append(bp.Payloads, PayloadSnapshot{Topic: t, Payload: message})
+			}
+		} else {
+			bp.Topics = append(bp.Topics, TopicSnapshot{Title: t, Subscribed: true})
+		}
+	}
+	return bp, notes, nil
+}
+
+// splitArgs splits a shell command line, honouring quotes and backslash
+// escapes.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// Preview describes the profiles of b, one line each, without passwords
+// but with password commands.
+func (b Bundle) Preview() []string {
+	var lines []string
+	for _, bp := range b.Profiles {
+		p := bp.Profile
+		line := fmt.Sprintf("%s  %s", p.Name, p.BrokerURL())
+		if p.Username != "" {
+			line += "  user " + p.Username
+		}
+		if p.Password != "" || bp.Secret != "" {
+			line += "  with password"
+		}
+		if p.PasswordCommand != "" {
+			line += fmt.Sprintf("  runs %q", p.PasswordCommand)
+		}
+		if p.SSL && p.SkipTLSVerify {
+			line += "  tls (unverified)"
+		} else if p.SSL {
+			line += "  tls"
+		}
+		var topics []string
+		for _, t := range bp.Topics {
+			topics = append(topics, t.Title)
+		}
+		if len(topics) > 0 {
+			line += "  topics " + strings.Join(topics, ", ")
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
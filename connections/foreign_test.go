@@ -0,0 +1,139 @@
+package connections
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zalando/go-keyring"
+)
+
+func writeTemp(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadProfilesMQTTX(t *testing.T) {
+	path := writeTemp(t, "mqttx.json", `{"connections": [{
+		"name": "cloud", "host": "broker.example.com", "port": 8883, "protocol": "mqtts",
+		"ssl": true, "rejectUnauthorized": false, "ca": "-----BEGIN CERTIFICATE-----",
+		"cert": "/certs/client.pem", "username": "dev", "password": "pw", "clientId": "mqttx_1",
+		"mqttVersion": "5.0", "clean": true, "keepalive": 60, "connectTimeout": 10,
+		"reconnect": true, "reconnectPeriod": 4000,
+		"subscriptions": [{"topic": "sensors/#", "qos": 1}],
+		"will": {"lastWillTopic": "status", "lastWillPayload": "gone", "lastWillQos": 1},
+		"properties": {"sessionExpiryInterval": 300, "receiveMaximum": 10}
+	}]}`)
+	b, notes, err := ReadProfiles(path, "")
+	if err != nil || len(b.Profiles) != 1 {
+		t.Fatalf("unexpected result %+v %v", b, err)
+	}
+	bp := b.Profiles[0]
+	p := bp.Profile
+	if p.Name != "cloud" || p.BrokerURL() != "mqtts://broker.example.com:8883" || !p.SSL || !p.SkipTLSVerify {
+		t.Fatalf("unexpected connection %+v", p)
+	}
+	if p.MQTTVersion != "5" || p.ReconnectPeriod != 4 || p.SessionExpiry != 300 || p.ReceiveMaximum != 10 {
+		t.Fatalf("unexpected settings %+v", p)
+	}
+	if p.CACertPath != "" || p.ClientCertPath != "/certs/client.pem" || !p.LastWillEnabled || p.LastWillTopic != "status" {
+		t.Fatalf("unexpected tls or will %+v", p)
+	}
+	if len(bp.Topics) != 1 || bp.Topics[0].Title != "sensors/#" || !bp.Topics[0].Subscribed {
+		t.Fatalf("unexpected topics %+v", bp.Topics)
+	}
+	if len(notes) != 1 || !strings.Contains(notes[0], "CA certificate") {
+		t.Fatalf("expected inline CA note, got %v", notes)
+	}
+}
+
+func TestReadProfilesMQTTExplorer(t *testing.T) {
+	path := writeTemp(t, "settings.json", `{"ConnectionManager_connections": {
+		"b": {"name": "home", "host": "nas.local", "port": 1883, "protocol": "mqtt",
+			"subscriptions": ["#", "$SYS/#"]},
+		"a": {"name": "home", "host": "ws.example.com", "port": 443, "protocol": "ws",
+			"encryption": true, "certValidation": false, "basePath": "mqtt", "username": "me",
+			"subscriptions": [{"topic": "a/#", "qos": 0}]}
+	}}`)
+	b, notes, err := ReadProfiles(path, "")
+	if err != nil || len(b.Profiles) != 2 {
+		t.Fatalf("unexpected result %+v %v", b, err)
+	}
+	ws, home := b.Profiles[0].Profile, b.Profiles[1].Profile
+	if ws.Name != "home" || ws.BrokerURL() != "wss://ws.example.com:443" || !ws.SkipTLSVerify || ws.Username != "me" {
+		t.Fatalf("unexpected websocket profile %+v", ws)
+	}
+	if home.Name != "home (2)" || home.SSL || len(b.Profiles[1].Topics) != 2 || b.Profiles[0].Topics[0].Title != "a/#" {
+		t.Fatalf("unexpected profiles %+v", b.Profiles)
+	}
+	if len(notes) != 1 || !strings.Contains(notes[0], "websocket path") {
+		t.Fatalf("expected path note, got %v", notes)
+	}
+}
+
+func TestReadProfilesMosquitto(t *testing.T) {
+	path := writeTemp(t, "cmds.sh", `# lab brokers
+mosquitto_sub -h lab.local -u alice -P 's3cr et' -t 'sensors/#' -t alerts -V mqttv311 -c -v
+mosquitto_pub -h lab.local --cafile /etc/ca.pem \
+  -t cmd/reboot -m '{"now": true}' -q 1 --will-topic gone
+mosquitto_sub -L mqtt://bob:pw@test.local:1884/status -W 5
+`)
+	b, notes, err := ReadProfiles(path, "")
+	if err != nil || len(b.Profiles) != 3 {
+		t.Fatalf("unexpected result %+v %v", b, err)
+	}
+	sub := b.Profiles[0]
+	if sub.Profile.Name != "alice@lab.local" || sub.Profile.Password != "s3cr et" || sub.Profile.MQTTVersion != "4" || sub.Profile.CleanStart {
+		t.Fatalf("unexpected subscriber %+v", sub.Profile)
+	}
+	if len(sub.Topics) != 2 || !sub.Topics[1].Subscribed {
+		t.Fatalf("unexpected topics %+v", sub.Topics)
+	}
+	pub := b.Profiles[1]
+	if pub.Profile.Name != "lab.local" || pub.Profile.BrokerURL() != "mqtts://lab.local:8883" || !pub.Profile.LastWillEnabled {
+		t.Fatalf("unexpected publisher %+v", pub.Profile)
+	}
+	if len(pub.Topics) != 1 || !pub.Topics[0].Publish || len(pub.Payloads) != 1 || pub.Payloads[0].Payload != `{"now": true}` {
+		t.Fatalf("unexpected publish state %+v %+v", pub.Topics, pub.Payloads)
+	}
+	url := b.Profiles[2].Profile
+	if url.BrokerURL() != "mqtt://test.local:1884" || url.Username != "bob" || b.Profiles[2].Topics[0].Title != "status" {
+		t.Fatalf("unexpected url profile %+v", url)
+	}
+	if len(notes) != 1 || !strings.Contains(notes[0], "line 5: option -W ignored") {
+		t.Fatalf("unexpected notes %v", notes)
+	}
+	if _, _, err := ReadProfiles(writeTemp(t, "bad.sh", "mosquitto_sub -h 'open"), FormatMosquitto); err == nil {
+		t.Fatalf("expected quote error")
+	}
+}
+
+func TestImportPromptPreview(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("HOME", t.TempDir())
+	path := writeTemp(t, "cmds.txt", "mosquitto_sub -h lab.local -t a/b\n")
+	mgr := NewConnectionsModel()
+	c := NewComponent(&testNav{}, &testAPI{mgr: &mgr})
+	c.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	c.bundle.inputs[0].SetValue(path)
+	for i := 0; i < 4; i++ {
+		c.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	}
+	if c.bundle == nil || !strings.Contains(c.bundle.View(), "lab.local  mqtt://lab.local:1883  topics a/b") {
+		t.Fatalf("expected preview before saving")
+	}
+	cfgPath, _ := DefaultUserConfigFile()
+	if _, err := os.Stat(cfgPath); err == nil {
+		t.Fatalf("preview must not save")
+	}
+	c.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if c.bundle != nil || !strings.Contains(c.note, "lab.local: added") {
+		t.Fatalf("expected import, note %q", c.note)
+	}
+}
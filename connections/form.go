@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -19,6 +20,11 @@ type Form struct {
 	ui.Form
 	Index   int  // -1 for new
 	fromEnv bool // current state of env loading
+	// child forms leave inherited settings empty, a 0 or false set in
+	// them is saved as an override
+	child bool
+	// kept are the overrides of settings without a form field
+	kept []string
 }
 
 type fieldType int
@@ -38,6 +44,7 @@ type fieldDef struct {
 
 var formFields = []fieldDef{
 	{key: "Name", label: "Name", placeholder: "Name", fieldType: ftText},
+	{key: "Parent", label: "Parent", placeholder: "Profile to inherit from", fieldType: ftText},
 	{key: "Schema", label: "Schema", placeholder: "Schema", fieldType: ftSelect, options: []string{"tcp", "ssl", "ws", "wss", "mqtt", "mqtts"}},
 	{key: "Host", label: "Host", placeholder: "Host", fieldType: ftText},
 	{key: "Port", label: "Port", placeholder: "Port", fieldType: ftText},
@@ -45,6 +52,12 @@ var formFields = []fieldDef{
 	{key: "RandomIDSuffix", label: "Random ID suffix", placeholder: "Random ID suffix", fieldType: ftBool},
 	{key: "Username", label: "Username", placeholder: "Username", fieldType: ftText},
 	{key: "Password", label: "Password", fieldType: ftPassword},
+	{key: "PasswordCommand", label: "Password command", placeholder: "Command printing the password", fieldType: ftText},
+	{key: "AuthMode", label: "Auth mode", placeholder: "Auth mode", fieldType: ftSelect, options: []string{AuthPassword, AuthJWT, AuthCommand}},
+	{key: "JWTKeyPath", label: "JWT key path", placeholder: "PEM private key signing the JWT", fieldType: ftText},
+	{key: "JWTAudience", label: "JWT audience", placeholder: "JWT audience", fieldType: ftText},
+	{key: "JWTClaims", label: "JWT claims", placeholder: "key=value, ...", fieldType: ftText},
+	{key: "JWTExpiry", label: "JWT expiry (s)", placeholder: "3600", fieldType: ftText},
 	{key: "FromEnv", label: "Load from env", placeholder: "Values from env", fieldType: ftBool},
 	{key: "SSL", label: "SSL/TLS", placeholder: "SSL/TLS", fieldType: ftBool},
 	{key: "SkipTLSVerify", label: "Skip TLS verify", placeholder: "Skip TLS verify", fieldType: ftBool},
@@ -87,11 +100,14 @@ func NewForm(p Profile, idx int) Form {
 	}
 	pwKey := ""
 	if p.Name != "" && p.Username != "" {
-		pwKey = fmt.Sprintf("keyring:emqutiti-%s/%s", p.Name, p.Username)
+		pwKey, _, _ = secretRef(p.Name, p.Username)
 	}
+	child := p.Parent != ""
+	overrides := overridden(p)
 	rv := reflect.ValueOf(p)
 	fields := make([]ui.Field, len(formFields))
 	for i, fd := range formFields {
+		inherits := child && !notInherited[fd.key] && !overrides[fd.key]
 		placeholder := fd.placeholder
 		if fd.key == "Password" && pwKey != "" {
 			placeholder = pwKey
@@ -104,20 +120,38 @@ func NewForm(p Profile, idx int) Form {
 			strVal = fv.String()
 		case reflect.Int:
 			strVal = fmt.Sprintf("%d", fv.Int())
+			if inherits && fv.Int() == 0 {
+				strVal = ""
+			}
 		case reflect.Bool:
 			boolVal = fv.Bool()
 			strVal = fmt.Sprintf("%v", boolVal)
+			if inherits && !boolVal {
+				strVal = ""
+			}
 		}
-		switch fd.fieldType {
-		case ftBool:
+		switch {
+		case fd.fieldType == ftBool && child && !notInherited[fd.key]:
+			// children inherit an empty switch
+			sf, err := ui.NewSelectField(strVal, []string{"", "true", "false"})
+			if err != nil {
+				sf = &ui.SelectField{}
+			}
+			fields[i] = sf
+		case fd.fieldType == ftBool:
 			fields[i] = ui.NewCheckField(boolVal)
-		case ftSelect:
-			sf, err := ui.NewSelectField(strVal, fd.options)
+		case fd.fieldType == ftSelect:
+			opts := fd.options
+			if child {
+				// children inherit an empty selection
+				opts = append([]string{""}, opts...)
+			}
+			sf, err := ui.NewSelectField(strVal, opts)
 			if err != nil {
 				sf = &ui.SelectField{}
 			}
 			fields[i] = sf
-		case ftPassword:
+		case fd.fieldType == ftPassword:
 			fields[i] = ui.NewTextField(strVal, placeholder, true)
 		default:
 			fields[i] = ui.NewTextField(strVal, placeholder)
@@ -133,11 +167,46 @@ func NewForm(p Profile, idx int) Form {
 			fld.SetReadOnly(true)
 		}
 	}
-	cf := Form{Form: ui.Form{Fields: fields, Focus: 0}, Index: idx, fromEnv: p.FromEnv}
+	var kept []string
+	for _, name := range p.Overrides {
+		if !slices.ContainsFunc(formFields, func(fd fieldDef) bool { return profileTomlName(fd.key) == name }) {
+			kept = append(kept, name)
+		}
+	}
+	cf := Form{Form: ui.Form{Fields: fields, Focus: 0}, Index: idx, fromEnv: p.FromEnv, child: child, kept: kept}
 	cf.ApplyFocus()
 	return cf
 }
 
+// ShowInherited clears the empty fields of a child profile's form and shows
+// the values they inherit from the resolved parent as placeholders.
+func (f *Form) ShowInherited(parent Profile) {
+	rv := reflect.ValueOf(parent)
+	for i, fd := range formFields {
+		tf, ok := f.Fields[i].(*ui.TextField)
+		if !ok || notInherited[fd.key] {
+			continue
+		}
+		if tf.Value() != "" {
+			continue
+		}
+		pv := rv.FieldByName(fd.key)
+		switch {
+		case pv.IsZero():
+		case fd.key == "Password":
+			tf.Placeholder = "inherited from " + parent.Name
+		default:
+			tf.Placeholder = fmt.Sprintf("%v (inherited)", pv.Interface())
+		}
+	}
+}
+
+// profileTomlName returns the config.toml key of the Profile field name.
+func profileTomlName(name string) string {
+	f, _ := reflect.TypeOf(Profile{}).FieldByName(name)
+	return tomlName(f)
+}
+
 // Init sets up the text input blink command.
 func (f Form) Init() tea.Cmd {
 	return textinput.Blink
@@ -208,6 +277,9 @@ func (f Form) View() string {
 		prefix := EnvPrefix(f.Fields[idxName].Value())
 		rows = append(rows, ui.InfoStyle.Render("Values loaded from env vars: "+prefix+"<FIELD>"))
 	}
+	if parent := f.Fields[fieldIndex["Parent"]].Value(); parent != "" {
+		rows = append(rows, ui.InfoStyle.Render("Empty fields and switches inherit from "+parent))
+	}
 	rows = append(rows, "", ui.InfoStyle.Render("[enter] save  [esc] cancel"))
 	return strings.Join(rows, "\n")
 }
@@ -217,7 +289,7 @@ func (f Form) View() string {
 // while parsing numeric or boolean fields.
 func (f Form) Profile() (Profile, error) {
 	p := Profile{}
-	var errs []string
+	var errs, zeros []string
 	rv := reflect.ValueOf(&p).Elem()
 	for i, fd := range formFields {
 		field := rv.FieldByName(fd.key)
@@ -236,15 +308,31 @@ func (f Form) Profile() (Profile, error) {
 				continue
 			}
 			field.SetInt(int64(iv))
+			if iv == 0 {
+				zeros = append(zeros, profileTomlName(fd.key))
+			}
 		case reflect.Bool:
+			if val == "" {
+				field.SetBool(false)
+				continue
+			}
 			bv, err := strconv.ParseBool(val)
 			if err != nil {
 				errs = append(errs, fmt.Sprintf("%s: %v", fd.label, err))
 				continue
 			}
 			field.SetBool(bv)
+			if !bv && !notInherited[fd.key] {
+				zeros = append(zeros, profileTomlName(fd.key))
+			}
 		}
 	}
+	if p.AuthMode == AuthPassword && p.Parent == "" {
+		p.AuthMode = ""
+	}
+	if f.child && p.Parent != "" {
+		p.Overrides = append(zeros, f.kept...)
+	}
 	if len(errs) > 0 {
 		return p, errors.New(strings.Join(errs, "; "))
 	}
@@ -0,0 +1,164 @@
+package connections
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// notInherited are the profile fields a child never takes from its parent.
+var notInherited = map[string]bool{"Name": true, "Parent": true, "FromEnv": true, "Overrides": true, "Ephemeral": true}
+
+// ResolveProfile fills the empty fields and unset switches of p from its
+// parent chain in profiles, except those p or a profile in between
+// overrides. Profiles without a parent are returned as is.
+func ResolveProfile(p Profile, profiles []Profile) (Profile, error) {
+	seen := map[string]bool{p.Name: true}
+	kept := overridden(p)
+	out := p
+	for name := p.Parent; name != ""; {
+		parent := findProfile(profiles, name)
+		if parent == nil {
+			return p, fmt.Errorf("profile %s: parent %q not found", p.Name, name)
+		}
+		if seen[name] {
+			return p, fmt.Errorf("profile %s: parent cycle through %s", p.Name, name)
+		}
+		seen[name] = true
+		inherit(&out, *parent, kept)
+		name = parent.Parent
+	}
+	return out, nil
+}
+
+// CheckParent reports whether p can be saved at idx (-1 for new) with its
+// parent: the parent must be a saved profile and not lead back to p.
+func CheckParent(profiles []Profile, p Profile, idx int) error {
+	if p.Parent == "" {
+		return nil
+	}
+	if parent := findProfile(profiles, p.Parent); parent != nil && parent.Ephemeral {
+		return fmt.Errorf("parent %s is not saved", p.Parent)
+	}
+	candidate := append([]Profile(nil), profiles...)
+	if idx >= 0 && idx < len(candidate) {
+		old := candidate[idx].Name
+		candidate[idx] = p
+		for i := range candidate {
+			if candidate[i].Parent == old {
+				candidate[i].Parent = p.Name
+			}
+		}
+	} else {
+		candidate = append(candidate, p)
+	}
+	_, err := ResolveProfile(p, candidate)
+	return err
+}
+
+// inherit copies the fields of parent into the zero fields of p that are
+// not kept. Fields parent overrides are kept from then on.
+func inherit(p *Profile, parent Profile, kept map[string]bool) {
+	pv := reflect.ValueOf(p).Elem()
+	parv := reflect.ValueOf(parent)
+	for i := 0; i < pv.NumField(); i++ {
+		name := pv.Type().Field(i).Name
+		if notInherited[name] || kept[name] {
+			continue
+		}
+		if f := pv.Field(i); f.IsZero() {
+			f.Set(parv.Field(i))
+		}
+	}
+	for name := range overridden(parent) {
+		kept[name] = true
+	}
+}
+
+// overridden returns the field names of the settings p overrides.
+func overridden(p Profile) map[string]bool {
+	out := map[string]bool{}
+	if len(p.Overrides) == 0 {
+		return out
+	}
+	t := reflect.TypeOf(p)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if slices.Contains(p.Overrides, tomlName(f)) {
+			out[f.Name] = true
+		}
+	}
+	return out
+}
+
+// tomlName returns the key of f in config.toml.
+func tomlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+	return name
+}
+
+func findProfile(profiles []Profile, name string) *Profile {
+	for i := range profiles {
+		if profiles[i].Name == name {
+			return &profiles[i]
+		}
+	}
+	return nil
+}
+
+// Children returns the names of the profiles whose parent is name.
+func Children(profiles []Profile, name string) []string {
+	var names []string
+	for _, p := range profiles {
+		if p.Parent == name && p.Name != name {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// groupOrder orders profiles into a tree with each child below its parent,
+// keeping the original order among siblings. It returns the indexes of the
+// profiles in tree order and the depth of each in the tree.
+func groupOrder(profiles []Profile) ([]int, []int) {
+	out := make([]int, 0, len(profiles))
+	depths := make([]int, 0, len(profiles))
+	placed := make([]bool, len(profiles))
+	var walk func(name string, depth int)
+	walk = func(name string, depth int) {
+		for i, p := range profiles {
+			if !placed[i] && p.Parent == name && p.Name != name {
+				placed[i] = true
+				out = append(out, i)
+				depths = append(depths, depth)
+				walk(p.Name, depth+1)
+			}
+		}
+	}
+	for i, p := range profiles {
+		if placed[i] || (p.Parent != "" && findProfile(profiles, p.Parent) != nil) {
+			continue
+		}
+		placed[i] = true
+		out = append(out, i)
+		depths = append(depths, 0)
+		walk(p.Name, 1)
+	}
+	// profiles in a parent cycle are listed last
+	for i := range profiles {
+		if !placed[i] {
+			out = append(out, i)
+			depths = append(depths, 0)
+		}
+	}
+	return out, depths
+}
+
+// treeTitle indents the title of a profile at depth in the group tree.
+func treeTitle(title string, depth int) string {
+	if depth == 0 {
+		return title
+	}
+	return strings.Repeat("  ", depth-1) + "└ " + title
+}
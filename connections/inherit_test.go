@@ -0,0 +1,196 @@
+package connections
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zalando/go-keyring"
+
+	"github.com/marang/emqutiti/ui"
+)
+
+func TestResolveProfile(t *testing.T) {
+	profiles := []Profile{
+		{Name: "base", Schema: "mqtts", Port: 8883, Username: "fleet", Password: "pw", SSL: true, CACertPath: "/ca.pem", KeepAlive: 30},
+		{Name: "eu", Parent: "base", KeepAlive: 60},
+		{Name: "eu-1", Parent: "eu", Host: "eu1.example.com", ClientID: "eu-1", Username: "ops"},
+	}
+	p, err := ResolveProfile(profiles[2], profiles)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if p.BrokerURL() != "mqtts://eu1.example.com:8883" || !p.SSL || p.CACertPath != "/ca.pem" || p.Password != "pw" {
+		t.Fatalf("expected inherited settings, got %+v", p)
+	}
+	if p.Name != "eu-1" || p.Parent != "eu" || p.KeepAlive != 60 || p.Username != "ops" || p.ClientID != "eu-1" {
+		t.Fatalf("expected child overrides, got %+v", p)
+	}
+
+	profiles[0].Parent = "eu-1"
+	if _, err := ResolveProfile(profiles[2], profiles); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+	if _, err := ResolveProfile(Profile{Name: "x", Parent: "gone"}, profiles); err == nil {
+		t.Fatalf("expected missing parent error")
+	}
+	profiles[0].Parent = ""
+	if err := CheckParent(profiles[:2], Profile{Name: "base", Parent: "eu"}, 0); err == nil {
+		t.Fatalf("expected CheckParent to reject a cycle")
+	}
+	if err := CheckParent(profiles[:2], Profile{Name: "new", Parent: "eu"}, -1); err != nil {
+		t.Fatalf("unexpected CheckParent error: %v", err)
+	}
+}
+
+func TestLoadProfileInherits(t *testing.T) {
+	keyring.MockInit()
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "config.toml")
+	data := `
+[[profiles]]
+name = "base"
+schema = "ssl"
+port = 8883
+ssl_tls = true
+username = "fleet"
+password = "pw"
+
+[[profiles]]
+name = "dev-7"
+parent = "base"
+host = "dev7.local"
+client_id = "dev-7"
+`
+	if err := os.WriteFile(cfg, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadProfile("dev-7", cfg)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if p.BrokerURL() != "ssl://dev7.local:8883" || !p.SSL || p.Username != "fleet" || p.Password != "pw" || p.ClientID != "dev-7" {
+		t.Fatalf("unexpected profile %+v", p)
+	}
+}
+
+func TestProfileGroupsInManager(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("HOME", t.TempDir())
+	mgr := NewConnectionsModel()
+	mgr.AddConnection(Profile{Name: "dev-1", Parent: "base", Host: "dev1.local"})
+	mgr.AddConnection(Profile{Name: "lab", Host: "lab.local"})
+	mgr.AddConnection(Profile{Name: "base", Schema: "mqtt", Port: 1883, Username: "u", Password: "pw"})
+	var titles []string
+	for _, it := range mgr.ConnectionsList.Items() {
+		titles = append(titles, it.(connectionItem).title)
+	}
+	if strings.Join(titles, "|") != "lab|base|└ dev-1" {
+		t.Fatalf("unexpected tree %q", titles)
+	}
+	if mgr.Profiles[0].Name != "dev-1" || mgr.Profiles[2].Name != "base" {
+		t.Fatalf("grouping reordered the saved profiles: %+v", mgr.Profiles)
+	}
+	mgr.ConnectionsList.Select(2)
+	if mgr.Selected() != 0 {
+		t.Fatalf("expected the last list item to be dev-1, got %d", mgr.Selected())
+	}
+	mgr.Select(2)
+	if mgr.ConnectionsList.Index() != 1 {
+		t.Fatalf("expected base at list index 1, got %d", mgr.ConnectionsList.Index())
+	}
+
+	mgr.EditConnection(2, Profile{Name: "fleet", Schema: "mqtt", Port: 1883, Username: "u", Password: "pw"})
+	if mgr.Profiles[0].Parent != "fleet" {
+		t.Fatalf("rename did not update the child: %+v", mgr.Profiles[0])
+	}
+	cfg, _ := DefaultUserConfigFile()
+	loaded, err := LoadConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := *findProfile(loaded.Profiles, "dev-1")
+	if child.Parent != "fleet" || child.Password != "" || child.Port != 0 {
+		t.Fatalf("expected a sparse child in config.toml, got %+v", child)
+	}
+	if p, _ := LoadProfile("dev-1", cfg); p.Password != "pw" || p.Port != 1883 {
+		t.Fatalf("unexpected resolved child %+v", p)
+	}
+
+	b, err := NewBundle(mgr.Profiles, nil, []string{"dev-1"}, "")
+	if err != nil || len(b.Profiles) != 2 {
+		t.Fatalf("expected child and parent in bundle, got %+v %v", b.Profiles, err)
+	}
+}
+
+func TestNewChildKey(t *testing.T) {
+	mgr := NewConnectionsModel()
+	mgr.Profiles = []Profile{{Name: "base"}}
+	mgr.refreshList()
+	api := &testAPI{mgr: &mgr}
+	c := NewComponent(&testNav{}, api)
+	c.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	if !api.began || api.parent != "base" {
+		t.Fatalf("expected child form for base, got %q", api.parent)
+	}
+}
+
+func TestChildFormShowsInherited(t *testing.T) {
+	parent := Profile{Name: "base", Schema: "mqtts", Port: 8883, Password: "pw"}
+	f := NewForm(Profile{Name: "dev", Parent: "base", Host: "dev.local"}, -1)
+	f.ShowInherited(parent)
+	port := f.Fields[fieldIndex["Port"]].(*ui.TextField)
+	if port.Value() != "" || port.Placeholder != "8883 (inherited)" {
+		t.Fatalf("expected inherited port placeholder, got %q", port.Placeholder)
+	}
+	p, err := f.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Port != 0 || p.Schema != "" || p.Host != "dev.local" || p.Parent != "base" {
+		t.Fatalf("expected only overrides in the child, got %+v", p)
+	}
+	if !strings.Contains(f.View(), "inherit from base") {
+		t.Fatalf("expected inheritance note")
+	}
+}
+
+func TestChildOverridesParentSettings(t *testing.T) {
+	profiles := []Profile{
+		{Name: "base", Port: 8883, SSL: true, CleanStart: true, KeepAlive: 30},
+		{Name: "plain", Parent: "base", Port: 1883, Overrides: []string{"ssl_tls", "keep_alive"}},
+		{Name: "dev", Parent: "plain"},
+	}
+	for _, p := range profiles[1:] {
+		r, err := ResolveProfile(p, profiles)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.SSL || r.KeepAlive != 0 || !r.CleanStart || r.Port != 1883 {
+			t.Fatalf("%s: expected ssl and keep alive turned off, got %+v", p.Name, r)
+		}
+	}
+
+	f := NewForm(profiles[1], 1)
+	ssl := f.Fields[fieldIndex["SSL"]]
+	clean := f.Fields[fieldIndex["CleanStart"]]
+	keep := f.Fields[fieldIndex["KeepAlive"]]
+	if ssl.Value() != "false" || clean.Value() != "" || keep.Value() != "0" {
+		t.Fatalf("unexpected child fields ssl=%q clean=%q keep alive=%q", ssl.Value(), clean.Value(), keep.Value())
+	}
+	clean.(*ui.SelectField).Index = 2 // false
+	p, err := f.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(p.Overrides)
+	if strings.Join(p.Overrides, ",") != "clean_start,keep_alive,ssl_tls" {
+		t.Fatalf("unexpected overrides %v", p.Overrides)
+	}
+	if r, _ := ResolveProfile(p, profiles); r.CleanStart {
+		t.Fatalf("expected clean start turned off")
+	}
+}
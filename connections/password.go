@@ -4,12 +4,12 @@ import "os"
 
 // ApplyDefaultPassword assigns the EMQUTITI_DEFAULT_PASSWORD environment variable
 // to the profile's password when the profile is not loaded from the environment
-// and has no existing password.
+// and has neither a password nor a password command.
 func ApplyDefaultPassword(p *Profile) {
 	if p == nil {
 		return
 	}
-	if !p.FromEnv && p.Password == "" {
+	if !p.FromEnv && p.Password == "" && p.PasswordCommand == "" {
 		if env := os.Getenv("EMQUTITI_DEFAULT_PASSWORD"); env != "" {
 			p.Password = env
 		}
@@ -7,15 +7,28 @@ import (
 	"github.com/zalando/go-keyring"
 )
 
-// Profile defines a broker connection.
+// Profile defines a broker connection. Empty fields and unset switches of a
+// profile with a Parent are inherited from it unless listed in Overrides,
+// see ResolveProfile. Password
+// may refer to a secret backend and PasswordCommand, used when Password is
+// empty, prints the password, see ResolvePassword. With AuthMode jwt or
+// command a fresh token is the password of every connection attempt, see
+// TokenSource.
 type Profile struct {
 	Name            string `toml:"name" env:"name"`
+	Parent          string `toml:"parent,omitempty"`
 	Schema          string `toml:"schema" env:"schema"`
 	Host            string `toml:"host" env:"host"`
 	Port            int    `toml:"port" env:"port"`
 	ClientID        string `toml:"client_id" env:"client_id"`
 	Username        string `toml:"username" env:"username"`
 	Password        string `toml:"password" env:"password"`
+	PasswordCommand string `toml:"password_command,omitempty"`
+	AuthMode        string `toml:"auth_mode,omitempty"`
+	JWTKeyPath      string `toml:"jwt_key_path,omitempty"`
+	JWTAudience     string `toml:"jwt_audience,omitempty"`
+	JWTClaims       string `toml:"jwt_claims,omitempty"`
+	JWTExpiry       int    `toml:"jwt_expiry,omitempty"`
 	FromEnv         bool   `toml:"from_env"`
 	SSL             bool   `toml:"ssl_tls" env:"ssl_tls"`
 	SkipTLSVerify   bool   `toml:"skip_tls_verify" env:"skip_tls_verify"`
@@ -47,6 +60,12 @@ type Profile struct {
 	LastWillRetain      bool   `toml:"last_will_retain" env:"last_will_retain"`
 	LastWillPayload     string `toml:"last_will_payload" env:"last_will_payload"`
 	RandomIDSuffix      bool   `toml:"random_id_suffix" env:"random_id_suffix"`
+	// Overrides lists the toml names of the zero numbers and unset switches
+	// a child profile keeps instead of inheriting them.
+	Overrides []string `toml:"overrides,omitempty"`
+	// Ephemeral profiles come from a broker URL and are not saved until
+	// edited.
+	Ephemeral bool `toml:"-"`
 }
 
 // BrokerURL returns the formatted broker URL.
@@ -24,6 +24,8 @@ func DefaultUserConfigFile() (string, error) {
 }
 
 // LoadConfig reads profiles from a TOML file and resolves keyring references.
+// References to other secret backends are resolved when connecting, see
+// ResolvePassword.
 func LoadConfig(filePath string) (*Config, error) {
 	var err error
 	if filePath == "" {
@@ -53,6 +55,7 @@ func LoadConfig(filePath string) (*Config, error) {
 }
 
 // LoadProfile returns the named profile from the config file, falling back to the default or first profile.
+// Settings inherited from parent profiles are resolved.
 func LoadProfile(name, file string) (*Profile, error) {
 	cfg, err := LoadConfig(file)
 	if err != nil {
@@ -80,5 +83,9 @@ func LoadProfile(name, file string) (*Profile, error) {
 	if p == nil {
 		return nil, fmt.Errorf("no connection profile available")
 	}
-	return p, nil
+	resolved, err := ResolveProfile(*p, cfg.Profiles)
+	if err != nil {
+		return nil, err
+	}
+	return &resolved, nil
 }
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/zalando/go-keyring"
 
@@ -12,13 +13,15 @@ import (
 )
 
 // saveConfig persists profiles and default selection to config.toml.
+// Ephemeral profiles are left out.
 func saveConfig(profiles []Profile, defaultName string) error {
 	saved := LoadState()
 	cfg := userConfig{
 		DefaultProfileName: defaultName,
-		Profiles:           profiles,
+		Profiles:           slices.DeleteFunc(slices.Clone(profiles), func(p Profile) bool { return p.Ephemeral }),
 		Saved:              saved,
 		ProxyAddr:          LoadProxyAddr(),
+		SecretStore:        loadSecretStore(),
 	}
 	return writeConfig(cfg)
 }
@@ -46,13 +49,28 @@ func deleteProfileData(name string) error {
 	return errors.Join(historyErr, tracesErr)
 }
 
-// persistProfileChange applies a profile update, saves config and keyring.
+// persistProfileChange applies a profile update, saves config and the
+// password in the secret store. The secret reference saved for the profile
+// is kept when the password still holds it.
 func persistProfileChange(profiles *[]Profile, defaultName string, p Profile, idx int) error {
 	plain := p.Password
-	if !p.FromEnv {
-		p.Password = "keyring:emqutiti-" + p.Name + "/" + p.Username
-	} else {
+	store := false
+	loaded := ""
+	if idx >= 0 && idx < len(*profiles) {
+		loaded = savedSecretRefs()[(*profiles)[idx].Name]
+	}
+	switch {
+	case p.FromEnv:
 		p.Password = ""
+	case plain == "" && (p.Parent != "" || p.PasswordCommand != "" || p.AuthMode == AuthJWT):
+		// the password is inherited, comes from the command or is a token
+	case plain != "" && plain == loaded:
+	default:
+		ref, _, err := secretRef(p.Name, p.Username)
+		if err != nil {
+			return err
+		}
+		p.Password, store = ref, true
 	}
 	if idx >= 0 && idx < len(*profiles) {
 		(*profiles)[idx] = p
@@ -62,8 +80,8 @@ func persistProfileChange(profiles *[]Profile, defaultName string, p Profile, id
 	if err := saveConfig(*profiles, defaultName); err != nil {
 		return err
 	}
-	if !p.FromEnv {
-		if err := savePasswordToKeyring(p.Name, p.Username, plain); err != nil {
+	if store {
+		if _, err := storePassword(p.Name, p.Username, plain); err != nil {
 			return err
 		}
 	}
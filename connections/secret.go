@@ -0,0 +1,236 @@
+package connections
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/zalando/go-keyring"
+)
+
+// SecretProvider is a backend that stores passwords. Profiles refer to a
+// stored password as "<backend>:<key>", e.g. "pass:emqutiti/lab/user".
+type SecretProvider interface {
+	Get(key string) (string, error)
+	Set(key, secret string) error
+	// Key returns the key the password of a profile is stored under.
+	Key(profile, username string) string
+}
+
+// secretProviders are the backends by reference prefix.
+var secretProviders = map[string]SecretProvider{
+	"keyring": keyringStore{},
+	"file":    fileStore{},
+	"pass":    passStore{},
+	"vault":   vaultStore{},
+}
+
+// SecretBackends returns the names of the available secret backends.
+func SecretBackends() []string {
+	names := make([]string, 0, len(secretProviders))
+	for name := range secretProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsSecretRef reports whether password refers to a secret backend instead
+// of being the password itself.
+func IsSecretRef(password string) bool {
+	_, _, ok := splitSecretRef(password)
+	return ok
+}
+
+func splitSecretRef(password string) (SecretProvider, string, bool) {
+	backend, key, ok := strings.Cut(password, ":")
+	if !ok || key == "" {
+		return nil, "", false
+	}
+	sp, ok := secretProviders[backend]
+	return sp, key, ok
+}
+
+// ResolveSecret returns the password a reference points to. Plain passwords
+// are returned unchanged.
+func ResolveSecret(password string) (string, error) {
+	sp, key, ok := splitSecretRef(password)
+	if !ok {
+		return password, nil
+	}
+	return sp.Get(key)
+}
+
+// savedSecretRefs returns the secret references config.toml holds by
+// profile name. Only these are references: a password typed into the form
+// or resolved from the keyring may look like one and is still a password.
+func savedSecretRefs() map[string]string {
+	fp, err := DefaultUserConfigFile()
+	if err != nil {
+		return map[string]string{}
+	}
+	var cfg userConfig
+	if _, err := toml.DecodeFile(fp, &cfg); err != nil {
+		return map[string]string{}
+	}
+	return secretRefs(cfg.Profiles)
+}
+
+// secretRefs returns the secret references of profiles read from
+// config.toml by profile name.
+func secretRefs(profiles []Profile) map[string]string {
+	refs := map[string]string{}
+	for _, p := range profiles {
+		if IsSecretRef(p.Password) {
+			refs[p.Name] = p.Password
+		}
+	}
+	return refs
+}
+
+// ResolvePassword replaces the password reference of p with the password.
+// Without a password the profile's password_command is run instead.
+func ResolvePassword(p *Profile) error {
+	if p.FromEnv {
+		return nil
+	}
+	if p.Password == "" && p.PasswordCommand != "" {
+		pw, err := runPasswordCommand(p.PasswordCommand)
+		if err != nil {
+			return fmt.Errorf("password command of %s: %w", p.Name, err)
+		}
+		p.Password = pw
+		return nil
+	}
+	pw, err := ResolveSecret(p.Password)
+	if err != nil {
+		return fmt.Errorf("password of %s: %w", p.Name, err)
+	}
+	p.Password = pw
+	return nil
+}
+
+// runPasswordCommand runs command with the shell and returns the first line
+// of its output.
+func runPasswordCommand(command string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return firstLine(out), nil
+}
+
+func firstLine(out []byte) string {
+	line, _, _ := strings.Cut(string(out), "\n")
+	return strings.TrimRight(line, "\r")
+}
+
+// SecretStore returns the name of the backend new passwords are stored in:
+// EMQUTITI_SECRET_STORE, secret_store in config.toml or the keyring.
+func SecretStore() string {
+	if name := os.Getenv("EMQUTITI_SECRET_STORE"); name != "" {
+		return name
+	}
+	if name := loadSecretStore(); name != "" {
+		return name
+	}
+	return "keyring"
+}
+
+// loadSecretStore returns secret_store from config.toml.
+func loadSecretStore() string {
+	fp, err := DefaultUserConfigFile()
+	if err != nil {
+		return ""
+	}
+	var cfg struct {
+		SecretStore string `toml:"secret_store"`
+	}
+	if _, err := toml.DecodeFile(fp, &cfg); err != nil {
+		return ""
+	}
+	return cfg.SecretStore
+}
+
+// secretRef returns the reference to the stored password of a profile.
+func secretRef(profile, username string) (string, SecretProvider, error) {
+	name := SecretStore()
+	sp, ok := secretProviders[name]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown secret store %q, use one of %s", name, strings.Join(SecretBackends(), ", "))
+	}
+	return name + ":" + sp.Key(profile, username), sp, nil
+}
+
+// storePassword saves the password of a profile in the configured secret
+// store and returns the reference to keep in config.toml.
+func storePassword(profile, username, password string) (string, error) {
+	ref, sp, err := secretRef(profile, username)
+	if err != nil {
+		return "", err
+	}
+	_, key, _ := strings.Cut(ref, ":")
+	if err := sp.Set(key, password); err != nil {
+		return "", err
+	}
+	return ref, nil
+}
+
+// keyringStore keeps passwords in the OS keyring under service/user keys.
+type keyringStore struct{}
+
+func (keyringStore) Get(key string) (string, error) {
+	return RetrievePasswordFromKeyring("keyring:" + key)
+}
+
+func (keyringStore) Set(key, secret string) error {
+	service, user, ok := strings.Cut(key, "/")
+	if !ok {
+		return fmt.Errorf("invalid keyring format: %s", key)
+	}
+	return keyring.Set(service, user, secret)
+}
+
+func (keyringStore) Key(profile, username string) string {
+	return "emqutiti-" + profile + "/" + username
+}
+
+// passCommand is the password-store executable.
+var passCommand = "pass"
+
+// passStore keeps passwords in the password-store of pass(1).
+type passStore struct{}
+
+func (passStore) Get(key string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(passCommand, "show", key)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("pass show %s: %w: %s", key, err, strings.TrimSpace(stderr.String()))
+	}
+	return firstLine(out), nil
+}
+
+func (passStore) Set(key, secret string) error {
+	cmd := exec.Command(passCommand, "insert", "--multiline", "--force", key)
+	cmd.Stdin = strings.NewReader(secret + "\n")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("pass insert %s: %w: %s", key, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (passStore) Key(profile, username string) string {
+	return "emqutiti/" + profile + "/" + username
+}
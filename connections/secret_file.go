@@ -0,0 +1,125 @@
+package connections
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// secretsCheck is sealed into the secrets file to verify the passphrase
+// before adding a secret.
+const secretsCheck = "emqutiti"
+
+// secretsFile is the layout of secrets.toml. Secrets are encrypted like
+// bundle secrets with a key derived from EMQUTITI_SECRETS_PASSPHRASE.
+type secretsFile struct {
+	Salt    string            `toml:"salt"`
+	Check   string            `toml:"check"`
+	Secrets map[string]string `toml:"secrets"`
+}
+
+// DefaultSecretsFile returns ~/.config/emqutiti/secrets.toml.
+func DefaultSecretsFile() (string, error) {
+	cfg, err := DefaultUserConfigFile()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(cfg), "secrets.toml"), nil
+}
+
+// fileStore keeps passwords in the passphrase encrypted secrets file for
+// machines without a keyring.
+type fileStore struct{}
+
+func (fileStore) Get(key string) (string, error) {
+	sf, aead, err := openSecretsFile()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := sf.Secrets[key]
+	if !ok {
+		return "", fmt.Errorf("no secret %s in the secrets file", key)
+	}
+	pw, err := open(aead, secret)
+	if err != nil {
+		return "", fmt.Errorf("wrong passphrase or damaged secret %s", key)
+	}
+	return pw, nil
+}
+
+func (fileStore) Set(key, secret string) error {
+	sf, aead, err := openSecretsFile()
+	if err != nil {
+		return err
+	}
+	if sf.Secrets[key], err = seal(aead, secret); err != nil {
+		return err
+	}
+	fp, err := DefaultSecretsFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fp), os.ModePerm); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(sf); err != nil {
+		return err
+	}
+	return os.WriteFile(fp, buf.Bytes(), 0o600)
+}
+
+func (fileStore) Key(profile, username string) string {
+	return profile + "/" + username
+}
+
+// openSecretsFile reads the secrets file, or starts a new one, and returns
+// the cipher for its secrets.
+func openSecretsFile() (secretsFile, cipher.AEAD, error) {
+	var sf secretsFile
+	passphrase := os.Getenv("EMQUTITI_SECRETS_PASSPHRASE")
+	if passphrase == "" {
+		return sf, nil, errors.New("set EMQUTITI_SECRETS_PASSPHRASE to use the secrets file")
+	}
+	fp, err := DefaultSecretsFile()
+	if err != nil {
+		return sf, nil, err
+	}
+	if _, err := toml.DecodeFile(fp, &sf); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return sf, nil, fmt.Errorf("failed to decode secrets file: %w", err)
+	}
+	if sf.Secrets == nil {
+		sf.Secrets = map[string]string{}
+	}
+	if sf.Salt == "" {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return sf, nil, err
+		}
+		sf.Salt = base64.StdEncoding.EncodeToString(salt)
+	}
+	salt, err := base64.StdEncoding.DecodeString(sf.Salt)
+	if err != nil || len(salt) == 0 {
+		return sf, nil, fmt.Errorf("invalid secrets file salt")
+	}
+	aead, err := bundleCipher(passphrase, salt)
+	if err != nil {
+		return sf, nil, err
+	}
+	if sf.Check == "" {
+		if sf.Check, err = seal(aead, secretsCheck); err != nil {
+			return sf, nil, err
+		}
+	} else if check, err := open(aead, sf.Check); err != nil || check != secretsCheck {
+		return sf, nil, errors.New("wrong passphrase for the secrets file")
+	}
+	return sf, aead, nil
+}
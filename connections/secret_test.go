@@ -0,0 +1,208 @@
+package connections
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestFileSecretStore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("EMQUTITI_SECRET_STORE", "file")
+	t.Setenv("EMQUTITI_SECRETS_PASSPHRASE", "hunter2")
+	mgr := NewConnectionsModel()
+	mgr.AddConnection(Profile{Name: "lab", Host: "lab.local", Username: "u", Password: "pw"})
+	cfg, _ := DefaultUserConfigFile()
+	data, _ := os.ReadFile(cfg)
+	if !strings.Contains(string(data), `password = "file:lab/u"`) {
+		t.Fatalf("expected a file reference:\n%s", data)
+	}
+	fp, _ := DefaultSecretsFile()
+	info, err := os.Stat(fp)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected a private secrets file, got %v %v", info, err)
+	}
+	if data, _ := os.ReadFile(fp); strings.Contains(string(data), "pw") {
+		t.Fatalf("password stored in clear:\n%s", data)
+	}
+	p, err := LoadProfile("lab", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ResolvePassword(p); err != nil || p.Password != "pw" {
+		t.Fatalf("resolve: %q %v", p.Password, err)
+	}
+
+	t.Setenv("EMQUTITI_SECRETS_PASSPHRASE", "wrong")
+	if _, err := ResolveSecret("file:lab/u"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Fatalf("expected passphrase error, got %v", err)
+	}
+	if err := (fileStore{}).Set("other/u", "x"); err == nil {
+		t.Fatalf("expected Set to refuse a wrong passphrase")
+	}
+	t.Setenv("EMQUTITI_SECRETS_PASSPHRASE", "")
+	if _, err := ResolveSecret("file:lab/u"); err == nil {
+		t.Fatalf("expected error without passphrase")
+	}
+}
+
+func TestPassSecretStore(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "pass")
+	fake := `#!/bin/sh
+case "$1" in
+show) cat "$STORE/$2" || exit 1 ;;
+insert) mkdir -p "$(dirname "$STORE/$4")" && cat > "$STORE/$4" ;;
+esac
+`
+	if err := os.WriteFile(script, []byte(fake), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("STORE", dir)
+	old := passCommand
+	passCommand = script
+	t.Cleanup(func() { passCommand = old })
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("EMQUTITI_SECRET_STORE", "pass")
+
+	ref, err := storePassword("lab", "u", "pw")
+	if err != nil || ref != "pass:emqutiti/lab/u" {
+		t.Fatalf("store: %q %v", ref, err)
+	}
+	if pw, err := ResolveSecret(ref); err != nil || pw != "pw" {
+		t.Fatalf("resolve: %q %v", pw, err)
+	}
+	if _, err := ResolveSecret("pass:missing"); err == nil {
+		t.Fatalf("expected error for a missing entry")
+	}
+}
+
+func TestVaultSecretStore(t *testing.T) {
+	kv := map[string]map[string]string{"secret/old": {"pw": "v1-pw"}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "tok" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/v1/")
+		v2 := strings.HasPrefix(path, "secret/data/")
+		switch r.Method {
+		case http.MethodPost:
+			var body struct {
+				Data map[string]string `json:"data"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			kv[path] = body.Data
+		case http.MethodGet:
+			data, ok := kv[path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"errors":[]}`))
+				return
+			}
+			if v2 {
+				json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": data, "metadata": map[string]any{}}})
+			} else {
+				json.NewEncoder(w).Encode(map[string]any{"data": data})
+			}
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "tok")
+	t.Setenv("EMQUTITI_SECRET_STORE", "vault")
+
+	ref, err := storePassword("lab", "u", "pw")
+	if err != nil || ref != "vault:secret/data/emqutiti/lab#password" {
+		t.Fatalf("store: %q %v", ref, err)
+	}
+	if pw, err := ResolveSecret(ref); err != nil || pw != "pw" {
+		t.Fatalf("resolve: %q %v", pw, err)
+	}
+	if pw, err := ResolveSecret("vault:secret/old#pw"); err != nil || pw != "v1-pw" {
+		t.Fatalf("resolve kv v1: %q %v", pw, err)
+	}
+	if _, err := ResolveSecret("vault:secret/data/none"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	t.Setenv("VAULT_TOKEN", "bad")
+	if _, err := ResolveSecret(ref); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("expected permission error, got %v", err)
+	}
+}
+
+func TestPasswordCommand(t *testing.T) {
+	t.Setenv("EMQUTITI_DEFAULT_PASSWORD", "default")
+	p := Profile{Name: "lab", PasswordCommand: "printf 'pw\\nrest'"}
+	ApplyDefaultPassword(&p)
+	if err := ResolvePassword(&p); err != nil || p.Password != "pw" {
+		t.Fatalf("expected command password, got %q %v", p.Password, err)
+	}
+	p = Profile{Name: "lab", PasswordCommand: "echo oops >&2; exit 3"}
+	if err := ResolvePassword(&p); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Fatalf("expected command error, got %v", err)
+	}
+	p = Profile{Name: "lab", Password: "plain:text"}
+	if err := ResolvePassword(&p); err != nil || p.Password != "plain:text" {
+		t.Fatalf("plain password changed: %q %v", p.Password, err)
+	}
+}
+
+func TestSecretReferencesAreKept(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("HOME", t.TempDir())
+	cfg, _ := DefaultUserConfigFile()
+	os.MkdirAll(filepath.Dir(cfg), 0o755)
+	data := `
+[[profiles]]
+name = "lab"
+username = "u"
+password = "pass:mqtt/lab"
+`
+	if err := os.WriteFile(cfg, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mgr := NewConnectionsModel()
+	if err := mgr.LoadProfiles(cfg); err != nil {
+		t.Fatal(err)
+	}
+	mgr.EditConnection(0, mgr.Profiles[0])
+	mgr.AddConnection(Profile{Name: "ci", Username: "u", PasswordCommand: "cat /run/secrets/mqtt"})
+	loaded, err := LoadConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Profiles[0].Password != "pass:mqtt/lab" || loaded.Profiles[1].Password != "" || loaded.Profiles[1].PasswordCommand == "" {
+		t.Fatalf("unexpected stored passwords %+v", loaded.Profiles)
+	}
+
+	// typed passwords that look like a reference are passwords
+	p := mgr.Profiles[0]
+	p.Password = "vault:typed"
+	mgr.EditConnection(0, p)
+	mgr.AddConnection(Profile{Name: "new", Username: "u", Password: "file:typed"})
+	raw, _ := os.ReadFile(cfg)
+	if !strings.Contains(string(raw), `"keyring:emqutiti-lab/u"`) || !strings.Contains(string(raw), `"keyring:emqutiti-new/u"`) {
+		t.Fatalf("typed passwords not stored in the keyring:\n%s", raw)
+	}
+	// a keyring password resolved on load is stored again, not kept as reference
+	mgr.LoadProfiles(cfg)
+	mgr.EditConnection(0, mgr.Profiles[0])
+	if pw, err := keyring.Get("emqutiti-lab", "u"); err != nil || pw != "vault:typed" {
+		t.Fatalf("expected the resolved password in the keyring, got %q %v", pw, err)
+	}
+
+	t.Setenv("EMQUTITI_SECRET_STORE", "nope")
+	mgr.AddConnection(Profile{Name: "x", Username: "u", Password: "pw"})
+	if len(mgr.Profiles) != 3 {
+		t.Fatalf("expected unknown store to be rejected")
+	}
+}
@@ -0,0 +1,120 @@
+package connections
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// vaultClient talks to the Vault HTTP API.
+var vaultClient = &http.Client{Timeout: 10 * time.Second}
+
+// vaultStore keeps passwords in a HashiCorp Vault compatible KV secrets
+// engine at VAULT_ADDR, authenticated with VAULT_TOKEN. Keys are
+// "<path>#<field>"; paths with a "data" segment after the mount use the KV
+// version 2 layout.
+type vaultStore struct{}
+
+func (vaultStore) Get(key string) (string, error) {
+	path, field := vaultKey(key)
+	body, err := vaultRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("vault %s: %w", path, err)
+	}
+	data := resp.Data
+	if kvV2(path) {
+		data = nil
+		if err := json.Unmarshal(resp.Data["data"], &data); err != nil {
+			return "", fmt.Errorf("vault %s: %w", path, err)
+		}
+	}
+	var secret string
+	if err := json.Unmarshal(data[field], &secret); err != nil {
+		return "", fmt.Errorf("vault %s: no string field %s", path, field)
+	}
+	return secret, nil
+}
+
+func (vaultStore) Set(key, secret string) error {
+	path, field := vaultKey(key)
+	var payload any = map[string]string{field: secret}
+	if kvV2(path) {
+		payload = map[string]any{"data": payload}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = vaultRequest(http.MethodPost, path, data)
+	return err
+}
+
+// Key stores passwords below emqutiti/ in the KV version 2 engine mounted
+// at EMQUTITI_VAULT_MOUNT, "secret" by default.
+func (vaultStore) Key(profile, username string) string {
+	mount := os.Getenv("EMQUTITI_VAULT_MOUNT")
+	if mount == "" {
+		mount = "secret"
+	}
+	return strings.Trim(mount, "/") + "/data/emqutiti/" + profile + "#password"
+}
+
+func vaultKey(key string) (path, field string) {
+	path, field, _ = strings.Cut(key, "#")
+	if field == "" {
+		field = "password"
+	}
+	return strings.Trim(path, "/"), field
+}
+
+func kvV2(path string) bool {
+	parts := strings.Split(path, "/")
+	return len(parts) > 2 && parts[1] == "data"
+}
+
+func vaultRequest(method, path string, body []byte) ([]byte, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return nil, errors.New("set VAULT_ADDR to use vault secrets")
+	}
+	req, err := http.NewRequest(method, strings.TrimRight(addr, "/")+"/v1/"+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", os.Getenv("VAULT_TOKEN"))
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := vaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("vault %s: %w", path, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		var e struct {
+			Errors []string `json:"errors"`
+		}
+		json.Unmarshal(data, &e)
+		return nil, fmt.Errorf("vault %s: %s", path, strings.TrimSpace(resp.Status+" "+strings.Join(e.Errors, "; ")))
+	}
+	return data, nil
+}
@@ -2,6 +2,9 @@ package connections
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 
@@ -20,6 +23,7 @@ type userConfig struct {
 	Profiles           []Profile                     `toml:"profiles"`
 	Saved              map[string]ConnectionSnapshot `toml:"saved"`
 	ProxyAddr          string                        `toml:"proxy_addr"`
+	SecretStore        string                        `toml:"secret_store,omitempty"`
 }
 
 // LoadState retrieves saved topics and payloads from config.toml.
@@ -57,6 +61,34 @@ func writeConfig(cfg userConfig) error {
 	return nil
 }
 
+// updateConfig applies fn to the profiles and saved state in config.toml
+// and writes them back, keeping the file's other tables.
+func updateConfig(fn func(*userConfig) error) error {
+	fp, err := DefaultUserConfigFile()
+	if err != nil {
+		return err
+	}
+	var cfg userConfig
+	raw := map[string]interface{}{}
+	if _, err := toml.DecodeFile(fp, &cfg); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to decode config file: %w", err)
+	}
+	toml.DecodeFile(fp, &raw) // ignore errors for new files
+	if err := fn(&cfg); err != nil {
+		return err
+	}
+	raw["profiles"] = cfg.Profiles
+	raw["saved"] = cfg.Saved
+	if err := os.MkdirAll(filepath.Dir(fp), os.ModePerm); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(raw); err != nil {
+		return err
+	}
+	return os.WriteFile(fp, buf.Bytes(), 0644)
+}
+
 // SaveState updates only the Saved section in config.toml.
 func SaveState(data map[string]ConnectionSnapshot) error {
 	fp, err := DefaultUserConfigFile()
@@ -0,0 +1,240 @@
+package connections
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// certExpiryWarning is how long before expiry a certificate is flagged.
+const certExpiryWarning = 30 * 24 * time.Hour
+
+// TLSReport is the outcome of a TLS handshake against the broker of a
+// profile.
+type TLSReport struct {
+	Address string
+	Version string
+	Cipher  string
+	// Chain is the certificate chain sent by the server, leaf first.
+	Chain    []CertInfo
+	Verified bool
+	// Problems explain why the handshake or the verification failed.
+	Problems []string
+	Warnings []string
+}
+
+// CertInfo summarizes a certificate of the server chain.
+type CertInfo struct {
+	Subject   string
+	Issuer    string
+	NotBefore time.Time
+	NotAfter  time.Time
+	SANs      []string
+}
+
+// CheckTLS performs a TLS handshake with the broker of p and verifies the
+// server's chain against CACertPath, or the system roots without one.
+func CheckTLS(p Profile, timeout time.Duration) TLSReport {
+	return checkTLS(p, timeout, time.Now())
+}
+
+func checkTLS(p Profile, timeout time.Duration, now time.Time) TLSReport {
+	r := TLSReport{Address: net.JoinHostPort(p.Host, strconv.Itoa(p.Port))}
+	if !p.SSL && !slices.Contains([]string{"ssl", "mqtts", "wss", "tls"}, p.Schema) {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("SSL/TLS is off for this profile (schema %s), checked anyway", p.Schema))
+	}
+	if p.SkipTLSVerify {
+		r.Warnings = append(r.Warnings, "skip TLS verify is on, connections accept any server certificate")
+	}
+	cfg := &tls.Config{InsecureSkipVerify: true, ServerName: p.Host}
+	if p.ClientCertPath != "" {
+		cert, err := tls.LoadX509KeyPair(p.ClientCertPath, p.ClientKeyPath)
+		if err != nil {
+			r.Problems = append(r.Problems, fmt.Sprintf("client certificate %s: %v", p.ClientCertPath, err))
+		} else {
+			cfg.Certificates = []tls.Certificate{cert}
+			if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil {
+				r.Warnings = append(r.Warnings, expiryWarnings("client certificate", leaf, now)...)
+			}
+		}
+	}
+	var roots *x509.CertPool
+	rootsName := "the system roots"
+	if p.CACertPath != "" {
+		rootsName = p.CACertPath
+		data, err := os.ReadFile(p.CACertPath)
+		roots = x509.NewCertPool()
+		switch {
+		case err != nil:
+			r.Problems = append(r.Problems, fmt.Sprintf("CA certificate: %v", err))
+		case !roots.AppendCertsFromPEM(data):
+			r.Problems = append(r.Problems, fmt.Sprintf("CA certificate %s holds no PEM certificates", p.CACertPath))
+		}
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", r.Address, cfg)
+	if err != nil {
+		r.Problems = append(r.Problems, explainHandshake(err))
+		return r
+	}
+	defer conn.Close()
+	state := conn.ConnectionState()
+	r.Version = tls.VersionName(state.Version)
+	r.Cipher = tls.CipherSuiteName(state.CipherSuite)
+	for _, c := range state.PeerCertificates {
+		r.Chain = append(r.Chain, CertInfo{
+			Subject:   certName(c.Subject.CommonName, c.Subject.String()),
+			Issuer:    certName(c.Issuer.CommonName, c.Issuer.String()),
+			NotBefore: c.NotBefore,
+			NotAfter:  c.NotAfter,
+			SANs:      sans(c),
+		})
+	}
+	if len(state.PeerCertificates) == 0 {
+		r.Problems = append(r.Problems, "the server sent no certificate")
+		return r
+	}
+	leaf := state.PeerCertificates[0]
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+		DNSName:       p.Host,
+		CurrentTime:   now,
+	}
+	for _, c := range state.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	if _, err := leaf.Verify(opts); err != nil {
+		r.Problems = append(r.Problems, explainVerify(err, state.PeerCertificates, p.Host, rootsName))
+	} else {
+		r.Verified = true
+		r.Warnings = append(r.Warnings, expiryWarnings("server certificate", leaf, now)...)
+	}
+	return r
+}
+
+// explainHandshake turns a dial or handshake error into a hint.
+func explainHandshake(err error) string {
+	var recErr tls.RecordHeaderError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &recErr):
+		return "the port does not speak TLS, it may be a plain MQTT or WebSocket port"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Sprintf("no answer in time: %v", err)
+	case strings.Contains(err.Error(), "certificate required"), strings.Contains(err.Error(), "bad certificate"):
+		return fmt.Sprintf("the server rejected the client certificate: %v", err)
+	}
+	return fmt.Sprintf("handshake failed: %v", err)
+}
+
+// explainVerify describes why the server chain did not verify.
+func explainVerify(err error, chain []*x509.Certificate, host, rootsName string) string {
+	leaf := chain[0]
+	var authErr x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	switch {
+	case errors.As(err, &authErr):
+		top := chain[len(chain)-1]
+		issuer := certName(top.Issuer.CommonName, top.Issuer.String())
+		switch {
+		case len(chain) == 1 && top.Subject.String() == top.Issuer.String():
+			return fmt.Sprintf("the server certificate is self-signed and not in %s; add it as CA certificate or turn on skip TLS verify", rootsName)
+		case len(chain) == 1:
+			return fmt.Sprintf("the server sent no intermediate certificates and %q is not in %s", issuer, rootsName)
+		}
+		return fmt.Sprintf("the chain ends at %q, which is not in %s", issuer, rootsName)
+	case errors.As(err, &hostErr):
+		if len(leaf.DNSNames) == 0 && len(leaf.IPAddresses) == 0 {
+			return fmt.Sprintf("the certificate has no SANs, only the common name %q, which is no longer accepted", leaf.Subject.CommonName)
+		}
+		return fmt.Sprintf("the certificate is valid for %s, not %s", strings.Join(sans(leaf), ", "), host)
+	case errors.As(err, &invalidErr):
+		name := certName(invalidErr.Cert.Subject.CommonName, invalidErr.Cert.Subject.String())
+		switch invalidErr.Reason {
+		case x509.Expired:
+			return fmt.Sprintf("certificate %q is only valid from %s to %s", name, formatDate(invalidErr.Cert.NotBefore), formatDate(invalidErr.Cert.NotAfter))
+		case x509.NotAuthorizedToSign:
+			return fmt.Sprintf("certificate %q is not a CA and cannot sign other certificates", name)
+		case x509.IncompatibleUsage:
+			return fmt.Sprintf("certificate %q is not meant for server authentication", name)
+		}
+	}
+	return fmt.Sprintf("verification failed: %v", err)
+}
+
+// expiryWarnings flags an expired, not yet valid or soon expiring certificate.
+func expiryWarnings(what string, c *x509.Certificate, now time.Time) []string {
+	switch {
+	case now.After(c.NotAfter):
+		return []string{fmt.Sprintf("%s expired on %s", what, formatDate(c.NotAfter))}
+	case now.Before(c.NotBefore):
+		return []string{fmt.Sprintf("%s is not valid before %s", what, formatDate(c.NotBefore))}
+	case c.NotAfter.Sub(now) < certExpiryWarning:
+		return []string{fmt.Sprintf("%s expires in %d days, on %s", what, int(c.NotAfter.Sub(now).Hours()/24), formatDate(c.NotAfter))}
+	}
+	return nil
+}
+
+// Lines renders the report for display.
+func (r TLSReport) Lines() []string {
+	lines := []string{"Address   " + r.Address}
+	if r.Version != "" {
+		lines = append(lines, "Protocol  "+r.Version+", "+r.Cipher)
+		verified := "no"
+		if r.Verified {
+			verified = "yes"
+		}
+		lines = append(lines, "Verified  "+verified)
+	}
+	if len(r.Chain) > 0 {
+		lines = append(lines, "", "Certificate chain")
+		for i, c := range r.Chain {
+			lines = append(lines,
+				fmt.Sprintf("%2d %s", i, c.Subject),
+				"   issued by "+c.Issuer,
+				fmt.Sprintf("   valid %s to %s", formatDate(c.NotBefore), formatDate(c.NotAfter)))
+			if len(c.SANs) > 0 {
+				lines = append(lines, "   SANs "+strings.Join(c.SANs, ", "))
+			}
+		}
+	}
+	if len(r.Problems) > 0 {
+		lines = append(lines, "", "Problems")
+		for _, p := range r.Problems {
+			lines = append(lines, " ✗ "+p)
+		}
+	}
+	if len(r.Warnings) > 0 {
+		lines = append(lines, "", "Warnings")
+		for _, w := range r.Warnings {
+			lines = append(lines, " ! "+w)
+		}
+	}
+	return lines
+}
+
+func sans(c *x509.Certificate) []string {
+	names := append([]string(nil), c.DNSNames...)
+	for _, ip := range c.IPAddresses {
+		names = append(names, ip.String())
+	}
+	return names
+}
+
+func certName(cn, full string) string {
+	if cn != "" {
+		return cn
+	}
+	return full
+}
+
+func formatDate(t time.Time) string { return t.UTC().Format("2006-01-02") }
@@ -0,0 +1,153 @@
+package connections
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// issueCert creates a certificate for name valid until notAfter, signed by
+// parent or self-signed without one.
+func issueCert(t *testing.T, name string, isCA bool, notAfter time.Time, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if isCA {
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		tmpl.DNSNames = []string{name}
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return cert, key
+}
+
+func writePEM(t *testing.T, dir, name string, cert *x509.Certificate, key *ecdsa.PrivateKey) (string, string) {
+	t.Helper()
+	certPath := filepath.Join(dir, name+".pem")
+	os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0o600)
+	der, _ := x509.MarshalECPrivateKey(key)
+	keyPath := filepath.Join(dir, name+"-key.pem")
+	os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600)
+	return certPath, keyPath
+}
+
+// serve accepts connections on a local port and hands them to handle.
+func serve(t *testing.T, handle func(net.Conn)) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestCheckTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := issueCert(t, "Lab CA", true, time.Now().Add(365*24*time.Hour), nil, nil)
+	srv, srvKey := issueCert(t, "localhost", false, time.Now().Add(10*24*time.Hour), ca, caKey)
+	caPath, _ := writePEM(t, dir, "ca", ca, caKey)
+	cli, cliKey := issueCert(t, "client", false, time.Now().Add(5*24*time.Hour), ca, caKey)
+	cliPath, cliKeyPath := writePEM(t, dir, "client", cli, cliKey)
+	cfg := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{srv.Raw}, PrivateKey: srvKey}}}
+	port := serve(t, func(c net.Conn) { tls.Server(c, cfg).Handshake() })
+
+	p := Profile{Name: "lab", Schema: "ssl", Host: "localhost", Port: port, SSL: true, CACertPath: caPath, ClientCertPath: cliPath, ClientKeyPath: cliKeyPath}
+	r := CheckTLS(p, 5*time.Second)
+	if !r.Verified || len(r.Problems) != 0 || r.Version == "" || len(r.Chain) != 1 || r.Chain[0].Issuer != "Lab CA" {
+		t.Fatalf("expected a verified chain, got %+v", r)
+	}
+	warnings := strings.Join(r.Warnings, "\n")
+	if !strings.Contains(warnings, "client certificate expires in") || !strings.Contains(warnings, "server certificate expires in") {
+		t.Fatalf("expected expiry warnings, got %q", warnings)
+	}
+
+	p.CACertPath = ""
+	if r := CheckTLS(p, 5*time.Second); r.Verified || !strings.Contains(strings.Join(r.Problems, ""), "not in the system roots") {
+		t.Fatalf("expected unknown authority, got %+v", r.Problems)
+	}
+	p.CACertPath, p.Host = caPath, "127.0.0.1"
+	if r := CheckTLS(p, 5*time.Second); !strings.Contains(strings.Join(r.Problems, ""), "valid for localhost, not 127.0.0.1") {
+		t.Fatalf("expected hostname mismatch, got %+v", r.Problems)
+	}
+	p.Host = "localhost"
+	if r := checkTLS(p, 5*time.Second, time.Now().Add(30*24*time.Hour)); !strings.Contains(strings.Join(r.Problems, ""), "is only valid from") {
+		t.Fatalf("expected expired certificate, got %+v", r.Problems)
+	}
+
+	plain := serve(t, func(c net.Conn) { c.Write([]byte("hello, this is not TLS\n")) })
+	r = CheckTLS(Profile{Name: "plain", Schema: "tcp", Host: "127.0.0.1", Port: plain}, 5*time.Second)
+	if !strings.Contains(strings.Join(r.Problems, ""), "does not speak TLS") || !strings.Contains(strings.Join(r.Warnings, ""), "SSL/TLS is off") {
+		t.Fatalf("expected plain port hint, got %+v", r)
+	}
+}
+
+func TestTLSPanel(t *testing.T) {
+	ca, caKey := issueCert(t, "Lab CA", true, time.Now().Add(time.Hour), nil, nil)
+	srv, srvKey := issueCert(t, "localhost", false, time.Now().Add(time.Hour), ca, caKey)
+	cfg := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{srv.Raw}, PrivateKey: srvKey}}}
+	port := serve(t, func(c net.Conn) { tls.Server(c, cfg).Handshake() })
+
+	mgr := NewConnectionsModel()
+	mgr.Profiles = []Profile{{Name: "base", Host: "localhost", SSL: true}, {Name: "lab", Parent: "base", Port: port}}
+	mgr.refreshList()
+	mgr.ConnectionsList.Select(1)
+	api := &testAPI{mgr: &mgr}
+	c := NewComponent(&testNav{}, api)
+	cmd := c.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	if c.tls == nil || cmd == nil || !strings.Contains(c.View(), "Checking") {
+		t.Fatalf("expected the TLS panel to open")
+	}
+	c.Update(cmd())
+	if c.tls.report == nil || c.tls.report.Address != "localhost:"+strconv.Itoa(port) {
+		t.Fatalf("expected a report for the resolved profile, got %+v", c.tls.report)
+	}
+	if !strings.Contains(c.tls.render(), "Lab CA") {
+		t.Fatalf("expected the chain in the panel:\n%s", c.tls.render())
+	}
+	c.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if c.tls != nil {
+		t.Fatalf("expected esc to close the panel")
+	}
+}
@@ -0,0 +1,118 @@
+package connections
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/marang/emqutiti/constants"
+	"github.com/marang/emqutiti/ui"
+)
+
+// defaultTLSTimeout bounds the TLS check of profiles without a connect
+// timeout.
+const defaultTLSTimeout = 10 * time.Second
+
+// tlsPanel shows the TLS diagnostics of a profile in the broker manager.
+type tlsPanel struct {
+	profile string
+	report  *TLSReport
+	vp      viewport.Model
+}
+
+// tlsReportMsg carries the result of a TLS check.
+type tlsReportMsg struct {
+	profile string
+	report  TLSReport
+}
+
+var (
+	tlsProblem = lipgloss.NewStyle().Foreground(ui.ColRed)
+	tlsWarning = lipgloss.NewStyle().Foreground(ui.ColWarn)
+)
+
+// checkSelectedTLS opens the TLS panel for the selected profile and starts
+// the check with its resolved settings.
+func (c *Component) checkSelectedTLS() tea.Cmd {
+	mgr := c.api.Manager()
+	i := mgr.Selected()
+	if i < 0 || i >= len(mgr.Profiles) {
+		return nil
+	}
+	p, err := ResolveProfile(mgr.Profiles[i], mgr.Profiles)
+	if err != nil {
+		c.note = err.Error()
+		return nil
+	}
+	if p.FromEnv {
+		ApplyEnvVars(&p)
+	}
+	timeout := defaultTLSTimeout
+	if p.ConnectTimeout > 0 {
+		timeout = time.Duration(p.ConnectTimeout) * time.Second
+	}
+	c.tls, c.note = &tlsPanel{profile: p.Name, vp: viewport.New(0, 0)}, ""
+	return func() tea.Msg {
+		return tlsReportMsg{profile: p.Name, report: CheckTLS(p, timeout)}
+	}
+}
+
+// updateTLS handles input while the TLS panel is open.
+func (c *Component) updateTLS(msg tea.Msg) tea.Cmd {
+	t := c.tls
+	switch msg := msg.(type) {
+	case tlsReportMsg:
+		if msg.profile == t.profile {
+			t.report = &msg.report
+			t.vp.SetContent(t.render())
+			t.vp.GotoTop()
+		}
+		return nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case constants.KeyEsc:
+			c.tls = nil
+			return nil
+		case constants.KeyR:
+			if t.report != nil {
+				return c.checkSelectedTLS()
+			}
+			return nil
+		}
+	}
+	var cmd tea.Cmd
+	t.vp, cmd = t.vp.Update(msg)
+	return cmd
+}
+
+func (t *tlsPanel) render() string {
+	lines := t.report.Lines()
+	section := ""
+	for i, l := range lines {
+		switch {
+		case l == "Problems" || l == "Warnings":
+			section = l
+		case l == "":
+			section = ""
+		case section == "Problems":
+			lines[i] = tlsProblem.Render(l)
+		case section == "Warnings":
+			lines[i] = tlsWarning.Render(l)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// View renders the panel in the space of the broker list.
+func (t *tlsPanel) View(width, height int) string {
+	title := "TLS diagnostics for " + t.profile
+	if t.report == nil {
+		return title + "\n\nChecking…"
+	}
+	t.vp.Width, t.vp.Height = width, max(height-2, 1)
+	help := ui.InfoStyle.Render("[r] check again  [esc] back")
+	return lipgloss.JoinVertical(lipgloss.Left, title, t.vp.View(), help)
+}
@@ -0,0 +1,161 @@
+package connections
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Auth modes of a profile. Profiles without a mode use their password.
+const (
+	AuthPassword = "password"
+	// AuthJWT signs a JWT with the profile's key as the password.
+	AuthJWT = "jwt"
+	// AuthCommand uses the output of the password command as a token.
+	AuthCommand = "command"
+)
+
+// defaultJWTExpiry is the lifetime of generated JWTs in seconds.
+const defaultJWTExpiry = 3600
+
+// TokenSource returns a function producing a fresh password for profiles
+// that authenticate with a JWT or a command token, and nil for profiles
+// with a static password.
+func TokenSource(p Profile) (func() (string, error), error) {
+	switch p.AuthMode {
+	case "", AuthPassword:
+		return nil, nil
+	case AuthJWT:
+		key, alg, err := loadJWTKey(p.JWTKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		claims, err := parseClaims(p.JWTClaims)
+		if err != nil {
+			return nil, err
+		}
+		if p.JWTAudience != "" {
+			claims["aud"] = p.JWTAudience
+		}
+		expiry := time.Duration(p.JWTExpiry) * time.Second
+		if p.JWTExpiry <= 0 {
+			expiry = defaultJWTExpiry * time.Second
+		}
+		return func() (string, error) {
+			return signJWT(key, alg, claims, time.Now(), expiry)
+		}, nil
+	case AuthCommand:
+		if p.PasswordCommand == "" {
+			return nil, fmt.Errorf("profile %s: auth mode command needs a password command", p.Name)
+		}
+		return func() (string, error) { return runPasswordCommand(p.PasswordCommand) }, nil
+	}
+	return nil, fmt.Errorf("profile %s: unknown auth mode %q", p.Name, p.AuthMode)
+}
+
+// parseClaims reads comma-separated key=value claims.
+func parseClaims(s string) (map[string]any, error) {
+	claims := map[string]any{}
+	for _, kv := range strings.Split(s, ",") {
+		if kv = strings.TrimSpace(kv); kv == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(kv, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid jwt claim %q, want key=value", kv)
+		}
+		if k == "iat" || k == "exp" {
+			return nil, fmt.Errorf("jwt claim %s is set from the expiry", k)
+		}
+		claims[k] = strings.TrimSpace(v)
+	}
+	return claims, nil
+}
+
+// loadJWTKey reads a PEM private key and returns it with its JWT algorithm.
+func loadJWTKey(path string) (crypto.Signer, string, error) {
+	if path == "" {
+		return nil, "", errors.New("auth mode jwt needs a jwt key path")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("read jwt key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, "", fmt.Errorf("jwt key %s: no PEM data", path)
+	}
+	var key any
+	if key, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+		if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			if key, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+				return nil, "", fmt.Errorf("jwt key %s: unsupported private key", path)
+			}
+		}
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, "RS256", nil
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, "", fmt.Errorf("jwt key %s: only P-256 EC keys are supported", path)
+		}
+		return k, "ES256", nil
+	case ed25519.PrivateKey:
+		return k, "EdDSA", nil
+	}
+	return nil, "", fmt.Errorf("jwt key %s: unsupported key type %T", path, key)
+}
+
+// signJWT returns a JWT with claims issued at now and expiring after expiry.
+func signJWT(key crypto.Signer, alg string, claims map[string]any, now time.Time, expiry time.Duration) (string, error) {
+	body := map[string]any{"iat": now.Unix(), "exp": now.Add(expiry).Unix()}
+	for k, v := range claims {
+		body[k] = v
+	}
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	input := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+	var sig []byte
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		digest := sha256.Sum256([]byte(input))
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			return "", err
+		}
+		// JWS uses the fixed size r || s encoding
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, []byte(input))
+	default:
+		digest := sha256.Sum256([]byte(input))
+		if sig, err = key.Sign(rand.Reader, digest[:], crypto.SHA256); err != nil {
+			return "", err
+		}
+	}
+	return input + "." + enc.EncodeToString(sig), nil
+}
@@ -0,0 +1,122 @@
+package connections
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeKey(t *testing.T, key any) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestJWTTokenSource(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	verify := map[string]func(input, sig []byte) bool{
+		"RS256": func(input, sig []byte) bool {
+			digest := sha256.Sum256(input)
+			return rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, digest[:], sig) == nil
+		},
+		"ES256": func(input, sig []byte) bool {
+			digest := sha256.Sum256(input)
+			r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+			return ecdsa.Verify(&ecKey.PublicKey, digest[:], r, s)
+		},
+		"EdDSA": func(input, sig []byte) bool {
+			return ed25519.Verify(edKey.Public().(ed25519.PublicKey), input, sig)
+		},
+	}
+	for alg, key := range map[string]any{"RS256": rsaKey, "ES256": ecKey, "EdDSA": edKey} {
+		p := Profile{Name: "iot", AuthMode: AuthJWT, JWTKeyPath: writeKey(t, key), JWTAudience: "my-project", JWTClaims: "sub=device-7, iss = fleet", JWTExpiry: 600}
+		token, err := TokenSource(p)
+		if err != nil {
+			t.Fatalf("%s: %v", alg, err)
+		}
+		jwt, err := token()
+		if err != nil {
+			t.Fatalf("%s: sign: %v", alg, err)
+		}
+		parts := strings.Split(jwt, ".")
+		if len(parts) != 3 {
+			t.Fatalf("%s: malformed token %q", alg, jwt)
+		}
+		var header map[string]string
+		var claims map[string]any
+		h, _ := base64.RawURLEncoding.DecodeString(parts[0])
+		c, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		json.Unmarshal(h, &header)
+		json.Unmarshal(c, &claims)
+		if header["alg"] != alg || !verify[alg]([]byte(parts[0]+"."+parts[1]), sig) {
+			t.Fatalf("%s: invalid signature or header %v", alg, header)
+		}
+		if claims["aud"] != "my-project" || claims["sub"] != "device-7" || claims["iss"] != "fleet" || claims["exp"].(float64)-claims["iat"].(float64) != 600 {
+			t.Fatalf("%s: unexpected claims %v", alg, claims)
+		}
+	}
+
+	for _, p := range []Profile{
+		{AuthMode: AuthJWT},
+		{AuthMode: AuthJWT, JWTKeyPath: writeKey(t, ecKey), JWTClaims: "exp=1"},
+		{AuthMode: AuthJWT, JWTKeyPath: writeKey(t, ecKey), JWTClaims: "sub"},
+		{AuthMode: AuthCommand},
+		{AuthMode: "oauth"},
+	} {
+		if _, err := TokenSource(p); err == nil {
+			t.Fatalf("expected error for %+v", p)
+		}
+	}
+	if token, err := TokenSource(Profile{Password: "pw"}); token != nil || err != nil {
+		t.Fatalf("expected no token source for password auth")
+	}
+}
+
+func TestCommandTokenSource(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "n")
+	cmd := "n=$(cat " + counter + " 2>/dev/null || echo 0); n=$((n+1)); echo $n > " + counter + "; echo tok$n"
+	token, err := TokenSource(Profile{Name: "lab", AuthMode: AuthCommand, PasswordCommand: cmd})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"tok1", "tok2"} {
+		if got, err := token(); err != nil || got != want {
+			t.Fatalf("expected %s, got %q %v", want, got, err)
+		}
+	}
+}
+
+func TestFormAuthMode(t *testing.T) {
+	f := NewForm(Profile{Name: "iot", AuthMode: AuthJWT, JWTKeyPath: "/k.pem", JWTExpiry: 60}, -1)
+	p, err := f.Profile()
+	if err != nil || p.AuthMode != AuthJWT || p.JWTKeyPath != "/k.pem" || p.JWTExpiry != 60 {
+		t.Fatalf("unexpected profile %+v %v", p, err)
+	}
+	f = NewForm(Profile{Name: "lab"}, -1)
+	if p, _ := f.Profile(); p.AuthMode != "" {
+		t.Fatalf("expected no auth mode for password profiles, got %q", p.AuthMode)
+	}
+}
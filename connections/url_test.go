@@ -0,0 +1,103 @@
+package connections
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseURL(t *testing.T) {
+	p, topics, err := ParseURL("mqtts://alice:pw@broker.example.com/?clientId=x&topics=a/#,b&version=5&insecure=true")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if p.Name != "alice@broker.example.com" || p.BrokerURL() != "mqtts://broker.example.com:8883" || !p.SSL || !p.SkipTLSVerify {
+		t.Fatalf("unexpected profile %+v", p)
+	}
+	if p.Password != "pw" || p.ClientID != "x" || p.RandomIDSuffix || p.MQTTVersion != "5" || !p.Ephemeral || !p.CleanStart {
+		t.Fatalf("unexpected settings %+v", p)
+	}
+	if len(topics) != 2 || topics[0].Title != "a/#" || topics[1].Title != "b" || !topics[0].Subscribed {
+		t.Fatalf("unexpected topics %+v", topics)
+	}
+	p, topics, err = ParseURL("mqtt://localhost:1884/sensors/#")
+	if err != nil || p.Port != 1884 || p.ClientID != "emqutiti" || !p.RandomIDSuffix || len(topics) != 1 || topics[0].Title != "sensors/#" {
+		t.Fatalf("unexpected path topic %+v %+v %v", p, topics, err)
+	}
+	for _, bad := range []string{"http://host", "mqtt://", "mqtt://host?qos=1", "ws://host/mqtt", "mqtt://host?version=6"} {
+		if _, _, err := ParseURL(bad); err == nil {
+			t.Fatalf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestEphemeralProfilesAreNotSaved(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	st := State{Manager: NewConnectionsModel(), Saved: map[string]ConnectionSnapshot{}}
+	st.Manager.AddConnection(Profile{Name: "lab", Host: "lab.local"})
+	p, topics, _ := ParseURL("mqtt://lab?topics=a")
+	p = st.AddEphemeral(p, topics)
+	if p.Name != "lab (2)" || !strings.Contains(st.Manager.ConnectionsList.Items()[1].FilterValue(), "(unsaved)") {
+		t.Fatalf("unexpected ephemeral profile %+v", p)
+	}
+	st.Manager.SetDefault(1)
+	if st.Manager.DefaultProfileName != "" {
+		t.Fatalf("ephemeral profile must not become the default")
+	}
+	st.Active = p.Name
+	st.SaveCurrent([]TopicSnapshot{{Title: "a", Subscribed: true}}, nil)
+	st.Manager.SetDefault(0)
+	cfg, _ := DefaultUserConfigFile()
+	data, err := os.ReadFile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "lab (2)") {
+		t.Fatalf("ephemeral profile persisted:\n%s", data)
+	}
+	if ts, _ := st.RestoreState(p.Name); len(ts) != 1 {
+		t.Fatalf("expected topics kept in memory, got %+v", ts)
+	}
+	st.Reload()
+	if len(st.Manager.Profiles) != 2 || !st.Manager.Profiles[1].Ephemeral {
+		t.Fatalf("reload dropped the ephemeral profile: %+v", st.Manager.Profiles)
+	}
+	if ts, _ := st.RestoreState(p.Name); len(ts) != 1 {
+		t.Fatalf("reload dropped the ephemeral topics, got %+v", ts)
+	}
+
+	saved := p
+	saved.Ephemeral = false
+	st.Manager.EditConnection(1, saved)
+	data, _ = os.ReadFile(cfg)
+	if !strings.Contains(string(data), "lab (2)") {
+		t.Fatalf("edited profile not saved:\n%s", data)
+	}
+}
+
+func TestQuickConnect(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mgr := NewConnectionsModel()
+	api := &testAPI{mgr: &mgr}
+	c := NewComponent(&testNav{}, api)
+	c.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	if c.quick == nil {
+		t.Fatalf("expected quick connect box")
+	}
+	c.quick.input.SetValue("ftp://host")
+	c.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if c.quick == nil || !strings.Contains(c.quick.err, "unsupported scheme") {
+		t.Fatalf("expected url error")
+	}
+	c.quick.input.SetValue("mqtt://broker.local")
+	c.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if c.quick != nil || api.connected != "broker.local" || mgr.ConnectionsList.Index() != 0 {
+		t.Fatalf("expected connect to the ephemeral profile, got %q", api.connected)
+	}
+	if _, err := os.Stat(filepath.Join(os.Getenv("HOME"), ".config", "emqutiti", "config.toml")); err == nil {
+		t.Fatalf("quick connect must not save a profile")
+	}
+}
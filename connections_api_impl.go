@@ -2,6 +2,7 @@ package emqutiti
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/list"
@@ -24,6 +25,10 @@ func (m *model) SendStatus(msg string) { m.connections.SendStatus(msg) }
 func (m *model) FlushStatus()          { m.connections.FlushStatus() }
 
 func (m *model) RefreshConnectionItems() { m.connections.RefreshConnectionItems() }
+
+// ReloadProfiles rereads profiles and saved topics after they changed on
+// disk, e.g. through a profile import.
+func (m *model) ReloadProfiles() { m.connections.Reload() }
 func (m *model) SubscribeActiveTopics() {
 	if m.mqttClient == nil {
 		return
@@ -39,6 +44,9 @@ func (m *model) SubscribeActiveTopics() {
 func (m *model) ConnectionMessage() string       { return m.connections.Connection }
 func (m *model) SetConnectionMessage(msg string) { m.connections.Connection = msg }
 func (m *model) Active() string                  { return m.connections.Active }
+func (m *model) AddEphemeral(p connections.Profile, topics []connections.TopicSnapshot) connections.Profile {
+	return m.connections.AddEphemeral(p, topics)
+}
 
 func (m *model) validProfileIndex(idx int) bool {
 	return idx >= 0 && idx < len(m.connections.Manager.Profiles)
@@ -47,17 +55,43 @@ func (m *model) BeginAdd() {
 	f := connections.NewForm(connections.Profile{}, -1)
 	m.connections.Form = &f
 }
+func (m *model) BeginAddChild(parent string) {
+	f := connections.NewForm(connections.Profile{Parent: parent}, -1)
+	m.showInherited(&f, parent)
+	m.connections.Form = &f
+}
 func (m *model) BeginEdit(index int) {
 	if m.validProfileIndex(index) {
-		f := connections.NewForm(m.connections.Manager.Profiles[index], index)
+		p := m.connections.Manager.Profiles[index]
+		f := connections.NewForm(p, index)
+		m.showInherited(&f, p.Parent)
 		m.connections.Form = &f
 	}
 }
+
+// showInherited shows the values a child form inherits from parent.
+func (m *model) showInherited(f *connections.Form, parent string) {
+	if parent == "" {
+		return
+	}
+	for _, p := range m.connections.Manager.Profiles {
+		if p.Name == parent {
+			if r, err := connections.ResolveProfile(p, m.connections.Manager.Profiles); err == nil {
+				f.ShowInherited(r)
+			}
+			return
+		}
+	}
+}
 func (m *model) BeginDelete(index int) {
 	if !m.validProfileIndex(index) {
 		return
 	}
 	name := m.connections.Manager.Profiles[index].Name
+	if children := connections.Children(m.connections.Manager.Profiles, name); len(children) > 0 {
+		m.connections.SendStatus(fmt.Sprintf("Cannot delete '%s', it is the parent of %s", name, strings.Join(children, ", ")))
+		return
+	}
 	info := "This also deletes history and traces"
 	rf := func() tea.Cmd { return m.SetFocus(m.ui.focusOrder[m.ui.focusIndex]) }
 	m.StartConfirm(
@@ -74,6 +108,13 @@ func (m *model) BeginDelete(index int) {
 }
 func (m *model) Connect(p connections.Profile) tea.Cmd {
 	m.connections.FlushStatus()
+	resolved, err := connections.ResolveProfile(p, m.connections.Manager.Profiles)
+	if err != nil {
+		m.connections.SetDisconnected(p.Name, err.Error())
+		m.RefreshConnectionItems()
+		return nil
+	}
+	p = resolved
 	if p.FromEnv {
 		connections.ApplyEnvVars(&p)
 	}
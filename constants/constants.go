@@ -19,6 +19,10 @@ const (
 	ModeHistoryDetail
 	ModeHelp
 	ModeLogs
+	ModePublishFile
+	ModeHistoryColumns
+	ModeChart
+	ModeStats
 )
 
 // ID constants for shared elements.
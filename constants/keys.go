@@ -21,11 +21,19 @@ const (
 	KeyD             = "d"
 	KeyE             = "e"
 	KeyQ             = "q"
+	KeyS             = "s"
+	KeyG             = "g"
 	KeyA             = "a"
+	KeyC             = "c"
 	KeyV             = "v"
 	KeyY             = "y"
 	KeyN             = "n"
 	KeyX             = "x"
+	KeyW             = "w"
+	KeyR             = "r"
+	KeyT             = "t"
+	KeyF             = "f"
+	KeyI             = "i"
 	KeySlash         = "/"
 	KeySpace         = "space"
 	KeySpaceBar      = " "
@@ -39,6 +47,8 @@ const (
 	KeyCtrlC         = "ctrl+c"
 	KeyCtrlX         = "ctrl+x"
 	KeyCtrlF         = "ctrl+f"
+	KeyCtrlG         = "ctrl+g"
+	KeyCtrlU         = "ctrl+u"
 	KeyShiftUp       = "shift+up"
 	KeyShiftDown     = "shift+down"
 	KeyCtrlShiftUp   = "ctrl+shift+up"
@@ -0,0 +1,25 @@
+package filepublish
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// ID identifies the file publish form.
+const ID = "file-publish"
+
+// Publisher sends MQTT messages.
+type Publisher interface {
+	Publish(topic string, qos byte, retained bool, payload interface{}) error
+}
+
+// API defines the dependencies Component requires from the host model.
+type API interface {
+	SetModeClient() tea.Cmd
+	FocusedID() string
+	ResetElemPos()
+	SetElemPos(id string, pos int)
+	OverlayHelp(view string) string
+	Width() int
+	Height() int
+	// FilePublisher returns the active client or nil when disconnected.
+	FilePublisher() Publisher
+	LogHistory(topic, payload, kind string, retained bool, text string)
+}
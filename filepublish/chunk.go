@@ -0,0 +1,87 @@
+package filepublish
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Options configures a file publish.
+type Options struct {
+	Path       string // file to publish
+	Topic      string // base topic
+	ChunkSize  int    // bytes per message, 0 publishes the whole file
+	ChunkTopic string // optional per-chunk topic template
+	QoS        byte
+	Retain     bool
+}
+
+// Chunk is a single message produced from a file.
+type Chunk struct {
+	Topic string
+	Data  []byte
+}
+
+// BuildTopic expands a chunk topic template. Supported placeholders are
+// {topic}, {index} (zero-based), {seq} (one-based), {total} and {name}.
+func BuildTopic(tmpl, topic, name string, index, total int) string {
+	if tmpl == "" {
+		return topic
+	}
+	r := strings.NewReplacer(
+		"{topic}", topic,
+		"{index}", strconv.Itoa(index),
+		"{seq}", strconv.Itoa(index+1),
+		"{total}", strconv.Itoa(total),
+		"{name}", name,
+	)
+	return r.Replace(tmpl)
+}
+
+// Split divides data into chunks according to opts.
+func Split(data []byte, opts Options) ([]Chunk, error) {
+	if strings.TrimSpace(opts.Topic) == "" && opts.ChunkTopic == "" {
+		return nil, fmt.Errorf("topic required")
+	}
+	if opts.ChunkSize < 0 {
+		return nil, fmt.Errorf("invalid chunk size %d", opts.ChunkSize)
+	}
+	name := filepath.Base(opts.Path)
+	size := opts.ChunkSize
+	if size == 0 || size > len(data) {
+		size = len(data)
+	}
+	total := 1
+	if size > 0 {
+		total = (len(data) + size - 1) / size
+	}
+	chunks := make([]Chunk, 0, total)
+	for i := 0; i < total; i++ {
+		start := i * size
+		end := start + size
+		if end > len(data) {
+			end = len(data)
+		}
+		chunks = append(chunks, Chunk{
+			Topic: BuildTopic(opts.ChunkTopic, opts.Topic, name, i, total),
+			Data:  data[start:end],
+		})
+	}
+	return chunks, nil
+}
+
+// Describe returns data as text when printable or a short binary summary.
+func Describe(data []byte) string {
+	if !utf8.Valid(data) {
+		return fmt.Sprintf("<binary %d bytes>", len(data))
+	}
+	for _, r := range string(data) {
+		if r != '\n' && r != '\r' && r != '\t' && !unicode.IsPrint(r) {
+			return fmt.Sprintf("<binary %d bytes>", len(data))
+		}
+	}
+	return string(data)
+}
@@ -0,0 +1,57 @@
+package filepublish
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSplitWholeFile(t *testing.T) {
+	chunks, err := Split([]byte("hello"), Options{Topic: "a/b"})
+	if err != nil {
+		t.Fatalf("Split error: %v", err)
+	}
+	if len(chunks) != 1 || chunks[0].Topic != "a/b" || string(chunks[0].Data) != "hello" {
+		t.Fatalf("unexpected chunks %+v", chunks)
+	}
+}
+
+func TestSplitChunksWithTemplate(t *testing.T) {
+	data := []byte{0, 1, 2, 3, 4, 5, 6}
+	opts := Options{Path: "/tmp/fw.bin", Topic: "dev", ChunkSize: 3, ChunkTopic: "{topic}/{name}/{seq}-{total}"}
+	chunks, err := Split(data, opts)
+	if err != nil {
+		t.Fatalf("Split error: %v", err)
+	}
+	want := []string{"dev/fw.bin/1-3", "dev/fw.bin/2-3", "dev/fw.bin/3-3"}
+	if len(chunks) != len(want) {
+		t.Fatalf("expected %d chunks, got %d", len(want), len(chunks))
+	}
+	var joined []byte
+	for i, ch := range chunks {
+		if ch.Topic != want[i] {
+			t.Fatalf("chunk %d topic %q, want %q", i, ch.Topic, want[i])
+		}
+		joined = append(joined, ch.Data...)
+	}
+	if !bytes.Equal(joined, data) {
+		t.Fatalf("chunks do not reassemble to input")
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	if _, err := Split([]byte("x"), Options{}); err == nil {
+		t.Fatalf("expected error for missing topic")
+	}
+	if _, err := Split([]byte("x"), Options{Topic: "t", ChunkSize: -1}); err == nil {
+		t.Fatalf("expected error for negative chunk size")
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	if got := Describe([]byte("line\nnext")); got != "line\nnext" {
+		t.Fatalf("unexpected text %q", got)
+	}
+	if got := Describe([]byte{0xff, 0x00, 0x01}); got != "<binary 3 bytes>" {
+		t.Fatalf("unexpected binary summary %q", got)
+	}
+}
@@ -0,0 +1,252 @@
+package filepublish
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/bubbles/filepicker"
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/marang/emqutiti/constants"
+	"github.com/marang/emqutiti/focus"
+	"github.com/marang/emqutiti/ui"
+)
+
+type stage int
+
+const (
+	stageForm stage = iota
+	stagePicker
+	stagePublishing
+	stageDone
+)
+
+// chunkMsg reports the result of publishing a single chunk.
+type chunkMsg struct{ err error }
+
+// Component lets the user pick a file and publish it, optionally chunked.
+type Component struct {
+	api      API
+	stage    stage
+	form     publishForm
+	picker   filepicker.Model
+	progress progress.Model
+	opts     Options
+	chunks   []Chunk
+	index    int
+	size     int
+	err      error
+}
+
+// New creates a file publish component.
+func New(api API) *Component {
+	return &Component{
+		api:      api,
+		form:     newPublishForm("", ""),
+		progress: progress.New(progress.WithDefaultGradient()),
+	}
+}
+
+// Open resets the component for publishing to topic.
+func (c *Component) Open(topic string) tea.Cmd {
+	path := c.form.Path()
+	c.form = newPublishForm(path, topic)
+	c.stage = stageForm
+	c.chunks = nil
+	c.index = 0
+	c.err = nil
+	return textinput.Blink
+}
+
+func (c *Component) Init() tea.Cmd { return nil }
+
+func (c *Component) Update(msg tea.Msg) tea.Cmd {
+	switch m := msg.(type) {
+	case chunkMsg:
+		return c.handleChunk(m)
+	case progress.FrameMsg:
+		pm, cmd := c.progress.Update(m)
+		c.progress = pm.(progress.Model)
+		return cmd
+	case tea.KeyMsg:
+		if m.String() == constants.KeyCtrlD {
+			return tea.Quit
+		}
+	}
+	switch c.stage {
+	case stagePicker:
+		return c.updatePicker(msg)
+	case stagePublishing:
+		return nil
+	case stageDone:
+		if km, ok := msg.(tea.KeyMsg); ok {
+			switch km.String() {
+			case constants.KeyEsc, constants.KeyEnter:
+				return c.api.SetModeClient()
+			}
+		}
+		return nil
+	}
+	return c.updateForm(msg)
+}
+
+func (c *Component) updateForm(msg tea.Msg) tea.Cmd {
+	if km, ok := msg.(tea.KeyMsg); ok {
+		switch km.String() {
+		case constants.KeyEsc:
+			return c.api.SetModeClient()
+		case constants.KeyCtrlO:
+			return c.openPicker()
+		case constants.KeyEnter:
+			return c.start()
+		}
+	}
+	var cmd tea.Cmd
+	c.form, cmd = c.form.Update(msg)
+	return cmd
+}
+
+func (c *Component) openPicker() tea.Cmd {
+	fp := filepicker.New()
+	fp.AutoHeight = false
+	fp.SetHeight(max(c.api.Height()-8, 5))
+	dir := filepath.Dir(c.form.Path())
+	if c.form.Path() == "" {
+		dir, _ = os.Getwd()
+	}
+	if dir != "" {
+		fp.CurrentDirectory = dir
+	}
+	c.picker = fp
+	c.stage = stagePicker
+	return c.picker.Init()
+}
+
+func (c *Component) updatePicker(msg tea.Msg) tea.Cmd {
+	if km, ok := msg.(tea.KeyMsg); ok && km.String() == constants.KeyEsc {
+		c.stage = stageForm
+		return nil
+	}
+	var cmd tea.Cmd
+	c.picker, cmd = c.picker.Update(msg)
+	if ok, path := c.picker.DidSelectFile(msg); ok {
+		c.form.SetPath(path)
+		c.stage = stageForm
+	}
+	return cmd
+}
+
+// start loads the file and begins publishing chunks.
+func (c *Component) start() tea.Cmd {
+	c.form.errMsg = ""
+	opts, err := c.form.Options()
+	if err != nil {
+		c.form.errMsg = err.Error()
+		return nil
+	}
+	if c.api.FilePublisher() == nil {
+		c.form.errMsg = "not connected"
+		return nil
+	}
+	data, err := os.ReadFile(opts.Path)
+	if err != nil {
+		c.form.errMsg = err.Error()
+		return nil
+	}
+	chunks, err := Split(data, opts)
+	if err != nil {
+		c.form.errMsg = err.Error()
+		return nil
+	}
+	c.opts = opts
+	c.chunks = chunks
+	c.size = len(data)
+	c.index = 0
+	c.err = nil
+	c.stage = stagePublishing
+	return tea.Batch(c.progress.SetPercent(0), c.nextChunkCmd())
+}
+
+func (c *Component) nextChunkCmd() tea.Cmd {
+	pub := c.api.FilePublisher()
+	if pub == nil || c.index >= len(c.chunks) {
+		return nil
+	}
+	ch := c.chunks[c.index]
+	qos, retain := c.opts.QoS, c.opts.Retain
+	return func() tea.Msg {
+		return chunkMsg{err: pub.Publish(ch.Topic, qos, retain, ch.Data)}
+	}
+}
+
+func (c *Component) handleChunk(m chunkMsg) tea.Cmd {
+	if c.stage != stagePublishing || c.index >= len(c.chunks) {
+		return nil
+	}
+	ch := c.chunks[c.index]
+	if m.err != nil {
+		c.err = m.err
+		c.stage = stageDone
+		c.api.LogHistory("", "", "log", false, fmt.Sprintf("File publish to %s failed: %v", ch.Topic, m.err))
+		return nil
+	}
+	payload := Describe(ch.Data)
+	text := fmt.Sprintf("Published to %s: %s", ch.Topic, payload)
+	if c.opts.Retain {
+		text = fmt.Sprintf("Published retained to %s: %s", ch.Topic, payload)
+	}
+	c.api.LogHistory(ch.Topic, payload, "pub", c.opts.Retain, text)
+	c.index++
+	cmd := c.progress.SetPercent(float64(c.index) / float64(len(c.chunks)))
+	if c.index >= len(c.chunks) {
+		c.stage = stageDone
+		return cmd
+	}
+	return tea.Batch(cmd, c.nextChunkCmd())
+}
+
+func (c *Component) View() string {
+	c.api.ResetElemPos()
+	c.api.SetElemPos(ID, 1)
+	width := c.api.Width() - 2
+	var content, help string
+	switch c.stage {
+	case stagePicker:
+		content = "Current directory: " + c.picker.CurrentDirectory + "\n\n" + c.picker.View()
+		help = "[enter] select  [esc] back"
+	case stagePublishing, stageDone:
+		c.progress.Width = max(width-4, 10)
+		status := fmt.Sprintf("Publishing %d/%d", c.index, len(c.chunks))
+		if c.stage == stageDone {
+			status = fmt.Sprintf("Published %d bytes in %d/%d message(s)", c.size, c.index, len(c.chunks))
+		}
+		content = status + "\n" + c.progress.View()
+		if c.err != nil {
+			content += "\n" + ui.ErrorStyle.Render(c.err.Error())
+		}
+		if c.stage == stageDone {
+			help = "[enter] back to client"
+		}
+	default:
+		content = c.form.View()
+		help = "[enter] publish  [ctrl+o] browse  [tab] next field  [esc] cancel"
+	}
+	if help != "" {
+		content += "\n" + ui.InfoStyle.Render(help)
+	}
+	focused := c.api.FocusedID() == ID
+	view := ui.LegendBox(content, "Publish File", width, 0, ui.ColBlue, focused, -1)
+	return c.api.OverlayHelp(view)
+}
+
+func (c *Component) Focus() tea.Cmd { return nil }
+
+func (c *Component) Blur() {}
+
+// Focusables exposes focusable elements for the file publish component.
+func (c *Component) Focusables() map[string]focus.Focusable {
+	return map[string]focus.Focusable{ID: &focus.NullFocusable{}}
+}
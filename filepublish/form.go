@@ -0,0 +1,106 @@
+package filepublish
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/marang/emqutiti/ui"
+)
+
+type publishForm struct {
+	ui.Form
+	errMsg string
+}
+
+const (
+	idxPath = iota
+	idxTopic
+	idxChunkSize
+	idxChunkTopic
+	idxQoS
+	idxRetain
+)
+
+var formLabels = []string{"File", "Topic", "Chunk size", "Chunk topic", "QoS", "Retain"}
+
+// newPublishForm builds the form used to configure a file publish.
+func newPublishForm(path, topic string) publishForm {
+	qos, _ := ui.NewSelectField("0", []string{"0", "1", "2"})
+	fields := []ui.Field{
+		ui.NewTextField(path, "path/to/file"),
+		ui.NewTextField(topic, "Topic"),
+		ui.NewTextField("", "0 (whole file)"),
+		ui.NewTextField("", "{topic}/{seq}"),
+		qos,
+		ui.NewCheckField(false),
+	}
+	f := publishForm{Form: ui.Form{Fields: fields, Focus: 0}}
+	f.ApplyFocus()
+	return f
+}
+
+// Update handles input for the focused field.
+func (f publishForm) Update(msg tea.Msg) (publishForm, tea.Cmd) {
+	if km, ok := msg.(tea.KeyMsg); ok {
+		f.CycleFocus(km)
+	}
+	f.ApplyFocus()
+	return f, f.Fields[f.Focus].Update(msg)
+}
+
+// SetPath replaces the file path value.
+func (f *publishForm) SetPath(p string) {
+	if tf, ok := f.Fields[idxPath].(*ui.TextField); ok {
+		tf.SetValue(p)
+	}
+}
+
+// Path returns the entered file path.
+func (f publishForm) Path() string { return strings.TrimSpace(f.Fields[idxPath].Value()) }
+
+// Options returns the publish options from the form values.
+func (f publishForm) Options() (Options, error) {
+	opts := Options{
+		Path:       f.Path(),
+		Topic:      strings.TrimSpace(f.Fields[idxTopic].Value()),
+		ChunkTopic: strings.TrimSpace(f.Fields[idxChunkTopic].Value()),
+		Retain:     f.Fields[idxRetain].Value() == "true",
+	}
+	if opts.Path == "" {
+		return opts, fmt.Errorf("file required")
+	}
+	if s := strings.TrimSpace(f.Fields[idxChunkSize].Value()); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("invalid chunk size %q", s)
+		}
+		opts.ChunkSize = n
+	}
+	q, _ := strconv.Atoi(f.Fields[idxQoS].Value())
+	opts.QoS = byte(q)
+	return opts, nil
+}
+
+// View renders the form fields.
+func (f publishForm) View() string {
+	var b strings.Builder
+	for i, fld := range f.Fields {
+		label := formLabels[i]
+		if i == f.Focus {
+			label = ui.FocusedStyle.Render(label)
+		}
+		b.WriteString(label + ": " + fld.View() + "\n")
+		if sf, ok := fld.(*ui.SelectField); ok && f.IsFocused(i) {
+			if opts := sf.OptionsView(); opts != "" {
+				b.WriteString(opts + "\n")
+			}
+		}
+	}
+	if f.errMsg != "" {
+		b.WriteString("\n" + ui.ErrorStyle.Render(f.errMsg) + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package filepublish
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/charmbracelet/bubbles/progress"
+)
+
+// Run publishes the file described by opts and writes progress to w.
+func Run(ctx context.Context, pub Publisher, opts Options, w io.Writer) error {
+	data, err := os.ReadFile(opts.Path)
+	if err != nil {
+		return fmt.Errorf("read file: %w", err)
+	}
+	chunks, err := Split(data, opts)
+	if err != nil {
+		return err
+	}
+	bar := progress.New(progress.WithDefaultGradient(), progress.WithWidth(40))
+	for i, ch := range chunks {
+		if err := ctx.Err(); err != nil {
+			fmt.Fprintln(w)
+			return err
+		}
+		if err := pub.Publish(ch.Topic, opts.QoS, opts.Retain, ch.Data); err != nil {
+			fmt.Fprintln(w)
+			return fmt.Errorf("publish chunk %d to %s: %w", i+1, ch.Topic, err)
+		}
+		p := float64(i+1) / float64(len(chunks))
+		fmt.Fprintf(w, "\r%s %d/%d", bar.ViewAs(p), i+1, len(chunks))
+	}
+	fmt.Fprintf(w, "\nPublished %d bytes in %d message(s)\n", len(data), len(chunks))
+	return nil
+}
@@ -0,0 +1,64 @@
+package filepublish
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type stubPublisher struct {
+	topics []string
+	data   [][]byte
+	qos    byte
+	retain bool
+	err    error
+}
+
+func (s *stubPublisher) Publish(topic string, qos byte, retained bool, payload interface{}) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.topics = append(s.topics, topic)
+	s.data = append(s.data, payload.([]byte))
+	s.qos = qos
+	s.retain = retained
+	return nil
+}
+
+func TestRunPublishesChunks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fw.bin")
+	if err := os.WriteFile(path, []byte{1, 2, 3, 4, 5}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pub := &stubPublisher{}
+	var out bytes.Buffer
+	opts := Options{Path: path, Topic: "dev", ChunkSize: 2, ChunkTopic: "{topic}/{index}", QoS: 1, Retain: true}
+	if err := Run(context.Background(), pub, opts, &out); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	if strings.Join(pub.topics, ",") != "dev/0,dev/1,dev/2" {
+		t.Fatalf("unexpected topics %v", pub.topics)
+	}
+	if pub.qos != 1 || !pub.retain {
+		t.Fatalf("qos/retain not forwarded")
+	}
+	if !strings.Contains(out.String(), "Published 5 bytes in 3 message(s)") {
+		t.Fatalf("missing summary in %q", out.String())
+	}
+}
+
+func TestRunPublishError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(path, []byte("hi"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pub := &stubPublisher{err: errors.New("boom")}
+	err := Run(context.Background(), pub, Options{Path: path, Topic: "t"}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected publish error, got %v", err)
+	}
+}
@@ -0,0 +1,13 @@
+package emqutiti
+
+import "github.com/marang/emqutiti/filepublish"
+
+// FilePublisher returns the active MQTT client for file publishing.
+func (m *model) FilePublisher() filepublish.Publisher {
+	if m.mqttClient == nil {
+		return nil
+	}
+	return m.mqttClient
+}
+
+var _ filepublish.API = (*model)(nil)
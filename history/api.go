@@ -45,7 +45,8 @@ const ID = "history"
 // NewComponent constructs a history Component bound to the provided Model.
 // The supplied Store may be nil when persistence is not required.
 func NewComponent(m Model, st Store) *Component {
-	del := historyDelegate{}
+	cols := newColumnSet(LoadColumns())
+	del := historyDelegate{cols: cols}
 	lst := list.New([]list.Item{}, del, 0, 0)
 	lst.SetShowTitle(false)
 	lst.SetShowStatusBar(false)
@@ -57,6 +58,7 @@ func NewComponent(m Model, st Store) *Component {
 		store:           st,
 		selectionAnchor: -1,
 		detail:          viewport.New(0, 0),
+		columns:         cols,
 	}
 	if st != nil {
 		msgs := st.Search(false, nil, time.Time{}, time.Time{}, "")
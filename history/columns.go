@@ -0,0 +1,410 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/marang/emqutiti/connections"
+	"github.com/marang/emqutiti/internal/jsonpath"
+	"github.com/marang/emqutiti/ui"
+)
+
+// maxColumnWidth caps the rendered width of a single column value.
+const maxColumnWidth = 24
+
+// maxCachedValues bounds the extracted values kept between renders.
+const maxCachedValues = 10000
+
+// Column extracts a value from JSON payloads using a JSONPath expression.
+type Column struct {
+	Name string `toml:"name"`
+	Path string `toml:"path"`
+}
+
+// Columns maps MQTT topic filters to the columns shown for matching topics.
+type Columns map[string][]Column
+
+// LoadColumns reads the [columns] table from config.toml.
+func LoadColumns() Columns {
+	out := Columns{}
+	fp, err := connections.DefaultUserConfigFile()
+	if err != nil {
+		return out
+	}
+	var cfg struct {
+		Columns Columns `toml:"columns"`
+	}
+	if _, err := toml.DecodeFile(fp, &cfg); err != nil {
+		return out
+	}
+	for k, v := range cfg.Columns {
+		out[k] = v
+	}
+	return out
+}
+
+// SaveColumns replaces the [columns] table in config.toml.
+func SaveColumns(cols Columns) error {
+	fp, err := connections.DefaultUserConfigFile()
+	if err != nil {
+		return err
+	}
+	cfg := map[string]interface{}{}
+	toml.DecodeFile(fp, &cfg) // ignore errors for new files
+	tbl := map[string]interface{}{}
+	for filter, list := range cols {
+		var entries []map[string]interface{}
+		for _, c := range list {
+			entries = append(entries, map[string]interface{}{"name": c.Name, "path": c.Path})
+		}
+		tbl[filter] = entries
+	}
+	cfg["columns"] = tbl
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(cfg); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fp), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(fp, buf.Bytes(), 0644)
+}
+
+// ParseColumns reads column definitions from lines of the form
+// "filter | name | path". Blank lines and lines starting with # are ignored.
+func ParseColumns(s string) (Columns, error) {
+	cols := Columns{}
+	for i, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Split(line, "|")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("line %d: expected filter | name | path", i+1)
+		}
+		filter := strings.TrimSpace(parts[0])
+		c := Column{Name: strings.TrimSpace(parts[1]), Path: strings.TrimSpace(parts[2])}
+		if filter == "" || c.Name == "" || c.Path == "" {
+			return nil, fmt.Errorf("line %d: filter, name and path required", i+1)
+		}
+		if _, err := jsonpath.Compile(c.Path); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		cols[filter] = append(cols[filter], c)
+	}
+	return cols, nil
+}
+
+// Format renders cols in the line format accepted by ParseColumns.
+func (c Columns) Format() string {
+	var b strings.Builder
+	for _, f := range c.filters() {
+		for _, col := range c[f] {
+			fmt.Fprintf(&b, "%s | %s | %s\n", f, col.Name, col.Path)
+		}
+	}
+	return b.String()
+}
+
+func (c Columns) filters() []string {
+	fs := make([]string, 0, len(c))
+	for f := range c {
+		fs = append(fs, f)
+	}
+	sort.Strings(fs)
+	return fs
+}
+
+// MatchFilter reports whether topic matches the MQTT subscription filter.
+func MatchFilter(filter, topic string) bool {
+	fp := strings.Split(filter, "/")
+	tp := strings.Split(topic, "/")
+	for i := 0; i < len(fp); i++ {
+		if fp[i] == "#" {
+			return true
+		}
+		if i >= len(tp) {
+			return false
+		}
+		if fp[i] != "+" && fp[i] != tp[i] {
+			return false
+		}
+	}
+	return len(fp) == len(tp)
+}
+
+// columnSet holds compiled columns, cached values and the sort order.
+type columnSet struct {
+	cols    Columns
+	names   []string
+	paths   map[string]map[string]jsonpath.Path // filter -> name -> path
+	cache   map[valueKey][]string
+	skip    map[string]bool // kinds without values
+	sortCol int
+	desc    bool
+	sorted  bool // items were reordered by a column
+}
+
+// valueKey identifies an item in the value cache.
+type valueKey struct {
+	ts                   int64
+	kind, topic, payload string
+}
+
+func newColumnSet(cols Columns) *columnSet {
+	cs := &columnSet{cols: cols, paths: map[string]map[string]jsonpath.Path{}, cache: map[valueKey][]string{}, skip: map[string]bool{"log": true}, sortCol: -1}
+	seen := map[string]bool{}
+	for _, f := range cols.filters() {
+		cs.paths[f] = map[string]jsonpath.Path{}
+		for _, c := range cols[f] {
+			p, err := jsonpath.Compile(c.Path)
+			if err != nil {
+				continue
+			}
+			cs.paths[f][c.Name] = p
+			if !seen[c.Name] {
+				seen[c.Name] = true
+				cs.names = append(cs.names, c.Name)
+			}
+		}
+	}
+	return cs
+}
+
+// values returns the extracted value for each column name of it.
+func (cs *columnSet) values(it Item) []string {
+	if cs == nil || len(cs.names) == 0 || cs.skip[it.Kind] {
+		return nil
+	}
+	key := valueKey{it.Timestamp.UnixNano(), it.Kind, it.Topic, it.Payload}
+	if v, ok := cs.cache[key]; ok {
+		return v
+	}
+	if len(cs.cache) >= maxCachedValues {
+		clear(cs.cache)
+	}
+	out := make([]string, len(cs.names))
+	if doc, err := decodeJSON(it.Payload); err == nil {
+		for _, f := range cs.cols.filters() {
+			if !MatchFilter(f, it.Topic) {
+				continue
+			}
+			for i, name := range cs.names {
+				p, ok := cs.paths[f][name]
+				if !ok || out[i] != "" {
+					continue
+				}
+				if vals := p.Eval(doc); len(vals) > 0 {
+					out[i] = formatValue(vals[0])
+				}
+			}
+		}
+	}
+	cs.cache[key] = out
+	return out
+}
+
+// widths computes the value widths needed to align items.
+func (cs *columnSet) widths(items []Item) []int {
+	if cs == nil {
+		return nil
+	}
+	w := make([]int, len(cs.names))
+	for _, it := range items {
+		for i, v := range cs.values(it) {
+			if l := len([]rune(v)); l > w[i] {
+				w[i] = min(l, maxColumnWidth)
+			}
+		}
+	}
+	return w
+}
+
+// cycleSort advances through ascending and descending order for each column
+// and finally back to chronological order.
+func (cs *columnSet) cycleSort() {
+	switch {
+	case cs.sortCol < 0:
+		cs.sortCol, cs.desc = 0, false
+	case !cs.desc:
+		cs.desc = true
+	default:
+		cs.sortCol++
+		cs.desc = false
+	}
+	if cs.sortCol >= len(cs.names) {
+		cs.sortCol = -1
+	}
+}
+
+// sort orders items by the active sort column or by timestamp.
+func (cs *columnSet) sort(items []Item) {
+	if cs == nil || cs.sortCol < 0 {
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Timestamp.Before(items[j].Timestamp) })
+		return
+	}
+	col := cs.sortCol
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := cs.valueAt(items[i], col), cs.valueAt(items[j], col)
+		if a == "" || b == "" {
+			return a != "" && b == "" // empty values last
+		}
+		if cs.desc {
+			return compareValues(a, b) > 0
+		}
+		return compareValues(a, b) < 0
+	})
+}
+
+func (cs *columnSet) valueAt(it Item, col int) string {
+	if v := cs.values(it); col < len(v) {
+		return v[col]
+	}
+	return ""
+}
+
+// compareValues compares numerically when both values are numbers.
+func compareValues(a, b string) int {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// formatValue renders a decoded JSON value for display.
+func formatValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// SortLabel describes the active sort order, or "" for chronological.
+func (h *Component) SortLabel() string {
+	cs := h.columns
+	if cs == nil || cs.sortCol < 0 || cs.sortCol >= len(cs.names) {
+		return ""
+	}
+	arrow := "↑"
+	if cs.desc {
+		arrow = "↓"
+	}
+	return cs.names[cs.sortCol] + " " + arrow
+}
+
+// Columns returns the configured extraction columns.
+func (h *Component) Columns() Columns {
+	if h.columns == nil {
+		return Columns{}
+	}
+	return h.columns.cols
+}
+
+// SetColumns replaces the extraction columns and resets the sort order.
+func (h *Component) SetColumns(cols Columns) {
+	cs := newColumnSet(cols)
+	if h.columns != nil {
+		cs.skip = h.columns.skip
+	}
+	h.columns = cs
+	h.list.SetDelegate(historyDelegate{cols: h.columns})
+	h.ApplySort()
+}
+
+// SkipColumnKinds leaves items of kinds, such as annotations, without
+// column values.
+func (h *Component) SkipColumnKinds(kinds ...string) {
+	for _, k := range kinds {
+		h.columns.skip[k] = true
+	}
+}
+
+// ColumnNames returns the names of all configured columns in display order.
+func (h *Component) ColumnNames() []string {
+	if h.columns == nil {
+		return nil
+	}
+	return h.columns.names
+}
+
+// ColumnValues returns the extracted column values for it.
+func (h *Component) ColumnValues(it Item) []string { return h.columns.values(it) }
+
+// CycleSort switches to the next sort column and reorders the list.
+func (h *Component) CycleSort() {
+	if h.columns == nil || len(h.columns.names) == 0 {
+		return
+	}
+	h.columns.cycleSort()
+	h.ApplySort()
+}
+
+// ApplySort reorders the items and list according to the sort state. When
+// sorting is switched off, previously sorted items return to chronological
+// order; otherwise the order is left untouched.
+func (h *Component) ApplySort() {
+	cs := h.columns
+	if cs == nil || (cs.sortCol < 0 && !cs.sorted) {
+		return
+	}
+	cs.sort(h.items)
+	cs.sorted = cs.sortCol >= 0
+	h.syncList()
+}
+
+// cells renders aligned "name=value" cells for it using widths.
+func (cs *columnSet) cells(it Item, widths []int) string {
+	if cs == nil || len(cs.names) == 0 {
+		return ""
+	}
+	vals := cs.values(it)
+	var b strings.Builder
+	for i, name := range cs.names {
+		v := ""
+		if i < len(vals) {
+			v = vals[i]
+		}
+		w := widths[i]
+		if w == 0 {
+			continue
+		}
+		if v == "" {
+			b.WriteString(strings.Repeat(" ", len(name)+1+w) + "  ")
+			continue
+		}
+		v = ansi.Truncate(v, w, "…")
+		b.WriteString(lipgloss.NewStyle().Foreground(ui.ColGray).Render(name + "="))
+		b.WriteString(lipgloss.NewStyle().Foreground(ui.ColCyan).Render(v))
+		b.WriteString(strings.Repeat(" ", w-lipgloss.Width(v)) + "  ")
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package history
+
+import (
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/marang/emqutiti/constants"
+	"github.com/marang/emqutiti/ui"
+)
+
+// columnsEditor edits column definitions as "filter | name | path" lines.
+type columnsEditor struct {
+	input  textarea.Model
+	errMsg string
+}
+
+// StartColumns opens the column editor with the current definitions.
+func (h *Component) StartColumns() tea.Cmd {
+	ta := textarea.New()
+	ta.Placeholder = "sensors/# | temp | $.temp"
+	ta.ShowLineNumbers = false
+	ta.CharLimit = 0
+	ta.SetWidth(max(h.m.Width()/2, 40))
+	ta.SetHeight(8)
+	ta.SetValue(h.Columns().Format())
+	ta.Focus()
+	h.columnsEditor = &columnsEditor{input: ta}
+	return textarea.Blink
+}
+
+// UpdateColumns handles input for the column editor.
+func (h *Component) UpdateColumns(msg tea.Msg) tea.Cmd {
+	e := h.columnsEditor
+	if e == nil {
+		return nil
+	}
+	if km, ok := msg.(tea.KeyMsg); ok {
+		switch km.String() {
+		case constants.KeyEsc:
+			h.columnsEditor = nil
+			return h.m.SetMode(h.m.PreviousMode())
+		case constants.KeyCtrlS:
+			cols, err := ParseColumns(e.input.Value())
+			if err != nil {
+				e.errMsg = err.Error()
+				return nil
+			}
+			if err := SaveColumns(cols); err != nil {
+				e.errMsg = err.Error()
+				return nil
+			}
+			h.SetColumns(cols)
+			h.columnsEditor = nil
+			return h.m.SetMode(h.m.PreviousMode())
+		}
+	}
+	var cmd tea.Cmd
+	e.input, cmd = e.input.Update(msg)
+	return cmd
+}
+
+// ViewColumns displays the column editor.
+func (h *Component) ViewColumns() string {
+	e := h.columnsEditor
+	if e == nil {
+		return ""
+	}
+	content := "One column per line: topic filter | name | JSONPath\n\n" + e.input.View()
+	if e.errMsg != "" {
+		content += "\n" + ui.ErrorStyle.Render(e.errMsg)
+	}
+	content += "\n" + ui.InfoStyle.Render("[ctrl+s] save  [esc] cancel")
+	content = lipgloss.NewStyle().Padding(1, 2).Render(content)
+	box := ui.LegendBox(content, "Columns", max(h.m.Width()/2, 40)+6, 0, ui.ColBlue, true, -1)
+	return lipgloss.Place(h.m.Width(), h.m.Height(), lipgloss.Center, lipgloss.Center, box)
+}
@@ -0,0 +1,139 @@
+package history
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseColumnsFormatRoundTrip(t *testing.T) {
+	src := "sensors/# | temp | $.temp\nsensors/# | id | $.device.id\n# comment\n\ngw/+ | rssi | .rssi\n"
+	cols, err := ParseColumns(src)
+	if err != nil {
+		t.Fatalf("ParseColumns: %v", err)
+	}
+	again, err := ParseColumns(cols.Format())
+	if err != nil || !reflect.DeepEqual(cols, again) {
+		t.Fatalf("round trip mismatch: %v %v", again, err)
+	}
+	if _, err := ParseColumns("a | b"); err == nil {
+		t.Fatalf("expected error for malformed line")
+	}
+	if _, err := ParseColumns("a | b | $.x["); err == nil {
+		t.Fatalf("expected error for invalid path")
+	}
+}
+
+func TestSaveLoadColumns(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cols := Columns{"sensors/#": {{Name: "temp", Path: "$.temp"}}}
+	if err := SaveColumns(cols); err != nil {
+		t.Fatalf("SaveColumns: %v", err)
+	}
+	if got := LoadColumns(); !reflect.DeepEqual(got, cols) {
+		t.Fatalf("LoadColumns = %v, want %v", got, cols)
+	}
+}
+
+func columnItems() []Item {
+	base := time.Unix(0, 0)
+	return []Item{
+		{Timestamp: base, Kind: "sub", Topic: "sensors/a", Payload: `{"temp":21.5,"device":{"id":"b"}}`},
+		{Timestamp: base.Add(time.Second), Kind: "sub", Topic: "sensors/b", Payload: `{"temp":3}`},
+		{Timestamp: base.Add(2 * time.Second), Kind: "sub", Topic: "other", Payload: `{"temp":99}`},
+		{Timestamp: base.Add(3 * time.Second), Kind: "sub", Topic: "sensors/c", Payload: `{"temp":100,"device":{"id":"a"}}`},
+	}
+}
+
+func TestColumnValuesAndSort(t *testing.T) {
+	c := NewComponent(stubModel{}, nil)
+	c.SetColumns(Columns{"sensors/#": {{Name: "temp", Path: "$.temp"}, {Name: "id", Path: "$.device.id"}}})
+	c.items = columnItems()
+	c.syncList()
+
+	if got := c.ColumnValues(c.items[0]); !reflect.DeepEqual(got, []string{"21.5", "b"}) {
+		t.Fatalf("unexpected values %v", got)
+	}
+	if got := c.ColumnValues(c.items[2]); !reflect.DeepEqual(got, []string{"", ""}) {
+		t.Fatalf("non matching topic should have empty values, got %v", got)
+	}
+
+	topics := func() string {
+		var out []string
+		for _, it := range c.items {
+			out = append(out, it.Topic)
+		}
+		return strings.Join(out, ",")
+	}
+	c.CycleSort() // temp ascending, numeric
+	if got := topics(); got != "sensors/b,sensors/a,sensors/c,other" {
+		t.Fatalf("temp asc: %s", got)
+	}
+	if c.SortLabel() != "temp ↑" {
+		t.Fatalf("unexpected label %q", c.SortLabel())
+	}
+	c.CycleSort() // temp descending
+	if got := topics(); got != "sensors/c,sensors/a,sensors/b,other" {
+		t.Fatalf("temp desc: %s", got)
+	}
+	c.CycleSort() // id ascending
+	if got := topics(); got != "sensors/c,sensors/a,sensors/b,other" {
+		t.Fatalf("id asc: %s", got)
+	}
+	c.CycleSort()
+	c.CycleSort() // back to chronological
+	if got := topics(); got != "sensors/a,sensors/b,other,sensors/c" {
+		t.Fatalf("chronological: %s", got)
+	}
+	if len(c.list.Items()) != len(c.items) {
+		t.Fatalf("list out of sync")
+	}
+}
+
+func TestColumnValuesPerItem(t *testing.T) {
+	c := NewComponent(stubModel{}, nil)
+	c.SetColumns(Columns{"sensors/#": {{Name: "temp", Path: "$.temp"}}})
+	ts := time.Unix(0, 0)
+	a := Item{Timestamp: ts, Kind: "sub", Topic: "sensors/a", Payload: `{"temp":1}`}
+	b := Item{Timestamp: ts, Kind: "sub", Topic: "sensors/a", Payload: `{"temp":2}`}
+	c.SetItems([]Item{a, b})
+	if got := c.ColumnValues(a); got[0] != "1" {
+		t.Fatalf("unexpected value %v", got)
+	}
+	if got := c.ColumnValues(b); got[0] != "2" {
+		t.Fatalf("messages at the same time share values: %v", got)
+	}
+	c.SetItems(nil)
+	if len(c.columns.cache) != 0 {
+		t.Fatalf("expected values of replaced items to be dropped")
+	}
+
+	note := Item{Timestamp: ts, Kind: "note", Topic: "sensors/a", Payload: `{"temp":3}`}
+	if got := c.ColumnValues(note); len(got) != 1 {
+		t.Fatalf("expected values for notes by default, got %v", got)
+	}
+	c.SkipColumnKinds("note")
+	c.SetColumns(c.Columns())
+	if got := c.ColumnValues(note); got != nil {
+		t.Fatalf("expected skipped kind without values, got %v", got)
+	}
+}
+
+func TestWriteCSVIncludesColumns(t *testing.T) {
+	c := NewComponent(stubModel{}, nil)
+	c.SetColumns(Columns{"sensors/#": {{Name: "temp", Path: "$.temp"}}})
+	var buf bytes.Buffer
+	if err := c.WriteCSV(&buf, columnItems()[:1]); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(rows) != 2 || rows[0][5] != "temp" || rows[1][5] != "21.5" || rows[1][2] != "sensors/a" {
+		t.Fatalf("unexpected csv %v", rows)
+	}
+}
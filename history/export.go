@@ -0,0 +1,68 @@
+package history
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marang/emqutiti/internal/files"
+)
+
+// ExportPath returns a timestamped CSV path under the profile's exports dir.
+func ExportPath(profile, name string) string {
+	name = strings.NewReplacer("/", "_", ":", "-", string(filepath.Separator), "_").Replace(name)
+	file := fmt.Sprintf("%s-%s.csv", name, time.Now().Format("20060102-150405"))
+	return filepath.Join(files.DataDir(profile), "exports", file)
+}
+
+// WriteCSV writes items with their extracted column values as CSV.
+func (h *Component) WriteCSV(w io.Writer, items []Item) error {
+	cw := csv.NewWriter(w)
+	header := append([]string{"timestamp", "kind", "topic", "retained", "payload"}, h.ColumnNames()...)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, it := range items {
+		row := []string{
+			it.Timestamp.Format(time.RFC3339Nano),
+			it.Kind,
+			it.Topic,
+			strconv.FormatBool(it.Retained),
+			it.Payload,
+		}
+		vals := h.ColumnValues(it)
+		for i := range h.ColumnNames() {
+			v := ""
+			if i < len(vals) {
+				v = vals[i]
+			}
+			row = append(row, v)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Export writes items as CSV to path, creating parent directories.
+func (h *Component) Export(path string, items []Item) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := h.WriteCSV(f, items); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -11,8 +11,14 @@ func (h *Component) List() *list.Model { return &h.list }
 // Items returns the current history items.
 func (h *Component) Items() []Item { return h.items }
 
-// SetItems replaces the current history items.
-func (h *Component) SetItems(items []Item) { h.items = items }
+// SetItems replaces the current history items and forgets the column values
+// of the previous ones.
+func (h *Component) SetItems(items []Item) {
+	h.items = items
+	if h.columns != nil {
+		clear(h.columns.cache)
+	}
+}
 
 // Store returns the underlying history store.
 func (h *Component) Store() Store { return h.store }
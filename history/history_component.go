@@ -24,6 +24,9 @@ type historyState struct {
 	filterQuery     string
 	detail          viewport.Model
 	detailItem      Item
+	detailJSON      *jsonView
+	columns         *columnSet
+	columnsEditor   *columnsEditor
 }
 
 // Component provides history browsing and filtering functionality. It holds its
@@ -67,17 +70,40 @@ func (h *Component) UpdateDetail(msg tea.Msg) tea.Cmd {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case constants.KeyEsc:
-			return h.m.SetMode(h.m.PreviousMode())
-		case constants.KeyCtrlD:
+		if msg.String() == constants.KeyCtrlD {
 			return tea.Quit
 		}
+		if h.detailJSON != nil {
+			if cmd, ok := h.detailJSON.Update(msg, h.jsonHeight()); ok {
+				return cmd
+			}
+		}
+		if msg.String() == constants.KeyEsc {
+			return h.m.SetMode(h.m.PreviousMode())
+		}
+	}
+	if h.detailJSON != nil {
+		return nil
 	}
 	h.detail, cmd = h.detail.Update(msg)
 	return cmd
 }
 
+// OpenDetail prepares the detail view for it. JSON objects and arrays are
+// shown as a collapsible tree.
+func (h *Component) OpenDetail(it Item) {
+	h.detailItem = it
+	h.detailJSON = nil
+	if v, err := newJSONView(it.Payload); err == nil {
+		h.detailJSON = v
+	}
+	h.detail.SetContent(it.Payload)
+	h.detail.SetYOffset(0)
+}
+
+// jsonHeight returns the rows available to the JSON tree below the path box.
+func (h *Component) jsonHeight() int { return max(h.detail.Height-2, 1) }
+
 // UpdateFilter handles the history filter form interaction.
 func (h *Component) UpdateFilter(msg tea.Msg) tea.Cmd {
 	if h.filterForm == nil {
@@ -98,6 +124,7 @@ func (h *Component) UpdateFilter(msg tea.Msg) tea.Cmd {
 			var items []list.Item
 			h.items, items = MessagesToItems(msgs)
 			h.list.SetItems(items)
+			h.ApplySort()
 			h.list.FilterInput.SetValue("")
 			h.list.SetFilterState(list.Unfiltered)
 			h.filterQuery = q
@@ -113,6 +140,9 @@ func (h *Component) UpdateFilter(msg tea.Msg) tea.Cmd {
 
 // ViewDetail renders the full payload of a history message.
 func (h *Component) ViewDetail() string {
+	if h.detailJSON != nil {
+		return h.viewDetailJSON()
+	}
 	lines := strings.Split(h.detail.View(), "\n")
 	help := ui.InfoStyle.Render("[esc] back")
 	lines = append(lines, help)
@@ -125,6 +155,24 @@ func (h *Component) ViewDetail() string {
 	return h.m.OverlayHelp(view)
 }
 
+// viewDetailJSON renders the JSON tree with the path extraction box.
+func (h *Component) viewDetailJSON() string {
+	v := h.detailJSON
+	height := h.jsonHeight()
+	help := "[/] path  [enter] toggle  [e]xpand all  [c]ollapse all  [esc] back"
+	if v.showing {
+		help = "[/] path  [esc] tree"
+	}
+	content := strings.Join([]string{
+		v.PathView(),
+		"",
+		v.View(height),
+		ui.InfoStyle.Render(help),
+	}, "\n")
+	view := ui.LegendBox(content, "Message", h.m.Width()-2, h.m.Height()-2, ui.ColGreen, true, v.ScrollPercent(height))
+	return h.m.OverlayHelp(view)
+}
+
 // ViewFilter displays the history filter form.
 func (h *Component) ViewFilter() string {
 	if h.filterForm == nil {
@@ -152,16 +200,23 @@ func (h *Component) appendItems(items ...Item) {
 			listItems = append(listItems, it)
 		}
 		h.list.SetItems(listItems)
+		h.ApplySort()
 		h.list.Select(len(listItems) - 1)
 		return
 	}
 	h.items = append(h.items, items...)
+	h.syncList()
+	h.ApplySort()
+	h.list.Select(len(h.items) - 1)
+}
+
+// syncList rebuilds the list items from h.items.
+func (h *Component) syncList() {
 	listItems := make([]list.Item, len(h.items))
 	for i, it := range h.items {
 		listItems[i] = it
 	}
 	h.list.SetItems(listItems)
-	h.list.Select(len(listItems) - 1)
 }
 
 // Append stores a message in the history list and optional store.
@@ -18,7 +18,8 @@ const historyPreviewLimit = 256
 
 // historyDelegate renders history items with two lines and supports highlighting
 // selected entries. It has no direct dependency on the application model.
-type historyDelegate struct{}
+// Configured extraction columns are shown aligned at the start of the header.
+type historyDelegate struct{ cols *columnSet }
 
 // Height returns the fixed height for history entries.
 func (d historyDelegate) Height() int { return 2 }
@@ -46,6 +47,10 @@ func (d historyDelegate) Render(w io.Writer, m list.Model, index int, item list.
 		label = fmt.Sprintf("PUB %s", hi.Topic)
 		lblColor = ui.ColBlue
 		msgColor = ui.ColSub
+	case "note":
+		label = strings.TrimSpace("NOTE " + hi.Topic)
+		lblColor = ui.ColWarn
+		msgColor = ui.ColWarn
 	default:
 		label = ""
 		lblColor = ui.ColGray
@@ -69,7 +74,12 @@ func (d historyDelegate) Render(w io.Writer, m list.Model, index int, item list.
 		header := lipgloss.JoinHorizontal(lipgloss.Top,
 			lipgloss.NewStyle().Foreground(lblColor).Render(label),
 			lipgloss.NewStyle().Foreground(ui.ColGray).Render(" "+ts+":"))
-		lines = append(lines, lipgloss.PlaceHorizontal(innerWidth, align, header))
+		cells := d.cols.cells(hi, d.pageWidths(m))
+		cw := lipgloss.Width(cells)
+		if cw >= innerWidth {
+			cells, cw = ansi.Truncate(cells, innerWidth, ""), innerWidth
+		}
+		lines = append(lines, cells+lipgloss.PlaceHorizontal(innerWidth-cw, align, header))
 	}
 	payload := strings.ReplaceAll(hi.Payload, "\r\n", "\n")
 	payload = strings.ReplaceAll(payload, "\n", "\u23ce")
@@ -114,3 +124,19 @@ func (d historyDelegate) Render(w io.Writer, m list.Model, index int, item list.
 	lines = ui.FormatHistoryLines(lines, width, bar)
 	fmt.Fprint(w, strings.Join(lines, "\n"))
 }
+
+// pageWidths returns the column widths for the items on the current page.
+func (d historyDelegate) pageWidths(m list.Model) []int {
+	if d.cols == nil || len(d.cols.names) == 0 {
+		return nil
+	}
+	visible := m.VisibleItems()
+	start, end := m.Paginator.GetSliceBounds(len(visible))
+	items := make([]Item, 0, end-start)
+	for _, li := range visible[start:end] {
+		if it, ok := li.(Item); ok {
+			items = append(items, it)
+		}
+	}
+	return d.cols.widths(items)
+}
@@ -14,7 +14,7 @@ type Item struct {
 	Timestamp           time.Time
 	Topic               string
 	Payload             string
-	Kind                string // pub, sub, log
+	Kind                string // pub, sub, log, note
 	Archived            bool
 	Retained            bool
 	IsSelected          *bool
@@ -35,6 +35,9 @@ func (h Item) Title() string {
 	case "pub":
 		label = "PUB"
 		color = ui.ColBlue
+	case "note":
+		label = "NOTE"
+		color = ui.ColWarn
 	default:
 		label = "LOG"
 		color = ui.ColGray
@@ -0,0 +1,377 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/marang/emqutiti/constants"
+	"github.com/marang/emqutiti/internal/jsonpath"
+	"github.com/marang/emqutiti/ui"
+)
+
+// jsonNode is a JSON value with object key order preserved.
+type jsonNode struct {
+	key       string // object member name, empty for array elements and root
+	hasKey    bool
+	kind      byte // '{', '[' or 0 for scalars
+	raw       string
+	children  []*jsonNode
+	collapsed bool
+}
+
+// jsonLine is a single rendered row of the tree.
+type jsonLine struct {
+	node    *jsonNode
+	depth   int
+	closing bool
+}
+
+// parseJSONTree decodes an object or array payload into a tree.
+func parseJSONTree(s string) (*jsonNode, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || (s[0] != '{' && s[0] != '[') {
+		return nil, fmt.Errorf("not a JSON object or array")
+	}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	root, err := decodeNode(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("trailing data after JSON value")
+	}
+	return root, nil
+}
+
+func decodeNode(dec *json.Decoder) (*jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	n := &jsonNode{}
+	switch t := tok.(type) {
+	case json.Delim:
+		n.kind = byte(t)
+		for dec.More() {
+			key := ""
+			if n.kind == '{' {
+				kt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ = kt.(string)
+			}
+			c, err := decodeNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			c.key, c.hasKey = key, n.kind == '{'
+			n.children = append(n.children, c)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	case json.Number:
+		n.raw = t.String()
+	default:
+		b, _ := json.Marshal(t)
+		n.raw = string(b)
+	}
+	return n, nil
+}
+
+// setCollapsed collapses or expands n and all descendants.
+func (n *jsonNode) setCollapsed(c bool) {
+	if n.kind != 0 {
+		n.collapsed = c
+	}
+	for _, ch := range n.children {
+		ch.setCollapsed(c)
+	}
+}
+
+// decodeJSON decodes s for path extraction, keeping numbers as json.Number
+// so large integers are not rounded.
+func decodeJSON(s string) (interface{}, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("trailing data after JSON value")
+	}
+	return doc, nil
+}
+
+// jsonView renders a collapsible JSON tree with a path extraction box.
+type jsonView struct {
+	root    *jsonNode
+	doc     interface{}
+	lines   []jsonLine
+	cursor  int
+	offset  int
+	path    textinput.Model
+	result  []string
+	errMsg  string
+	showing bool // true when extraction results replace the tree
+}
+
+func newJSONView(payload string) (*jsonView, error) {
+	root, err := parseJSONTree(payload)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeJSON(payload)
+	if err != nil {
+		return nil, err
+	}
+	ti := textinput.New()
+	ti.Placeholder = "$.path or .path[0]"
+	ti.Prompt = ""
+	v := &jsonView{root: root, doc: doc, path: ti}
+	v.rebuild()
+	return v, nil
+}
+
+// rebuild recomputes the visible rows from the tree.
+func (v *jsonView) rebuild() {
+	v.lines = v.lines[:0]
+	var add func(n *jsonNode, depth int)
+	add = func(n *jsonNode, depth int) {
+		v.lines = append(v.lines, jsonLine{node: n, depth: depth})
+		if n.kind == 0 || n.collapsed {
+			return
+		}
+		for _, c := range n.children {
+			add(c, depth+1)
+		}
+		v.lines = append(v.lines, jsonLine{node: n, depth: depth, closing: true})
+	}
+	add(v.root, 0)
+	if v.cursor >= len(v.lines) {
+		v.cursor = len(v.lines) - 1
+	}
+}
+
+// toggle expands or collapses the container under the cursor.
+func (v *jsonView) toggle(collapse *bool) {
+	ln := v.lines[v.cursor]
+	if ln.node.kind == 0 {
+		return
+	}
+	if collapse != nil {
+		ln.node.collapsed = *collapse
+	} else {
+		ln.node.collapsed = !ln.node.collapsed
+	}
+	v.rebuild()
+	for i, l := range v.lines {
+		if l.node == ln.node && !l.closing {
+			v.cursor = i
+			break
+		}
+	}
+}
+
+// extract evaluates the path expression and stores highlighted results.
+func (v *jsonView) extract() {
+	v.errMsg = ""
+	expr := strings.TrimSpace(v.path.Value())
+	if expr == "" {
+		v.showing = false
+		v.result = nil
+		return
+	}
+	vals, err := jsonpath.Eval(v.doc, expr)
+	if err != nil {
+		v.errMsg = err.Error()
+		return
+	}
+	v.result = v.result[:0]
+	if len(vals) == 0 {
+		v.result = append(v.result, "no match")
+	}
+	for _, val := range vals {
+		b, err := json.MarshalIndent(val, "", "  ")
+		if err != nil {
+			continue
+		}
+		for _, l := range strings.Split(string(b), "\n") {
+			v.result = append(v.result, ui.HighlightJSON(l))
+		}
+	}
+	v.showing = true
+	v.offset = 0
+}
+
+// Update handles keys for the tree and path box. It reports whether the key
+// was consumed.
+func (v *jsonView) Update(msg tea.KeyMsg, height int) (tea.Cmd, bool) {
+	key := msg.String()
+	if v.path.Focused() {
+		switch key {
+		case constants.KeyEnter:
+			v.extract()
+			v.path.Blur()
+			return nil, true
+		case constants.KeyEsc:
+			v.path.Blur()
+			return nil, true
+		}
+		var cmd tea.Cmd
+		v.path, cmd = v.path.Update(msg)
+		return cmd, true
+	}
+	total := len(v.lines)
+	if v.showing {
+		total = len(v.result)
+	}
+	switch key {
+	case constants.KeySlash:
+		v.path.Focus()
+		return textinput.Blink, true
+	case constants.KeyEsc:
+		if v.showing {
+			v.showing = false
+			v.offset = 0
+			v.ensureVisible(height)
+			return nil, true
+		}
+		return nil, false
+	case constants.KeyUp, constants.KeyK:
+		if v.showing {
+			v.offset = max(v.offset-1, 0)
+		} else if v.cursor > 0 {
+			v.cursor--
+		}
+	case constants.KeyDown, constants.KeyJ:
+		if v.showing {
+			v.offset = min(v.offset+1, max(total-height, 0))
+		} else if v.cursor < total-1 {
+			v.cursor++
+		}
+	case constants.KeyEnter, constants.KeySpace, constants.KeySpaceBar:
+		if !v.showing {
+			v.toggle(nil)
+		}
+	case constants.KeyLeft, constants.KeyH:
+		if !v.showing {
+			c := true
+			v.toggle(&c)
+		}
+	case constants.KeyRight, constants.KeyL:
+		if !v.showing {
+			c := false
+			v.toggle(&c)
+		}
+	case constants.KeyE:
+		v.root.setCollapsed(false)
+		v.rebuild()
+	case constants.KeyC:
+		v.root.setCollapsed(true)
+		v.cursor = 0
+		v.rebuild()
+	default:
+		return nil, false
+	}
+	if !v.showing {
+		v.ensureVisible(height)
+	}
+	return nil, true
+}
+
+func (v *jsonView) ensureVisible(height int) {
+	if height <= 0 {
+		return
+	}
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+height {
+		v.offset = v.cursor - height + 1
+	}
+}
+
+// text renders the JSON fragment for a row without indentation.
+func (l jsonLine) text() string {
+	n := l.node
+	if l.closing {
+		return string(n.kind + 2) // '{'+2 == '}', '['+2 == ']'
+	}
+	var b bytes.Buffer
+	if n.hasKey {
+		k, _ := json.Marshal(n.key)
+		b.Write(k)
+		b.WriteString(": ")
+	}
+	switch {
+	case n.kind == 0:
+		b.WriteString(n.raw)
+	case n.collapsed:
+		fmt.Fprintf(&b, "%c…%c", n.kind, n.kind+2)
+	default:
+		b.WriteByte(n.kind)
+	}
+	return b.String()
+}
+
+// View renders height rows of the tree or extraction result.
+func (v *jsonView) View(height int) string {
+	var rows []string
+	if v.showing {
+		end := min(v.offset+height, len(v.result))
+		rows = append(rows, v.result[v.offset:end]...)
+		return strings.Join(rows, "\n")
+	}
+	end := min(v.offset+height, len(v.lines))
+	for i := v.offset; i < end; i++ {
+		ln := v.lines[i]
+		marker := "  "
+		if ln.node.kind != 0 && !ln.closing {
+			marker = "▾ "
+			if ln.node.collapsed {
+				marker = "▸ "
+			}
+		}
+		cur := " "
+		if i == v.cursor {
+			cur = ui.FocusedStyle.Render(">")
+		}
+		row := cur + strings.Repeat("  ", ln.depth) + marker + ui.HighlightJSON(ln.text())
+		if ln.node.collapsed && !ln.closing {
+			row += ui.BlurredStyle.Render(fmt.Sprintf(" %d items", len(ln.node.children)))
+		}
+		rows = append(rows, row)
+	}
+	return strings.Join(rows, "\n")
+}
+
+// ScrollPercent reports the scroll position for the box indicator.
+func (v *jsonView) ScrollPercent(height int) float64 {
+	total := len(v.lines)
+	if v.showing {
+		total = len(v.result)
+	}
+	if total <= height {
+		return -1
+	}
+	return float64(v.offset) / float64(total-height)
+}
+
+// PathView renders the path expression box.
+func (v *jsonView) PathView() string {
+	line := "Path: " + v.path.View()
+	if v.errMsg != "" {
+		line += "  " + ui.ErrorStyle.Render(v.errMsg)
+	}
+	return line
+}
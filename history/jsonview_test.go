@@ -0,0 +1,98 @@
+package history
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func keyMsg(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestParseJSONTreeKeepsOrder(t *testing.T) {
+	root, err := parseJSONTree(`{"b":1,"a":{"c":[true,null]}}`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(root.children) != 2 || root.children[0].key != "b" || root.children[1].key != "a" {
+		t.Fatalf("unexpected key order")
+	}
+	if _, err := parseJSONTree("42"); err == nil {
+		t.Fatalf("expected scalar payload to be rejected")
+	}
+}
+
+func TestJSONViewToggleAndExtract(t *testing.T) {
+	v, err := newJSONView(`{"dev":{"id":"gw"},"vals":[1,2]}`)
+	if err != nil {
+		t.Fatalf("newJSONView: %v", err)
+	}
+	full := len(v.lines)
+	v.Update(keyMsg("down"), 10)
+	v.Update(keyMsg("enter"), 10)
+	if !v.lines[1].node.collapsed || len(v.lines) != full-2 {
+		t.Fatalf("expected dev to collapse, lines=%d", len(v.lines))
+	}
+	if out := ansi.Strip(v.View(10)); !strings.Contains(out, `"dev": {…}`) {
+		t.Fatalf("collapsed node not rendered: %q", out)
+	}
+
+	v.Update(keyMsg("/"), 10)
+	for _, r := range "$.vals[1]" {
+		v.Update(keyMsg(string(r)), 10)
+	}
+	v.Update(keyMsg("enter"), 10)
+	if !v.showing || len(v.result) != 1 || ansi.Strip(v.result[0]) != "2" {
+		t.Fatalf("unexpected extraction result %q", v.result)
+	}
+	if _, ok := v.Update(keyMsg("esc"), 10); !ok || v.showing {
+		t.Fatalf("esc should return to the tree")
+	}
+	if _, ok := v.Update(keyMsg("esc"), 10); ok {
+		t.Fatalf("esc on the tree should not be consumed")
+	}
+}
+
+func TestJSONExtractionKeepsLargeIntegers(t *testing.T) {
+	v, err := newJSONView(`{"id":9007199254740993,"n":[12345678901234567890]}`)
+	if err != nil {
+		t.Fatalf("newJSONView: %v", err)
+	}
+	v.path.SetValue("$.id")
+	v.extract()
+	if len(v.result) != 1 || ansi.Strip(v.result[0]) != "9007199254740993" {
+		t.Fatalf("unexpected extraction result %q", v.result)
+	}
+	c := NewComponent(stubModel{}, nil)
+	c.SetColumns(Columns{"#": {{Name: "n", Path: "$.n[0]"}}})
+	it := Item{Kind: "sub", Topic: "t", Payload: `{"n":[12345678901234567890]}`}
+	if got := c.ColumnValues(it); got[0] != "12345678901234567890" {
+		t.Fatalf("unexpected column value %v", got)
+	}
+	if _, err := newJSONView(`{"a":1} {"b":2}`); err == nil {
+		t.Fatalf("expected trailing data error")
+	}
+}
+
+func TestOpenDetailJSON(t *testing.T) {
+	c := NewComponent(stubModel{}, nil)
+	c.OpenDetail(Item{Payload: `[{"a":1}]`})
+	if c.detailJSON == nil {
+		t.Fatalf("expected JSON tree for array payload")
+	}
+	c.OpenDetail(Item{Payload: "plain text"})
+	if c.detailJSON != nil {
+		t.Fatalf("expected raw view for text payload")
+	}
+}
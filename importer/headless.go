@@ -0,0 +1,223 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/marang/emqutiti/importer/steps"
+)
+
+// Options configures a headless import. Empty settings fall back to the
+// wizard's saved preferences or, when MappingFile is set, to that file.
+type Options struct {
+	File string
+	// MappingFile is a file in the importer.toml format providing the
+	// mapping, template, payload and publish options.
+	MappingFile string
+	// Preset names a saved preset, or `auto` for the one matching the
+	// file's headers. Presets are read from MappingFile when it is set.
+	Preset         string
+	Template       string
+	Payload        string
+	ReadOptions    string
+	PublishOptions string
+	// QoS and Retain take precedence over the publish options when set.
+	QoS    *byte
+	Retain *bool
+	DryRun bool
+}
+
+// Summary counts the outcome of a headless import.
+type Summary struct {
+	Total     int
+	Published int
+	Failed    int
+	Skipped   int
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%d rows: %d published, %d failed, %d skipped", s.Total, s.Published, s.Failed, s.Skipped)
+}
+
+// progressSteps is how often a headless import reports progress.
+const progressSteps = 20
+
+// Run imports o.File without a UI, writing progress, per-row problems and a
+// summary to out. Dry runs print the messages instead of publishing them
+// and need no client. Rows that failed or were skipped are written to
+// steps.RejectsPath(o.File). Run fails when rows could not be published.
+func Run(ctx context.Context, client steps.Publisher, o Options, out io.Writer) (Summary, error) {
+	var sum Summary
+	prefs := steps.LoadPrefs()
+	if o.MappingFile != "" {
+		var err error
+		prefs, err = steps.LoadPrefsFile(o.MappingFile)
+		if err != nil {
+			return sum, fmt.Errorf("mapping file: %w", err)
+		}
+	}
+	readOpts, err := steps.ParseReadOptions(o.ReadOptions)
+	if err != nil {
+		return sum, fmt.Errorf("read options: %w", err)
+	}
+	info, err := steps.ScanFile(o.File, readOpts, 0)
+	if err != nil {
+		return sum, err
+	}
+	sum.Total = info.Rows
+	if o.Preset != "" {
+		name, pr, err := prefs.FindPreset(o.Preset, info.Headers)
+		if err != nil {
+			return sum, fmt.Errorf("preset: %w", err)
+		}
+		fmt.Fprintf(out, "using preset %s\n", name)
+		prefs.Mapping, prefs.Template, prefs.Payload, prefs.Publish = pr.Mapping, pr.Template, pr.Payload, pr.Publish
+	}
+	enc := steps.Encoder{Template: firstSet(o.Template, prefs.Template), Mapping: prefs.Mapping}
+	if enc.Template == "" {
+		return sum, fmt.Errorf("no topic template, pass --template")
+	}
+	if src := firstSet(o.Payload, prefs.Payload); src != "" {
+		t, err := steps.ParsePayloadTemplate(src)
+		if err != nil {
+			return sum, fmt.Errorf("payload template: %w", err)
+		}
+		enc.Payload = t
+	}
+	pubOpts, err := steps.ParsePublishOptions(firstSet(o.PublishOptions, prefs.Publish))
+	if err != nil {
+		return sum, fmt.Errorf("publish options: %w", err)
+	}
+	qos, retain := pubOpts.QoS, pubOpts.Retain
+	if o.QoS != nil {
+		qos = *o.QoS
+	}
+	if o.Retain != nil {
+		retain = *o.Retain
+	}
+	for _, h := range info.Headers {
+		if _, err := steps.ParseFieldSpec(h, prefs.Mapping[h]); err != nil {
+			return sum, fmt.Errorf("mapping: %w", err)
+		}
+	}
+	var pacer *steps.Pacer
+	if !o.DryRun && (pubOpts.Rate > 0 || pubOpts.PaceBy != "") {
+		pacer = steps.NewPacer(pubOpts)
+	}
+
+	rows, err := steps.OpenRows(o.File, readOpts)
+	if err != nil {
+		return sum, err
+	}
+	defer rows.Close()
+	var rejects *steps.Rejects
+	if !o.DryRun {
+		rejects = steps.NewRejects(o.File, info.Headers, false)
+		defer func() {
+			rejects.Close()
+			if rejects.Rows > 0 {
+				fmt.Fprintf(out, "%d rejected rows written to %s\n", rejects.Rows, rejects.Path)
+			}
+		}()
+	}
+	reject := func(row map[string]string) {
+		if rejects == nil {
+			return
+		}
+		if err := rejects.Add(row); err != nil {
+			fmt.Fprintf(out, "error writing %s: %v\n", rejects.Path, err)
+		}
+	}
+	every := max(sum.Total/progressSteps, 1)
+	for i := 1; ; i++ {
+		if err := ctx.Err(); err != nil {
+			fmt.Fprintln(out, sum)
+			return sum, err
+		}
+		row, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			sum.Failed++
+			fmt.Fprintf(out, "row %d: %v\n", i, err)
+			break
+		}
+		topic, payload, err := enc.Encode(row)
+		if err != nil {
+			sum.Skipped++
+			fmt.Fprintf(out, "row %d skipped: %v\n", i, err)
+			reject(row)
+			continue
+		}
+		if o.DryRun {
+			fmt.Fprintf(out, "%s -> %s\n", topic, payload)
+			sum.Published++
+			continue
+		}
+		if pacer != nil {
+			wait, err := pacer.Delay(row, time.Now())
+			if err != nil {
+				fmt.Fprintf(out, "row %d not paced: %v\n", i, err)
+			}
+			if !sleepCtx(ctx, wait) {
+				continue
+			}
+		}
+		err = pubOpts.Retry(func() error {
+			return client.Publish(topic, qos, retain, payload)
+		}, func(d time.Duration) bool {
+			return sleepCtx(ctx, d)
+		})
+		if err != nil {
+			sum.Failed++
+			fmt.Fprintf(out, "row %d: error publishing %s: %v\n", i, topic, err)
+			reject(row)
+			if pubOpts.TooManyErrors(sum.Failed) {
+				fmt.Fprintln(out, sum)
+				return sum, fmt.Errorf("aborted at row %d after %d errors", i, sum.Failed)
+			}
+		} else {
+			sum.Published++
+		}
+		if i%every == 0 || i == sum.Total {
+			fmt.Fprintf(out, "progress %d/%d (%d%%)\n", i, sum.Total, i*100/max(sum.Total, 1))
+		}
+	}
+	if o.DryRun {
+		fmt.Fprintf(out, "dry run, %s\n", sum)
+	} else {
+		fmt.Fprintln(out, sum)
+	}
+	if sum.Failed > 0 {
+		return sum, fmt.Errorf("%d of %d rows failed", sum.Failed, sum.Total)
+	}
+	return sum, nil
+}
+
+func firstSet(vals ...string) string {
+	for _, v := range vals {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// sleepCtx waits for d and reports false when ctx ended first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
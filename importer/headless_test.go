@@ -0,0 +1,106 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type flakyPublisher struct {
+	topics []string
+	qos    byte
+	retain bool
+}
+
+func (f *flakyPublisher) Publish(topic string, qos byte, retained bool, payload interface{}) error {
+	if strings.HasSuffix(topic, "/3") {
+		return errors.New("broker gone")
+	}
+	f.topics = append(f.topics, topic)
+	f.qos, f.retain = qos, retained
+	return nil
+}
+
+func TestRunHeadless(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(file, []byte("id,temp\n1,20.5\n2,x\n3,22\n4,23\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mapping := filepath.Join(t.TempDir(), "map.toml")
+	if err := os.WriteFile(mapping, []byte("template = \"dev/{id}\"\n[mapping]\ntemp = \"t:float\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	sum, err := Run(context.Background(), nil, Options{File: file, MappingFile: mapping, DryRun: true}, &out)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if sum != (Summary{Total: 4, Published: 3, Skipped: 1}) {
+		t.Fatalf("unexpected summary %+v", sum)
+	}
+	if !strings.Contains(out.String(), `dev/1 -> {"id":"1","t":20.5}`) || !strings.Contains(out.String(), "row 2 skipped") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+
+	pub := &flakyPublisher{}
+	out.Reset()
+	sum, err = Run(context.Background(), pub, Options{File: file, MappingFile: mapping, Template: "x/{id}", QoS: ptr(byte(1)), Retain: ptr(true)}, &out)
+	if err == nil {
+		t.Fatalf("expected failure")
+	}
+	if sum != (Summary{Total: 4, Published: 2, Failed: 1, Skipped: 1}) {
+		t.Fatalf("unexpected summary %+v", sum)
+	}
+	if strings.Join(pub.topics, ",") != "x/1,x/4" || pub.qos != 1 || !pub.retain {
+		t.Fatalf("unexpected publishes %v qos %d retain %v", pub.topics, pub.qos, pub.retain)
+	}
+	if !strings.Contains(out.String(), "4 rows: 2 published, 1 failed, 1 skipped") {
+		t.Fatalf("missing summary:\n%s", out.String())
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestRunHeadlessFlagsOverridePublishOptions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(file, []byte("id\n1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mapping := filepath.Join(t.TempDir(), "map.toml")
+	if err := os.WriteFile(mapping, []byte("template = \"dev/{id}\"\npublish = \"qos=2 retain=true\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	pub := &flakyPublisher{}
+	if _, err := Run(context.Background(), pub, Options{File: file, MappingFile: mapping}, &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if pub.qos != 2 || !pub.retain {
+		t.Fatalf("expected mapping publish options, got qos %d retain %v", pub.qos, pub.retain)
+	}
+	pub = &flakyPublisher{}
+	if _, err := Run(context.Background(), pub, Options{File: file, MappingFile: mapping, QoS: ptr(byte(0)), Retain: ptr(false)}, &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if pub.qos != 0 || pub.retain {
+		t.Fatalf("expected --qos 0 and --retain=false to win, got qos %d retain %v", pub.qos, pub.retain)
+	}
+}
+
+func TestRunHeadlessNeedsTemplate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(file, []byte("id\n1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Run(context.Background(), nil, Options{File: file, DryRun: true}, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "template") {
+		t.Fatalf("expected template error, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/marang/emqutiti/importer/steps"
+)
+
+func TestParseErrorPolicyOptions(t *testing.T) {
+	o, err := steps.ParsePublishOptions("qos=1 retain=true retries=3 backoff=200ms max-errors=5")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := steps.PublishOptions{QoS: 1, Retain: true, Retries: 3, Backoff: 200 * time.Millisecond, MaxErrors: 5}
+	if o != want {
+		t.Fatalf("got %+v", o)
+	}
+	if o.String() != "qos=1 retain=true retries=3 backoff=200ms max-errors=5" {
+		t.Fatalf("got %q", o.String())
+	}
+	for _, s := range []string{"qos=3", "retain=maybe", "retries=-1", "backoff=0s", "max-errors=0"} {
+		if _, err := steps.ParsePublishOptions(s); err == nil {
+			t.Fatalf("expected error for %q", s)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	o := steps.PublishOptions{Retries: 3, Backoff: 100 * time.Millisecond}
+	calls := 0
+	var waits []time.Duration
+	err := o.Retry(func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("busy")
+		}
+		return nil
+	}, func(d time.Duration) bool {
+		waits = append(waits, d)
+		return true
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("expected success on third call, got %v after %d", err, calls)
+	}
+	if len(waits) != 2 || waits[0] != 100*time.Millisecond || waits[1] != 200*time.Millisecond {
+		t.Fatalf("unexpected backoff %v", waits)
+	}
+
+	calls = 0
+	err = o.Retry(func() error { calls++; return errors.New("down") }, func(time.Duration) bool { return true })
+	if err == nil || calls != 4 {
+		t.Fatalf("expected failure after 4 calls, got %v after %d", err, calls)
+	}
+}
+
+// failPublisher fails every topic in fail.
+type failPublisher struct {
+	fail   map[string]bool
+	topics []string
+}
+
+func (f *failPublisher) Publish(topic string, qos byte, retained bool, payload interface{}) error {
+	if f.fail[topic] {
+		return errors.New("not authorized")
+	}
+	f.topics = append(f.topics, topic)
+	return nil
+}
+
+func TestRunHeadlessRejectsAndAborts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(file, []byte("id,temp\n1,20\n2,x\n3,22\n4,23\n5,24\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pub := &failPublisher{fail: map[string]bool{"d/3": true, "d/4": true}}
+	opts := Options{File: file, Template: "d/{id}", Payload: "{{.temp}}", PublishOptions: "max-errors=2"}
+	var out bytes.Buffer
+	sum, err := Run(context.Background(), pub, opts, &out)
+	if err == nil || !strings.Contains(err.Error(), "aborted at row 4") {
+		t.Fatalf("expected abort, got %v", err)
+	}
+	if sum.Published != 2 || sum.Failed != 2 || strings.Join(pub.topics, ",") != "d/1,d/2" {
+		t.Fatalf("unexpected summary %+v %v", sum, pub.topics)
+	}
+	data, err := os.ReadFile(steps.RejectsPath(file))
+	if err != nil {
+		t.Fatalf("rejects: %v", err)
+	}
+	if string(data) != "id,temp\n3,22\n4,23\n" {
+		t.Fatalf("unexpected rejects:\n%s", data)
+	}
+	if !strings.Contains(out.String(), "2 rejected rows written to") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestWizardAbortsAfterErrors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "rows.csv")
+	if err := os.WriteFile(file, []byte("n\n1\n2\n3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w := New(&failPublisher{fail: map[string]bool{"t/1": true}}, file)
+	loadFile(w)
+	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	w.Base.Tmpl.SetValue("t/{n}")
+	w.Base.PubOpts.SetValue("qos=1 max-errors=1")
+	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	runCmd(w, w.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}}))
+	if !w.Base.Finished || !w.Base.Aborted || w.Base.Failed != 1 {
+		t.Fatalf("expected abort after first row, got finished=%v aborted=%v failed=%d", w.Base.Finished, w.Base.Aborted, w.Base.Failed)
+	}
+	if got := steps.ResumeOffset(file); got != 1 {
+		t.Fatalf("expected resume offset 1, got %d", got)
+	}
+	data, err := os.ReadFile(steps.RejectsPath(file))
+	if err != nil || string(data) != "n\n1\n" {
+		t.Fatalf("unexpected rejects %q %v", data, err)
+	}
+}
+
+func TestWizardPauseStopsPublishing(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "rows.csv")
+	if err := os.WriteFile(file, []byte("n\n1\n2\n3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w := New(&failPublisher{fail: map[string]bool{"t/1": true}}, file)
+	loadFile(w)
+	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	w.Base.Tmpl.SetValue("t/{n}")
+	w.Base.PubOpts.SetValue("retries=3 backoff=1h")
+	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	batch, ok := w.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})().(tea.BatchMsg)
+	if !ok {
+		t.Fatalf("expected batched publish commands")
+	}
+	msgs := make(chan tea.Msg, len(batch))
+	for _, c := range batch {
+		go func() { msgs <- c() }()
+	}
+	run := w.Base.Run
+	w.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	for range batch {
+		select {
+		case msg := <-msgs:
+			if _, ok := msg.(steps.PublishMsg); ok {
+				t.Fatalf("paused import kept publishing: %+v", msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("retry backoff not cancelled by the pause")
+		}
+	}
+	if _, err := os.Stat(steps.RejectsPath(file)); !os.IsNotExist(err) {
+		t.Fatalf("paused import wrote rejects: %v", err)
+	}
+
+	w.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	w.Update(steps.PublishMsg{Run: run, Line: "stale"})
+	if w.Base.Index != 0 || w.Base.Run == run || len(w.Base.Published) != 0 {
+		t.Fatalf("stale message of the paused run was processed: index %d, %v", w.Base.Index, w.Base.Published)
+	}
+	w.Close()
+}
@@ -0,0 +1,85 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marang/emqutiti/importer/steps"
+)
+
+func TestParsePublishOptions(t *testing.T) {
+	o, err := steps.ParsePublishOptions("rate=50/s burst=10 pace=ts speed=2x")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := steps.PublishOptions{Rate: 50, Burst: 10, PaceBy: "ts", Speed: 2}
+	if o != want {
+		t.Fatalf("got %+v", o)
+	}
+	if o.String() != "rate=50 burst=10 pace=ts speed=2" {
+		t.Fatalf("got %q", o.String())
+	}
+	for _, s := range []string{"rate=0", "burst=x", "speed=-1", "qps=3", "rate"} {
+		if _, err := steps.ParsePublishOptions(s); err == nil {
+			t.Fatalf("expected error for %q", s)
+		}
+	}
+}
+
+func TestPacerRateLimit(t *testing.T) {
+	p := steps.NewPacer(steps.PublishOptions{Rate: 10, Burst: 2})
+	now := time.Unix(0, 0)
+	var waits []time.Duration
+	for i := 0; i < 4; i++ {
+		d, _ := p.Delay(nil, now)
+		waits = append(waits, d)
+		now = now.Add(d)
+	}
+	want := []time.Duration{0, 0, 100 * time.Millisecond, 100 * time.Millisecond}
+	for i := range want {
+		if waits[i] != want[i] {
+			t.Fatalf("waits %v, want %v", waits, want)
+		}
+	}
+}
+
+func TestPacerTimestampColumn(t *testing.T) {
+	p := steps.NewPacer(steps.PublishOptions{PaceBy: "ts", Speed: 2})
+	start := time.Unix(1000, 0)
+	rows := []map[string]string{
+		{"ts": "2026-10-19T08:00:00Z"},
+		{"ts": "2026-10-19T08:00:10Z"},
+		{"ts": "2026-10-19T08:00:04Z"},
+		{"ts": "2026-10-19T08:00:30Z"},
+	}
+	now := start
+	var waits []time.Duration
+	for _, r := range rows {
+		d, err := p.Delay(r, now)
+		if err != nil {
+			t.Fatalf("delay: %v", err)
+		}
+		waits = append(waits, d)
+		now = now.Add(d + time.Second)
+	}
+	// the second row is due 5s after the first, published 1s later
+	want := []time.Duration{0, 4 * time.Second, 0, 8 * time.Second}
+	for i := range want {
+		if waits[i] != want[i] {
+			t.Fatalf("waits %v, want %v", waits, want)
+		}
+	}
+	if _, err := p.Delay(map[string]string{"ts": "soon"}, now); err == nil {
+		t.Fatalf("expected timestamp error")
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	for _, s := range []string{"2026-10-19T08:00:00Z", "2026-10-19 08:00:00", "1792396800", "1792396800000"} {
+		got, err := steps.ParseTimestamp(s)
+		if err != nil || !got.Equal(want) {
+			t.Fatalf("%s: got %v %v", s, got, err)
+		}
+	}
+}
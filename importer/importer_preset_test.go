@@ -0,0 +1,100 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/marang/emqutiti/importer/steps"
+	"github.com/marang/emqutiti/ui"
+)
+
+func TestMatchPreset(t *testing.T) {
+	p := steps.WizardPrefs{Presets: map[string]steps.Preset{
+		"sensors": {Headers: []string{"id", "temp", "hum"}},
+		"meters":  {Headers: []string{"meter", "kwh"}},
+	}}
+	if name, ok := p.MatchPreset([]string{"id", "temp", "hum", "ts"}); !ok || name != "sensors" {
+		t.Fatalf("expected sensors, got %q %v", name, ok)
+	}
+	if name, ok := p.MatchPreset([]string{"id", "kwh", "x", "y"}); ok {
+		t.Fatalf("expected no match, got %q", name)
+	}
+	if name, _, err := p.FindPreset("auto", []string{"meter", "kwh"}); err != nil || name != "meters" {
+		t.Fatalf("expected meters, got %q %v", name, err)
+	}
+	if _, _, err := p.FindPreset("nope", nil); err == nil {
+		t.Fatalf("expected unknown preset error")
+	}
+}
+
+func TestWizardSavesAndSuggestsPreset(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.csv")
+	if err := os.WriteFile(first, []byte("a,b\n1,2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w := New(&mockPublisher{}, first)
+	loadFile(w)
+	if tf, ok := w.Base.Form.Fields[0].(*ui.TextField); ok {
+		tf.SetValue("aa:int")
+	}
+	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	w.Base.Tmpl.SetValue("dev/{aa}")
+	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	w.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	w.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("lab")})
+	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if _, ok := steps.LoadPrefs().Presets["lab"]; !ok {
+		t.Fatalf("expected preset lab to be saved")
+	}
+
+	second := filepath.Join(dir, "second.csv")
+	if err := os.WriteFile(second, []byte("a,b,c\n1,2,3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w2 := New(&mockPublisher{}, second)
+	loadFile(w2)
+	if _, ok := w2.current.(*steps.MapStep); !ok {
+		t.Fatalf("expected MapStep, got %T", w2.current)
+	}
+	if w2.Base.Preset.Value() != "lab" || w2.Base.Tmpl.Value() != "dev/{aa}" || w2.Base.Form.Fields[0].Value() != "aa:int" {
+		t.Fatalf("expected preset lab applied, got %q %q", w2.Base.Preset.Value(), w2.Base.Tmpl.Value())
+	}
+	if w2.Base.PresetNote != "preset lab matches the headers" {
+		t.Fatalf("unexpected preset note %q", w2.Base.PresetNote)
+	}
+}
+
+func TestRunHeadlessPreset(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(file, []byte("id,temp\n1,20.5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mapping := filepath.Join(t.TempDir(), "map.toml")
+	data := "template = \"other/{id}\"\n[presets.lab]\nheaders = [\"id\", \"temp\"]\ntemplate = \"lab/{id}\"\npublish = \"qos=1 retain=true\"\n[presets.lab.mapping]\ntemp = \"t:float\"\n"
+	if err := os.WriteFile(mapping, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pub := &flakyPublisher{}
+	var out bytes.Buffer
+	if _, err := Run(context.Background(), pub, Options{File: file, MappingFile: mapping, Preset: "auto"}, &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Join(pub.topics, ",") != "lab/1" || pub.qos != 1 || !pub.retain {
+		t.Fatalf("expected preset settings, got %v qos %d retain %v", pub.topics, pub.qos, pub.retain)
+	}
+	if !strings.Contains(out.String(), "using preset lab") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+	if _, err := Run(context.Background(), pub, Options{File: file, MappingFile: mapping, Preset: "missing"}, &out); err == nil {
+		t.Fatalf("expected unknown preset error")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/marang/emqutiti/importer/steps"
@@ -71,3 +72,148 @@ func TestRowToJSONNested(t *testing.T) {
 		t.Fatalf("got %+v", got)
 	}
 }
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestReadDelimitedVariants(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data []byte
+		opts string
+		want map[string]string
+	}{
+		{"tsv", "data.tsv", []byte("id\tmsg\n1\thello, world\n"), "", map[string]string{"id": "1", "msg": "hello, world"}},
+		{"semicolon detected", "data.csv", []byte("id;msg\n1;\"a;b\"\n"), "", map[string]string{"id": "1", "msg": "a;b"}},
+		{"single quote", "data.csv", []byte("id|msg\n1|'say \"hi\"|x'\n"), "quote=' delimiter=|", map[string]string{"id": "1", "msg": `say "hi"|x`}},
+		{"no quote", "data.csv", []byte("id,msg\n1,\"raw\n"), "quote=none", map[string]string{"id": "1", "msg": `"raw`}},
+		{"latin1 detected", "data.csv", []byte("id,city\n1,M\xfcnchen\n"), "", map[string]string{"id": "1", "city": "München"}},
+		{"utf-8 bom", "data.csv", []byte("\xef\xbb\xbfid,msg\n1,x\n"), "", map[string]string{"id": "1", "msg": "x"}},
+		{"utf-16le bom", "data.csv", []byte("\xff\xfei\x00d\x00\n\x007\x00\n\x00"), "", map[string]string{"id": "7"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := steps.ParseReadOptions(tt.opts)
+			if err != nil {
+				t.Fatalf("options: %v", err)
+			}
+			tbl, err := steps.ReadTable(writeFile(t, tt.file, tt.data), opts)
+			if err != nil {
+				t.Fatalf("read failed: %v", err)
+			}
+			if len(tbl.Rows) != 1 {
+				t.Fatalf("expected 1 row, got %d", len(tbl.Rows))
+			}
+			for k, v := range tt.want {
+				if tbl.Rows[0][k] != v {
+					t.Fatalf("%s: got %q want %q (row %v)", k, tbl.Rows[0][k], v, tbl.Rows[0])
+				}
+			}
+		})
+	}
+}
+
+func TestParseReadOptionsErrors(t *testing.T) {
+	for _, s := range []string{"delimiter=ab", "encoding=ebcdic", "colour=red", "quote=; delimiter=;"} {
+		if _, err := steps.ParseReadOptions(s); err == nil {
+			t.Fatalf("expected error for %q", s)
+		}
+	}
+}
+
+func TestReadJSONFlattens(t *testing.T) {
+	data := `[
+		{"id": 1, "meta": {"device": "d1", "tags": ["a", "b"]}, "ok": true},
+		{"id": 2.5, "meta": {"device": "d2", "tags": []}, "note": null}
+	]`
+	tbl, err := steps.ReadTable(writeFile(t, "data.json", []byte(data)), steps.ReadOptions{})
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	want := []string{"id", "meta.device", "meta.tags.0", "meta.tags.1", "ok", "meta.tags", "note"}
+	if strings.Join(tbl.Headers, ",") != strings.Join(want, ",") {
+		t.Fatalf("headers %v", tbl.Headers)
+	}
+	if tbl.Rows[0]["meta.tags.1"] != "b" || tbl.Rows[0]["ok"] != "true" || tbl.Rows[1]["id"] != "2.5" {
+		t.Fatalf("rows %v", tbl.Rows)
+	}
+	topic := steps.BuildTopic("devices/{meta.device}", tbl.Rows[1])
+	if topic != "devices/d2" {
+		t.Fatalf("got topic %s", topic)
+	}
+}
+
+func TestReadNDJSON(t *testing.T) {
+	data := "{\"id\":1,\"v\":{\"t\":20}}\n\n{\"id\":2,\"v\":{\"t\":21}}\r\n"
+	tbl, err := steps.ReadTable(writeFile(t, "data.ndjson", []byte(data)), steps.ReadOptions{})
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if len(tbl.Rows) != 2 || tbl.Rows[1]["v.t"] != "21" {
+		t.Fatalf("rows %v", tbl.Rows)
+	}
+	if _, err := steps.ReadTable(writeFile(t, "bad.jsonl", []byte("{\"id\":1}\n[1]\n")), steps.ReadOptions{}); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected line error, got %v", err)
+	}
+}
+
+func TestScanFileKeepsSample(t *testing.T) {
+	file := writeFile(t, "data.ndjson", []byte("{\"a\":1}\n{\"a\":2,\"b\":{\"c\":3}}\n{\"a\":3}\n"))
+	info, err := steps.ScanFile(file, steps.ReadOptions{}, 2)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if info.Rows != 3 || len(info.Sample) != 2 {
+		t.Fatalf("expected 3 rows and 2 samples, got %d %d", info.Rows, len(info.Sample))
+	}
+	if strings.Join(info.Headers, ",") != "a,b.c" {
+		t.Fatalf("headers %v", info.Headers)
+	}
+}
+
+func TestRowToJSONTyped(t *testing.T) {
+	row := map[string]string{"n": "42", "t": "21.5", "ok": "yes", "ts": "2026-10-19T08:00:00Z", "empty": "", "secret": "x", "name": "pump"}
+	mapping := map[string]string{
+		"n":      "count:int",
+		"t":      "temp.value:float",
+		"ok":     ":bool",
+		"ts":     "time:time=unix",
+		"empty":  ":float?",
+		"secret": "-",
+	}
+	data, err := steps.RowToJSON(row, mapping)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"count":42,"empty":null,"name":"pump","ok":true,"temp":{"value":21.5},"time":1792396800}`
+	if string(data) != want {
+		t.Fatalf("got %s", data)
+	}
+	if _, err := steps.RowToJSON(map[string]string{"n": "x"}, map[string]string{"n": ":int"}); err == nil || !strings.Contains(err.Error(), "n:") {
+		t.Fatalf("expected conversion error, got %v", err)
+	}
+	if _, err := steps.ParseFieldSpec("n", "n:decimal"); err == nil {
+		t.Fatalf("expected unknown type error")
+	}
+}
+
+func TestPayloadTemplate(t *testing.T) {
+	tmpl, err := steps.ParsePayloadTemplate(`weather,station={{.id}} temp={{.temp}},dev="{{index . "meta.device" | upper}}" {{unixnano .ts}}`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	out, err := steps.RenderPayload(tmpl, map[string]string{"id": "s1", "temp": "21.5", "meta.device": "d1", "ts": "2026-10-19T08:00:00Z"})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if string(out) != `weather,station=s1 temp=21.5,dev="D1" 1792396800000000000` {
+		t.Fatalf("got %s", out)
+	}
+}
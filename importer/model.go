@@ -26,6 +26,7 @@ func (m *Model) Init() tea.Cmd { return textinput.Blink }
 
 func (m *Model) Update(msg tea.Msg) tea.Cmd {
 	if km, ok := msg.(tea.KeyMsg); ok && km.Type == tea.KeyCtrlD {
+		m.Close()
 		return tea.Quit
 	}
 	switch v := msg.(type) {
@@ -45,6 +46,14 @@ func (m *Model) Update(msg tea.Msg) tea.Cmd {
 	return cmd
 }
 
+// Close stops a running import and remembers how far it got so it can be
+// resumed.
+func (m *Model) Close() {
+	if m != nil && m.Base != nil {
+		m.Base.Close()
+	}
+}
+
 // Focus satisfies Component.
 func (m *Model) Focus() tea.Cmd { return textinput.Blink }
 
@@ -2,7 +2,9 @@ package importer
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -23,6 +25,32 @@ func (m *errPublisher) Publish(topic string, qos byte, retained bool, payload in
 	return errors.New("fail")
 }
 
+// runCmd feeds the messages of cmd back into the wizard.
+func runCmd(w *Model, cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		for _, c := range batch {
+			if c != nil {
+				if mm := c(); mm != nil {
+					w.Update(mm)
+				}
+			}
+		}
+		return
+	}
+	if msg != nil {
+		w.Update(msg)
+	}
+}
+
+// loadFile confirms the file step and waits for the scan.
+func loadFile(w *Model) {
+	runCmd(w, w.Update(tea.KeyMsg{Type: tea.KeyEnter}))
+}
+
 // Test wizard progresses through file, map, template, and publish steps.
 func TestModelStepProgression(t *testing.T) {
 	t.Setenv("HOME", t.TempDir())
@@ -41,7 +69,7 @@ func TestModelStepProgression(t *testing.T) {
 	if _, ok := w.current.(*steps.FileStep); !ok {
 		t.Fatalf("expected FileStep")
 	}
-	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	loadFile(w)
 	if _, ok := w.current.(*steps.MapStep); !ok {
 		t.Fatalf("expected MapStep")
 	}
@@ -92,7 +120,7 @@ func TestModelFileStep(t *testing.T) {
 	f.Close()
 
 	w := New(&mockPublisher{}, f.Name())
-	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	loadFile(w)
 	if _, ok := w.current.(*steps.MapStep); !ok {
 		t.Fatalf("expected MapStep")
 	}
@@ -114,12 +142,12 @@ func TestModelMapNavigation(t *testing.T) {
 	f.Close()
 
 	w := New(&mockPublisher{}, f.Name())
-	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	loadFile(w)
 	w.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
 	if _, ok := w.current.(*steps.FileStep); !ok {
 		t.Fatalf("expected FileStep")
 	}
-	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	loadFile(w)
 	w.Update(tea.KeyMsg{Type: tea.KeyCtrlN})
 	if _, ok := w.current.(*steps.TemplateStep); !ok {
 		t.Fatalf("expected TemplateStep")
@@ -139,7 +167,7 @@ func TestModelReviewPublish(t *testing.T) {
 	f.Close()
 
 	w := New(&mockPublisher{}, f.Name())
-	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	loadFile(w)
 	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
 	w.Base.Tmpl.SetValue("topic/{a}")
 	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
@@ -179,13 +207,14 @@ func TestModelPublishError(t *testing.T) {
 		t.Fatalf("temp file: %v", err)
 	}
 	defer os.Remove(f.Name())
+	defer os.Remove(steps.RejectsPath(f.Name()))
 	if _, err := f.WriteString("a,b\n1,2\n"); err != nil {
 		t.Fatalf("write: %v", err)
 	}
 	f.Close()
 
 	w := New(&errPublisher{}, f.Name())
-	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	loadFile(w)
 	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
 	w.Base.Tmpl.SetValue("topic/{a}")
 	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
@@ -227,7 +256,7 @@ func TestSettingsRoundTrip(t *testing.T) {
 	f.Close()
 
 	w := New(&mockPublisher{}, f.Name())
-	w.Update(tea.KeyMsg{Type: tea.KeyEnter}) // load file -> stepMap
+	loadFile(w) // load file -> stepMap
 	if tf, ok := w.Base.Form.Fields[0].(*ui.TextField); ok {
 		tf.SetValue("aa")
 	}
@@ -237,7 +266,7 @@ func TestSettingsRoundTrip(t *testing.T) {
 	w.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
 
 	w2 := New(&mockPublisher{}, f.Name())
-	w2.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	loadFile(w2)
 	if v := w2.Base.Form.Fields[0].Value(); v != "aa" {
 		t.Fatalf("expected mapping aa, got %q", v)
 	}
@@ -245,3 +274,109 @@ func TestSettingsRoundTrip(t *testing.T) {
 		t.Fatalf("expected template topic/{aa}, got %q", v)
 	}
 }
+
+type recordPublisher struct{ topics []string }
+
+func (r *recordPublisher) Publish(topic string, qos byte, retained bool, payload interface{}) error {
+	r.topics = append(r.topics, topic)
+	return nil
+}
+
+func TestImportResume(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var b strings.Builder
+	b.WriteString("n\n")
+	for i := 0; i < 250; i++ {
+		fmt.Fprintf(&b, "%d\n", i)
+	}
+	file := filepath.Join(t.TempDir(), "rows.csv")
+	if err := os.WriteFile(file, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := New(&mockPublisher{}, file)
+	loadFile(w)
+	if w.Base.Total != 250 || len(w.Base.Sample) != 3 {
+		t.Fatalf("expected 250 rows and 3 samples, got %d %d", w.Base.Total, len(w.Base.Sample))
+	}
+	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	w.Base.Tmpl.SetValue("t/{n}")
+	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	w.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	for i := 0; i < 150; i++ {
+		w.Update(steps.PublishMsg{Run: w.Base.Run})
+	}
+	if got := steps.ResumeOffset(file); got != 100 {
+		t.Fatalf("expected periodic offset 100, got %d", got)
+	}
+	w.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if _, ok := w.current.(*steps.ReviewStep); !ok || w.Base.Resume != 150 {
+		t.Fatalf("expected review with resume 150, got %T %d", w.current, w.Base.Resume)
+	}
+
+	pub := &recordPublisher{}
+	w2 := New(pub, file)
+	loadFile(w2)
+	if w2.Base.Resume != 150 {
+		t.Fatalf("expected resume offset 150, got %d", w2.Base.Resume)
+	}
+	w2.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	w2.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	runCmd(w2, w2.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}}))
+	if len(pub.topics) != 1 || pub.topics[0] != "t/150" {
+		t.Fatalf("expected to resume at t/150, got %v", pub.topics)
+	}
+	for !w2.Base.Finished {
+		w2.Update(steps.PublishMsg{Run: w2.Base.Run})
+	}
+	if w2.Base.Index != 250 {
+		t.Fatalf("expected 250 rows processed, got %d", w2.Base.Index)
+	}
+	if got := steps.ResumeOffset(file); got != 0 {
+		t.Fatalf("expected offset cleared, got %d", got)
+	}
+}
+
+func TestResumeOffsetIgnoresChangedFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "rows.csv")
+	if err := os.WriteFile(file, []byte("n\n1\n2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := steps.SaveProgress(file, 1); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if got := steps.ResumeOffset(file); got != 1 {
+		t.Fatalf("expected offset 1, got %d", got)
+	}
+	if err := os.WriteFile(file, []byte("n\n1\n2\n3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := steps.ResumeOffset(file); got != 0 {
+		t.Fatalf("expected changed file to reset offset, got %d", got)
+	}
+}
+
+func TestMapStepRejectsInvalidType(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "rows.csv")
+	if err := os.WriteFile(file, []byte("a,b\n1,2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w := New(&mockPublisher{}, file)
+	loadFile(w)
+	if tf, ok := w.Base.Form.Fields[1].(*ui.TextField); ok {
+		tf.SetValue("b:decimal")
+	}
+	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if _, ok := w.current.(*steps.MapStep); !ok || w.Base.Form.Focus != 1 {
+		t.Fatalf("expected to stay on the invalid field, got %T focus %d", w.current, w.Base.Form.Focus)
+	}
+	if tf, ok := w.Base.Form.Fields[1].(*ui.TextField); ok {
+		tf.SetValue("b:int")
+	}
+	w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if _, ok := w.current.(*steps.TemplateStep); !ok {
+		t.Fatalf("expected TemplateStep, got %T", w.current)
+	}
+}
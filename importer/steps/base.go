@@ -2,12 +2,15 @@ package steps
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"math"
 	"math/rand"
 	"os"
 	"path/filepath"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/BurntSushi/toml"
@@ -19,10 +22,45 @@ import (
 
 type Base struct {
 	File    textinput.Model
+	Options textinput.Model
 	Headers []string
 	Form    ui.Form
 	Tmpl    textinput.Model
-	Rows    []map[string]string
+	// PubOpts holds the publish options entered with the template,
+	// Publish their parsed form.
+	PubOpts textinput.Model
+	Publish PublishOptions
+	pacer   *Pacer
+	// Payload is an optional Go template replacing the JSON payload.
+	Payload     textinput.Model
+	payloadTmpl *template.Template
+	// Preset names the preset the settings came from; PresetNote tells
+	// the mapping step about it.
+	Preset     textinput.Model
+	PresetNote string
+
+	// Path and ReadOpts locate the file whose rows are streamed while
+	// publishing. Only Sample is kept in memory; Total is the row count.
+	Path     string
+	ReadOpts ReadOptions
+	Sample   []map[string]string
+	Total    int
+	// Start is the row publishing began at and Resume the offset an
+	// interrupted import of Path reached.
+	Start  int
+	Resume int
+	stream *RowReader
+	// Failed counts rows that could not be published; they and skipped
+	// rows go to Rejects. Aborted is set when Failed hit the limit.
+	Failed  int
+	Rejects *Rejects
+	Aborted bool
+
+	// Run counts the publishing runs; cancel stops the waits and retries
+	// of the current one.
+	Run    int
+	ctx    context.Context
+	cancel context.CancelFunc
 
 	Index       int
 	Progress    progress.Model
@@ -42,20 +80,38 @@ type Base struct {
 
 func NewBase(client Publisher, path string) *Base {
 	ti := textinput.New()
-	ti.Placeholder = "CSV file"
+	ti.Placeholder = "CSV, TSV, JSON or NDJSON file"
 	ti.Focus()
 	ti.SetValue(path)
+	opts := textinput.New()
+	opts.Prompt = "Options: "
+	opts.Placeholder = "delimiter=; quote=' encoding=latin1 (detected when empty)"
 	tmpl := textinput.New()
 	tmpl.Placeholder = "Topic template"
+	pub := textinput.New()
+	pub.Prompt = "Publish: "
+	pub.Placeholder = "rate=50 pace=timestamp speed=2 qos=1 retain=true retries=3 max-errors=10"
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	hv := ui.NewHistoryView(50, 10)
 	prefs := LoadPrefs()
 	if prefs.Template != "" {
 		tmpl.SetValue(prefs.Template)
 	}
+	pub.SetValue(prefs.Publish)
+	payload := textinput.New()
+	payload.Prompt = "Payload: "
+	payload.Placeholder = `Go template, e.g. weather,station={{.id}} temp={{.temp}} {{unixnano .ts}} (JSON when empty)`
+	payload.SetValue(prefs.Payload)
+	preset := textinput.New()
+	preset.Prompt = "Preset: "
+	preset.Placeholder = "name of a saved preset (suggested from the headers when empty)"
 	return &Base{
 		File:     ti,
+		Options:  opts,
 		Tmpl:     tmpl,
+		PubOpts:  pub,
+		Payload:  payload,
+		Preset:   preset,
 		Client:   client,
 		Progress: progress.New(progress.WithDefaultGradient()),
 		History:  hv,
@@ -67,6 +123,9 @@ func NewBase(client Publisher, path string) *Base {
 type WizardPrefs struct {
 	Mapping  map[string]string `toml:"mapping"`
 	Template string            `toml:"template"`
+	Publish  string            `toml:"publish"`
+	Payload  string            `toml:"payload"`
+	Presets  map[string]Preset `toml:"presets,omitempty"`
 }
 
 func configFile() (string, error) {
@@ -86,17 +145,19 @@ func LoadPrefs() WizardPrefs {
 	if err != nil {
 		return WizardPrefs{Mapping: map[string]string{}}
 	}
+	cfg, _ := LoadPrefsFile(fp)
+	return cfg
+}
+
+// LoadPrefsFile reads settings in the importer.toml format, e.g. a mapping
+// file passed to a headless import.
+func LoadPrefsFile(path string) (WizardPrefs, error) {
 	var cfg WizardPrefs
-	if _, err := toml.DecodeFile(fp, &cfg); err != nil {
-		if cfg.Mapping == nil {
-			cfg.Mapping = map[string]string{}
-		}
-		return cfg
-	}
+	_, err := toml.DecodeFile(path, &cfg)
 	if cfg.Mapping == nil {
 		cfg.Mapping = map[string]string{}
 	}
-	return cfg
+	return cfg, err
 }
 
 func SavePrefs(p WizardPrefs) error {
@@ -141,18 +202,35 @@ func (b *Base) mapping() map[string]string {
 func renameFields(row map[string]string, mapping map[string]string) map[string]string {
 	out := map[string]string{}
 	for k, v := range row {
-		if name, ok := mapping[k]; ok && strings.TrimSpace(name) != "" {
+		out[k] = v
+		if name := fieldName(k, mapping[k]); name != k {
 			out[name] = v
-			if name != k {
-				out[k] = v
-			}
-		} else {
-			out[k] = v
 		}
 	}
 	return out
 }
 
+// buildForm creates the mapping fields for the headers, filled from mapping.
+func (b *Base) buildForm(mapping map[string]string) {
+	var fields []ui.Field
+	for _, k := range b.Headers {
+		fi := ui.NewTextField(k, "")
+		if v, ok := mapping[k]; ok {
+			fi.SetValue(v)
+		}
+		fields = append(fields, fi)
+	}
+	b.Form = ui.Form{Fields: fields, Focus: 0}
+	if len(fields) > 0 {
+		b.Form.ApplyFocus()
+	}
+}
+
+// encoder encodes rows with the settings entered in the wizard.
+func (b *Base) encoder() Encoder {
+	return Encoder{Template: b.Tmpl.Value(), Mapping: b.mapping(), Payload: b.payloadTmpl}
+}
+
 func sampleSize(total int) int {
 	if total <= 5 {
 		return total
@@ -167,42 +245,200 @@ func sampleSize(total int) int {
 	return size
 }
 
+// startPublish streams the rows from offset from, publishing them or, for
+// dry runs, only collecting the output.
+func (b *Base) startPublish(dry bool, from int) tea.Cmd {
+	b.closeStream()
+	b.DryRun = dry
+	b.Start = from
+	b.Index = from
+	b.Published = nil
+	b.Finished = false
+	b.Failed = 0
+	b.Aborted = false
+	b.History.GotoTop()
+	r, err := OpenRows(b.Path, b.ReadOpts)
+	if err == nil {
+		err = r.Skip(from)
+	}
+	if err != nil {
+		if r != nil {
+			r.Close()
+		}
+		b.Published = []string{fmt.Sprintf("error reading %s: %v", b.Path, err)}
+		b.Finished = true
+		return nil
+	}
+	b.stream = r
+	b.Run++
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	b.Rejects = nil
+	if !dry {
+		b.Rejects = NewRejects(b.Path, b.Headers, from > 0)
+	}
+	b.pacer = nil
+	if b.Publish.Rate > 0 || b.Publish.PaceBy != "" {
+		b.pacer = NewPacer(b.Publish)
+	}
+	return tea.Batch(b.Progress.SetPercent(b.percent()), b.nextPublishCmd())
+}
+
+func (b *Base) percent() float64 {
+	if b.Total == 0 {
+		return 1
+	}
+	return min(float64(b.Index)/float64(b.Total), 1)
+}
+
+// nextPublishCmd reads the next row and publishes it. It returns nil once
+// the file has no more rows.
 func (b *Base) nextPublishCmd() tea.Cmd {
-	if b.Index >= len(b.Rows) {
+	if b.stream == nil {
 		return nil
 	}
-	row := b.Rows[b.Index]
-	mapping := b.mapping()
-	topic := BuildTopic(b.Tmpl.Value(), renameFields(row, mapping))
-	payload, _ := RowToJSON(row, mapping)
-	limit := b.SampleLimit
-	if limit == 0 {
-		limit = sampleSize(len(b.Rows))
-		b.SampleLimit = limit
+	row, err := b.stream.Next()
+	if err != nil {
+		if err != io.EOF {
+			b.keep(fmt.Sprintf("error reading row %d: %v", b.Index+1, err))
+			b.History.SetLines(b.Published)
+		}
+		return nil
+	}
+	topic, payload, err := b.encoder().Encode(row)
+	if err != nil {
+		b.keep(fmt.Sprintf("row %d skipped: %v", b.Index+1, err))
+		b.reject(row)
+		b.History.SetLines(b.Published)
+		run := b.Run
+		return func() tea.Msg { return PublishMsg{Run: run} }
+	}
+	var wait time.Duration
+	if b.pacer != nil && !b.DryRun {
+		wait, err = b.pacer.Delay(row, time.Now())
+		if err != nil {
+			b.keep(fmt.Sprintf("row %d not paced: %v", b.Index+1, err))
+		}
 	}
-	i := b.Index
+	ctx, run, dry, opts, client := b.ctx, b.Run, b.DryRun, b.Publish, b.Client
 	return func() tea.Msg {
-		line := fmt.Sprintf("%s -> %s", topic, string(payload))
-		if b.DryRun {
-			b.Published = append(b.Published, line)
-		} else {
-			if err := b.Client.Publish(topic, 0, false, payload); err != nil {
-				errLine := fmt.Sprintf("error publishing %s: %v", topic, err)
-				b.Published = append(b.Published, errLine)
-			} else {
-				if len(b.Published) < limit {
-					b.Published = append(b.Published, line)
-				} else if r := b.Rnd.Intn(i + 1); r < limit {
-					b.Published[r] = line
-				}
-			}
+		if !sleep(ctx, wait) {
+			return nil
+		}
+		msg := PublishMsg{Run: run, Line: fmt.Sprintf("%s -> %s", topic, string(payload))}
+		if dry {
+			return msg
+		}
+		err := opts.Retry(func() error {
+			return client.Publish(topic, opts.QoS, opts.Retain, payload)
+		}, func(d time.Duration) bool { return sleep(ctx, d) })
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			msg.Line = fmt.Sprintf("error publishing %s: %v", topic, err)
+			msg.Failed = row
 		}
+		return msg
+	}
+}
+
+// record adds the outcome of a published row.
+func (b *Base) record(msg PublishMsg) {
+	if msg.Failed != nil {
+		b.Failed++
+		b.reject(msg.Failed)
+	}
+	if msg.Line != "" {
+		b.keep(msg.Line)
 		b.History.SetLines(b.Published)
 		b.History.GotoBottom()
-		return PublishMsg{}
 	}
 }
 
+// sleep waits for d, reporting false when ctx is cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// reject writes row to the rejected rows file.
+func (b *Base) reject(row map[string]string) {
+	if b.Rejects == nil {
+		return
+	}
+	if err := b.Rejects.Add(row); err != nil {
+		b.keep(fmt.Sprintf("error writing %s: %v", b.Rejects.Path, err))
+	}
+}
+
+// keep adds line to the sampled output, replacing a random earlier line once
+// SampleLimit lines are kept so memory stays bounded for large files.
+func (b *Base) keep(line string) {
+	limit := b.SampleLimit
+	if limit == 0 {
+		limit = sampleSize(b.Total)
+		b.SampleLimit = limit
+	}
+	if len(b.Published) < limit {
+		b.Published = append(b.Published, line)
+	} else if r := b.Rnd.Intn(b.Index - b.Start + 1); r < limit {
+		b.Published[r] = line
+	}
+}
+
+// advance counts a processed row, saving the offset from time to time so an
+// interrupted import can resume.
+func (b *Base) advance() {
+	b.Index++
+	if !b.DryRun && b.Index%progressEvery == 0 {
+		SaveProgress(b.Path, b.Index)
+	}
+}
+
+// finish ends publishing and forgets the resume offset of a completed import.
+func (b *Base) finish() {
+	b.Finished = true
+	b.closeStream()
+	if b.Aborted {
+		SaveProgress(b.Path, b.Index)
+		b.Resume = b.Index
+		return
+	}
+	if !b.DryRun {
+		ClearProgress(b.Path)
+		b.Resume = 0
+	}
+}
+
+// Close stops an import in progress, remembering its offset for a later
+// resume.
+func (b *Base) Close() {
+	if b.stream != nil && !b.DryRun && !b.Finished {
+		SaveProgress(b.Path, b.Index)
+	}
+	b.closeStream()
+}
+
+func (b *Base) closeStream() {
+	if b.cancel != nil {
+		b.cancel()
+	}
+	if b.stream != nil {
+		b.stream.Close()
+		b.stream = nil
+	}
+	b.Rejects.Close()
+}
+
 // spacedLines inserts blank lines between each provided line for readability.
 func spacedLines(lines []string) []string {
 	out := make([]string, 0, len(lines)*2)
@@ -42,7 +42,7 @@ func (s *DoneStep) View(bw, wrap int) string {
 		out = ansi.Wrap(out, wrap, " ") + "\n[ctrl+p] back  [q] quit"
 		return ui.LegendBox(out, "Dry Run", bw, 0, ui.ColGreen, true, s.History.ScrollPercent())
 	} else if s.Finished {
-		msg := fmt.Sprintf("Published %d messages\n[ctrl+p] back  [q] quit", len(s.Rows))
+		msg := fmt.Sprintf("Published %d messages\n[ctrl+p] back  [q] quit", s.Index-s.Start)
 		msg = ansi.Wrap(msg, wrap, " ")
 		return ui.LegendBox(msg, "Import", bw, 0, ui.ColBlue, true, -1)
 	}
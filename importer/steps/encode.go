@@ -1,58 +1,42 @@
 package steps
 
 import (
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
-	"io"
-	"os"
-	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"text/template"
 )
 
-// ReadFile reads a CSV file and returns rows as maps keyed by header name.
+// ReadFile reads an import file with detected settings and returns rows as
+// maps keyed by header name.
 func ReadFile(path string) ([]map[string]string, error) {
-	ext := strings.ToLower(filepath.Ext(path))
-	switch ext {
-	case ".csv":
-		return readCSV(path)
-	default:
-		return nil, fmt.Errorf("unsupported file type: %s", ext)
-	}
-}
-
-func readCSV(path string) ([]map[string]string, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-	r := csv.NewReader(f)
-	headers, err := r.Read()
+	t, err := ReadTable(path, ReadOptions{})
 	if err != nil {
 		return nil, err
 	}
-	var rows []map[string]string
-	for {
-		rec, err := r.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, err
-		}
-		row := map[string]string{}
-		for i, h := range headers {
-			if i < len(rec) {
-				row[h] = rec[i]
-			} else {
-				row[h] = ""
-			}
-		}
-		rows = append(rows, row)
+	return t.Rows, nil
+}
+
+// Encoder turns rows into MQTT messages. The wizard and headless imports
+// share it.
+type Encoder struct {
+	Template string
+	Mapping  map[string]string
+	// Payload replaces the JSON payload when set.
+	Payload *template.Template
+}
+
+// Encode returns the topic and payload of row.
+func (e Encoder) Encode(row map[string]string) (string, []byte, error) {
+	topic := BuildTopic(e.Template, renameFields(row, e.Mapping))
+	if e.Payload != nil {
+		p, err := RenderPayload(e.Payload, renameFields(row, e.Mapping))
+		return topic, p, err
 	}
-	return rows, nil
+	p, err := RowToJSON(row, e.Mapping)
+	return topic, p, err
 }
 
 var placeholder = regexp.MustCompile(`\{([^}]+)\}`)
@@ -68,29 +52,43 @@ func BuildTopic(tmpl string, fields map[string]string) string {
 	})
 }
 
-// RowToJSON converts a row map to a JSON payload using the provided field mapping.
+// RowToJSON converts a row map to a JSON payload using the provided field
+// mapping. Mappings may carry a type, see FieldSpec.
 func RowToJSON(row map[string]string, mapping map[string]string) ([]byte, error) {
+	keys := make([]string, 0, len(row))
+	for k := range row {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 	out := map[string]interface{}{}
-	for k, v := range row {
-		path := k
-		if mapped, ok := mapping[k]; ok && strings.TrimSpace(mapped) != "" {
-			path = mapped
+	for _, k := range keys {
+		f, err := ParseFieldSpec(k, mapping[k])
+		if err != nil {
+			return nil, err
+		}
+		if f.Drop {
+			continue
+		}
+		v, err := f.Convert(row[k])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
 		}
-		setNested(out, strings.Split(path, "."), v)
+		setNested(out, strings.Split(f.Name, "."), v)
 	}
 	return json.Marshal(out)
 }
 
-func setNested(m map[string]interface{}, path []string, value string) {
+func setNested(m map[string]interface{}, path []string, value interface{}) {
 	for i, p := range path {
 		if i == len(path)-1 {
 			m[p] = value
 			return
 		}
-		if _, ok := m[p]; !ok {
-			m[p] = map[string]interface{}{}
+		child, ok := m[p].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			m[p] = child
 		}
-		child, _ := m[p].(map[string]interface{})
 		m = child
 	}
 }
@@ -0,0 +1,147 @@
+package steps
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// FieldSpec is the parsed mapping of a column. The mapping step accepts
+// `name`, `name:type` or `:type` where type is string, int, float, bool or
+// time with an optional `=format` (unix, unixms, unixnano, rfc3339 or a Go
+// layout). A `?` after the type emits null for empty values and a single `-`
+// drops the column from the payload.
+type FieldSpec struct {
+	Name     string
+	Type     string
+	Format   string
+	Nullable bool
+	Drop     bool
+}
+
+// ParseFieldSpec parses the mapping s of column. An empty name keeps the
+// column name.
+func ParseFieldSpec(column, s string) (FieldSpec, error) {
+	s = strings.TrimSpace(s)
+	if s == "-" {
+		return FieldSpec{Name: column, Drop: true}, nil
+	}
+	name, typ, _ := strings.Cut(s, ":")
+	f := FieldSpec{Name: strings.TrimSpace(name)}
+	if f.Name == "" {
+		f.Name = column
+	}
+	typ, f.Format, _ = strings.Cut(typ, "=")
+	typ = strings.TrimSpace(typ)
+	if t, ok := strings.CutSuffix(typ, "?"); ok {
+		typ, f.Nullable = t, true
+	}
+	switch typ {
+	case "", "string", "int", "float", "bool":
+		if f.Format != "" {
+			return f, fmt.Errorf("%s: format only applies to time", column)
+		}
+	case "time":
+	default:
+		return f, fmt.Errorf("%s: unknown type %q", column, typ)
+	}
+	f.Type = typ
+	return f, nil
+}
+
+// Convert coerces v to the type of the field.
+func (f FieldSpec) Convert(v string) (any, error) {
+	if f.Nullable && strings.TrimSpace(v) == "" {
+		return nil, nil
+	}
+	switch f.Type {
+	case "int":
+		return strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	case "float":
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	case "bool":
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "yes", "y", "on":
+			return true, nil
+		case "no", "n", "off":
+			return false, nil
+		}
+		return strconv.ParseBool(strings.TrimSpace(v))
+	case "time":
+		t, err := ParseTimestamp(v)
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToLower(f.Format) {
+		case "", "rfc3339":
+			return t.Format(time.RFC3339Nano), nil
+		case "unix":
+			return t.Unix(), nil
+		case "unixms":
+			return t.UnixMilli(), nil
+		case "unixnano":
+			return t.UnixNano(), nil
+		default:
+			return t.Format(f.Format), nil
+		}
+	default:
+		return v, nil
+	}
+}
+
+// fieldName returns the name column is published as.
+func fieldName(column, mapping string) string {
+	f, err := ParseFieldSpec(column, mapping)
+	if err != nil {
+		return column
+	}
+	return f.Name
+}
+
+// payloadFuncs are available in payload templates.
+var payloadFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"xml": func(s string) (string, error) {
+		var b bytes.Buffer
+		err := xml.EscapeText(&b, []byte(s))
+		return b.String(), err
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"unix": func(s string) (int64, error) {
+		t, err := ParseTimestamp(s)
+		return t.Unix(), err
+	},
+	"unixms": func(s string) (int64, error) {
+		t, err := ParseTimestamp(s)
+		return t.UnixMilli(), err
+	},
+	"unixnano": func(s string) (int64, error) {
+		t, err := ParseTimestamp(s)
+		return t.UnixNano(), err
+	},
+}
+
+// ParsePayloadTemplate parses a Go template rendering a row as payload,
+// e.g. line protocol or XML. Fields are referenced by column or mapped
+// name: `{{.temp}}` or `{{index . "meta.device"}}`.
+func ParsePayloadTemplate(s string) (*template.Template, error) {
+	return template.New("payload").Funcs(payloadFuncs).Option("missingkey=zero").Parse(s)
+}
+
+// RenderPayload executes t with the fields of a row.
+func RenderPayload(t *template.Template, fields map[string]string) ([]byte, error) {
+	var b bytes.Buffer
+	if err := t.Execute(&b, fields); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -3,58 +3,135 @@ package steps
 import (
 	"strings"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/marang/emqutiti/ui"
 )
 
-// FileStep handles selecting the file to import.
-type FileStep struct{ *Base }
+// previewRows is how many rows the review step previews.
+const previewRows = 3
+
+// ScanMsg carries the result of the pre-pass over the selected file.
+type ScanMsg struct {
+	Path string
+	Opts ReadOptions
+	Info FileInfo
+	Err  error
+}
+
+// FileStep handles selecting the file to import and how to parse it.
+type FileStep struct {
+	*Base
+	scanning bool
+}
 
 func NewFileStep(b *Base) *FileStep {
 	b.Current = File
+	b.Options.Blur()
+	b.Preset.Blur()
 	b.File.Focus()
 	return &FileStep{Base: b}
 }
 
+// inputs lists the step's inputs in focus order.
+func (s *FileStep) inputs() []*textinput.Model {
+	return []*textinput.Model{&s.File, &s.Options, &s.Preset}
+}
+
+// scanCmd counts the rows of the file in the background so large files do
+// not block the UI.
+func scanCmd(path string, opts ReadOptions) tea.Cmd {
+	return func() tea.Msg {
+		info, err := ScanFile(path, opts, previewRows)
+		return ScanMsg{Path: path, Opts: opts, Info: info, Err: err}
+	}
+}
+
 func (s *FileStep) Update(msg tea.Msg) (Step, tea.Cmd) {
+	if sm, ok := msg.(ScanMsg); ok {
+		return s.loaded(sm)
+	}
+	if s.scanning {
+		return s, nil
+	}
+	inputs := s.inputs()
+	focus := 0
+	for i, in := range inputs {
+		if in.Focused() {
+			focus = i
+		}
+	}
+	if km, ok := msg.(tea.KeyMsg); ok && (km.Type == tea.KeyTab || km.Type == tea.KeyShiftTab) {
+		inputs[focus].Blur()
+		if km.Type == tea.KeyTab {
+			focus = (focus + 1) % len(inputs)
+		} else {
+			focus = (focus + len(inputs) - 1) % len(inputs)
+		}
+		return s, inputs[focus].Focus()
+	}
 	var cmd tea.Cmd
-	s.File, cmd = s.File.Update(msg)
+	*inputs[focus], cmd = inputs[focus].Update(msg)
 	if km, ok := msg.(tea.KeyMsg); ok && (km.Type == tea.KeyEnter || km.Type == tea.KeyCtrlN) {
 		path := strings.TrimSpace(s.File.Value())
 		if path == "" {
 			return s, cmd
 		}
-		rows, err := ReadFile(path)
+		opts, err := ParseReadOptions(s.Options.Value())
 		if err != nil {
-			s.File.SetValue(path + " (" + err.Error() + ")")
+			s.Options.SetValue(s.Options.Value() + " (" + err.Error() + ")")
 			return s, cmd
 		}
-		if len(rows) == 0 {
-			s.File.SetValue(path + " (no data)")
-			return s, cmd
+		s.scanning = true
+		return s, tea.Batch(cmd, scanCmd(path, opts))
+	}
+	return s, cmd
+}
+
+// loaded moves on to the mapping once the file was scanned.
+func (s *FileStep) loaded(sm ScanMsg) (Step, tea.Cmd) {
+	s.scanning = false
+	if sm.Err != nil {
+		s.File.SetValue(sm.Path + " (" + sm.Err.Error() + ")")
+		return s, nil
+	}
+	if sm.Info.Rows == 0 {
+		s.File.SetValue(sm.Path + " (no data)")
+		return s, nil
+	}
+	s.Path = sm.Path
+	s.ReadOpts = sm.Opts
+	s.Sample = sm.Info.Sample
+	s.Total = sm.Info.Rows
+	s.Resume = ResumeOffset(sm.Path)
+	s.Headers = sm.Info.Headers
+	s.buildForm(s.Prefs.Mapping)
+	s.PresetNote = ""
+	name := strings.TrimSpace(s.Preset.Value())
+	if name == "" {
+		if match, ok := s.Prefs.MatchPreset(s.Headers); ok {
+			name = match
+			s.PresetNote = "preset " + match + " matches the headers"
 		}
-		s.Rows = rows
-		s.Headers = nil
-		var fields []ui.Field
-		for k := range rows[0] {
-			s.Headers = append(s.Headers, k)
-			fi := ui.NewTextField(k, "")
-			if v, ok := s.Prefs.Mapping[k]; ok {
-				fi.SetValue(v)
-			}
-			fields = append(fields, fi)
+	}
+	if name != "" {
+		if err := s.applyPreset(name); err != nil {
+			s.Preset.SetValue(name + " (" + err.Error() + ")")
+			return s, nil
 		}
-		if len(fields) > 0 {
-			s.Form = ui.Form{Fields: fields, Focus: 0}
-			s.Form.ApplyFocus()
+		if s.PresetNote == "" {
+			s.PresetNote = "preset " + name + " applied"
 		}
-		return NewMapStep(s.Base), cmd
 	}
-	return s, cmd
+	return NewMapStep(s.Base), nil
 }
 
 func (s *FileStep) View(bw, _ int) string {
-	content := s.File.View() + "\n[enter] load file  [ctrl+n] next"
+	help := "[enter] load file  [tab] options/preset  [ctrl+n] next"
+	if s.scanning {
+		help = "Counting rows..."
+	}
+	content := s.File.View() + "\n" + s.Options.View() + "\n" + s.Preset.View() + "\n" + help
 	return ui.LegendBox(content, "Import", bw, 0, ui.ColBlue, true, -1)
 }
@@ -12,7 +12,10 @@ import (
 )
 
 // MapStep handles mapping columns to fields.
-type MapStep struct{ *Base }
+type MapStep struct {
+	*Base
+	err string
+}
 
 func NewMapStep(b *Base) *MapStep {
 	b.Current = Map
@@ -29,7 +32,10 @@ func (s *MapStep) Update(msg tea.Msg) (Step, tea.Cmd) {
 		case constants.KeyTab, constants.KeyShiftTab, constants.KeyUp, constants.KeyDown, constants.KeyK, constants.KeyJ:
 			s.Form.CycleFocus(ev)
 		case constants.KeyCtrlN:
-			return NewTemplateStep(s.Base), nil
+			if s.validate() {
+				return NewTemplateStep(s.Base), nil
+			}
+			return s, nil
 		case constants.KeyCtrlP:
 			return NewFileStep(s.Base), nil
 		}
@@ -42,12 +48,26 @@ func (s *MapStep) Update(msg tea.Msg) (Step, tea.Cmd) {
 	}
 	s.Form.ApplyFocus()
 	cmd := s.Form.Fields[s.Form.Focus].Update(msg)
-	if km, ok := msg.(tea.KeyMsg); ok && km.Type == tea.KeyEnter {
+	if km, ok := msg.(tea.KeyMsg); ok && km.Type == tea.KeyEnter && s.validate() {
 		return NewTemplateStep(s.Base), cmd
 	}
 	return s, cmd
 }
 
+// validate checks the typed mappings and focuses the first invalid one.
+func (s *MapStep) validate() bool {
+	for i, h := range s.Headers {
+		if _, err := ParseFieldSpec(h, s.Form.Fields[i].Value()); err != nil {
+			s.Form.Focus = i
+			s.Form.ApplyFocus()
+			s.err = err.Error()
+			return false
+		}
+	}
+	s.err = ""
+	return true
+}
+
 func (s *MapStep) View(bw, _ int) string {
 	colw := 0
 	for _, h := range s.Headers {
@@ -64,6 +84,13 @@ func (s *MapStep) View(bw, _ int) string {
 		padding := strings.Repeat(" ", colw-lipgloss.Width(h))
 		fmt.Fprintf(&b, "%s%s : %s\n", padding, label, s.Form.Fields[i].View())
 	}
-	b.WriteString("\nUse a.b to nest fields\n[enter] continue  [ctrl+n] next  [ctrl+p] back")
+	if s.PresetNote != "" {
+		b.WriteString("\n" + ui.InfoStyle.Render(s.PresetNote) + "\n")
+	}
+	if s.err != "" {
+		b.WriteString("\n" + ui.FormError.Render(s.err) + "\n")
+	}
+	b.WriteString("\nUse a.b to nest fields, name:int|float|bool|time=unix to type them,\n? after the type for null on empty, - to drop a column")
+	b.WriteString("\n[enter] continue  [ctrl+n] next  [ctrl+p] back")
 	return ui.LegendBox(b.String(), "Map Columns", bw, 0, ui.ColBlue, true, -1)
 }
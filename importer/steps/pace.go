@@ -0,0 +1,257 @@
+package steps
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PublishOptions controls how rows are published. A zero value publishes as
+// fast as possible.
+type PublishOptions struct {
+	// Rate limits publishing to this many messages per second, allowing
+	// Burst messages at once.
+	Rate  float64
+	Burst int
+	// PaceBy names a timestamp column whose gaps between rows are
+	// reproduced, divided by Speed.
+	PaceBy string
+	Speed  float64
+	// QoS and Retain are used for every message.
+	QoS    byte
+	Retain bool
+	// Retries is how often a failed publish is repeated, waiting Backoff
+	// and doubling it after each attempt. The import stops once MaxErrors
+	// rows failed.
+	Retries   int
+	Backoff   time.Duration
+	MaxErrors int
+}
+
+// Retry backoff defaults and limit.
+const (
+	defaultBackoff = 500 * time.Millisecond
+	maxBackoff     = 30 * time.Second
+)
+
+// ParsePublishOptions parses settings such as `rate=50 burst=10`,
+// `pace=timestamp speed=2` or `qos=1 retain=true retries=3 backoff=1s
+// max-errors=10`.
+func ParsePublishOptions(s string) (PublishOptions, error) {
+	var o PublishOptions
+	for _, f := range strings.Fields(s) {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok || v == "" {
+			return o, fmt.Errorf("invalid option %q", f)
+		}
+		var err error
+		switch strings.ToLower(k) {
+		case "rate":
+			o.Rate, err = strconv.ParseFloat(strings.TrimSuffix(v, "/s"), 64)
+			if err == nil && o.Rate <= 0 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "burst":
+			o.Burst, err = strconv.Atoi(v)
+			if err == nil && o.Burst < 1 {
+				err = fmt.Errorf("must be at least 1")
+			}
+		case "pace":
+			o.PaceBy = v
+		case "speed":
+			o.Speed, err = strconv.ParseFloat(strings.TrimSuffix(v, "x"), 64)
+			if err == nil && o.Speed <= 0 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "qos":
+			var q int
+			q, err = strconv.Atoi(v)
+			if err == nil && (q < 0 || q > 2) {
+				err = fmt.Errorf("must be 0, 1 or 2")
+			}
+			o.QoS = byte(q)
+		case "retain":
+			o.Retain, err = strconv.ParseBool(v)
+		case "retries":
+			o.Retries, err = strconv.Atoi(v)
+			if err == nil && o.Retries < 0 {
+				err = fmt.Errorf("must not be negative")
+			}
+		case "backoff":
+			o.Backoff, err = time.ParseDuration(v)
+			if err == nil && o.Backoff <= 0 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "max-errors":
+			o.MaxErrors, err = strconv.Atoi(v)
+			if err == nil && o.MaxErrors < 1 {
+				err = fmt.Errorf("must be at least 1")
+			}
+		default:
+			return o, fmt.Errorf("unknown option %q", k)
+		}
+		if err != nil {
+			return o, fmt.Errorf("%s: %w", k, err)
+		}
+	}
+	return o, nil
+}
+
+// String formats o in the syntax accepted by ParsePublishOptions.
+func (o PublishOptions) String() string {
+	var parts []string
+	if o.Rate > 0 {
+		parts = append(parts, "rate="+strconv.FormatFloat(o.Rate, 'f', -1, 64))
+	}
+	if o.Burst > 0 {
+		parts = append(parts, "burst="+strconv.Itoa(o.Burst))
+	}
+	if o.PaceBy != "" {
+		parts = append(parts, "pace="+o.PaceBy)
+	}
+	if o.Speed > 0 {
+		parts = append(parts, "speed="+strconv.FormatFloat(o.Speed, 'f', -1, 64))
+	}
+	if o.QoS > 0 {
+		parts = append(parts, "qos="+strconv.Itoa(int(o.QoS)))
+	}
+	if o.Retain {
+		parts = append(parts, "retain=true")
+	}
+	if o.Retries > 0 {
+		parts = append(parts, "retries="+strconv.Itoa(o.Retries))
+	}
+	if o.Backoff > 0 {
+		parts = append(parts, "backoff="+o.Backoff.String())
+	}
+	if o.MaxErrors > 0 {
+		parts = append(parts, "max-errors="+strconv.Itoa(o.MaxErrors))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Retry calls publish until it succeeds or Retries more attempts failed.
+// wait sleeps between attempts and reports false to give up early.
+func (o PublishOptions) Retry(publish func() error, wait func(time.Duration) bool) error {
+	err := publish()
+	for i := 0; err != nil && i < o.Retries; i++ {
+		if !wait(o.backoff(i)) {
+			return err
+		}
+		err = publish()
+	}
+	return err
+}
+
+// backoff returns the wait before retry attempt, counted from zero.
+func (o PublishOptions) backoff(attempt int) time.Duration {
+	d := o.Backoff
+	if d == 0 {
+		d = defaultBackoff
+	}
+	for ; attempt > 0 && d < maxBackoff; attempt-- {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
+
+// TooManyErrors reports whether an import with failed rows should stop.
+func (o PublishOptions) TooManyErrors(failed int) bool {
+	return o.MaxErrors > 0 && failed >= o.MaxErrors
+}
+
+// Pacer computes how long to wait before publishing each row.
+type Pacer struct {
+	opts PublishOptions
+
+	// token bucket of the rate limit
+	tokens float64
+	last   time.Time
+
+	// first paced row and when it was published
+	first     time.Time
+	firstWall time.Time
+}
+
+// NewPacer returns a pacer for o.
+func NewPacer(o PublishOptions) *Pacer {
+	if o.Burst == 0 {
+		o.Burst = 1
+	}
+	if o.Speed == 0 {
+		o.Speed = 1
+	}
+	return &Pacer{opts: o, tokens: float64(o.Burst)}
+}
+
+// Delay returns how long after now row should be published. Rows with a
+// timestamp before the previous ones are published without delay; an
+// unreadable timestamp is reported and not paced.
+func (p *Pacer) Delay(row map[string]string, now time.Time) (time.Duration, error) {
+	var wait time.Duration
+	var err error
+	if p.opts.PaceBy != "" {
+		wait, err = p.paceDelay(row, now)
+	}
+	if p.opts.Rate > 0 {
+		wait = max(wait, p.reserve(now.Add(wait)))
+	}
+	return wait, err
+}
+
+func (p *Pacer) paceDelay(row map[string]string, now time.Time) (time.Duration, error) {
+	ts, err := ParseTimestamp(row[p.opts.PaceBy])
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", p.opts.PaceBy, err)
+	}
+	if p.first.IsZero() {
+		p.first, p.firstWall = ts, now
+		return 0, nil
+	}
+	due := p.firstWall.Add(time.Duration(float64(ts.Sub(p.first)) / p.opts.Speed))
+	return max(due.Sub(now), 0), nil
+}
+
+// reserve takes a token at time at and returns the extra wait until one is
+// available.
+func (p *Pacer) reserve(at time.Time) time.Duration {
+	if !p.last.IsZero() && at.After(p.last) {
+		p.tokens = min(p.tokens+at.Sub(p.last).Seconds()*p.opts.Rate, float64(p.opts.Burst))
+	}
+	if p.last.IsZero() || at.After(p.last) {
+		p.last = at
+	}
+	p.tokens--
+	if p.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-p.tokens / p.opts.Rate * float64(time.Second))
+}
+
+// timestampLayouts are the textual timestamp formats accepted for pacing.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// ParseTimestamp reads RFC 3339 style timestamps and Unix times in seconds
+// or, for values above 1e12, milliseconds.
+func ParseTimestamp(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		if f > 1e12 {
+			return time.UnixMilli(int64(f)), nil
+		}
+		sec := int64(f)
+		return time.Unix(sec, int64((f-float64(sec))*1e9)), nil
+	}
+	for _, l := range timestampLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
@@ -0,0 +1,106 @@
+package steps
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// presetMatch is the share of headers two layouts must have in common for a
+// preset to be suggested.
+const presetMatch = 0.5
+
+// Preset is a named set of import settings for one file layout. Headers
+// records the layout it was saved for; QoS, retain and rate are part of
+// the publish options.
+type Preset struct {
+	Headers  []string          `toml:"headers"`
+	Mapping  map[string]string `toml:"mapping"`
+	Template string            `toml:"template"`
+	Payload  string            `toml:"payload,omitempty"`
+	Publish  string            `toml:"publish,omitempty"`
+}
+
+// headerOverlap returns the Jaccard similarity of two header sets.
+func headerOverlap(a, b []string) float64 {
+	set := map[string]bool{}
+	for _, h := range a {
+		set[h] = true
+	}
+	common, union := 0, len(set)
+	for _, h := range b {
+		if set[h] {
+			common++
+			delete(set, h)
+		} else {
+			union++
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return float64(common) / float64(union)
+}
+
+// MatchPreset suggests the preset whose headers best match headers. Ties go
+// to the first name in alphabetical order.
+func (p WizardPrefs) MatchPreset(headers []string) (string, bool) {
+	best, score := "", presetMatch
+	for _, name := range slices.Sorted(maps.Keys(p.Presets)) {
+		if s := headerOverlap(p.Presets[name].Headers, headers); s > score || (s == score && best == "") {
+			best, score = name, s
+		}
+	}
+	return best, best != ""
+}
+
+// FindPreset returns the named preset. The name `auto` picks the preset
+// matching headers.
+func (p WizardPrefs) FindPreset(name string, headers []string) (string, Preset, error) {
+	if strings.EqualFold(name, "auto") {
+		match, ok := p.MatchPreset(headers)
+		if !ok {
+			return "", Preset{}, fmt.Errorf("no preset matches the headers %s", strings.Join(headers, ","))
+		}
+		name = match
+	}
+	pr, ok := p.Presets[name]
+	if !ok {
+		return "", Preset{}, fmt.Errorf("unknown preset %q", name)
+	}
+	if pr.Mapping == nil {
+		pr.Mapping = map[string]string{}
+	}
+	return name, pr, nil
+}
+
+// applyPreset fills the wizard with the settings of the named preset.
+func (b *Base) applyPreset(name string) error {
+	name, pr, err := b.Prefs.FindPreset(name, b.Headers)
+	if err != nil {
+		return err
+	}
+	b.Preset.SetValue(name)
+	b.buildForm(pr.Mapping)
+	b.Tmpl.SetValue(pr.Template)
+	b.Payload.SetValue(pr.Payload)
+	b.PubOpts.SetValue(pr.Publish)
+	return nil
+}
+
+// savePreset stores the current settings under name.
+func (b *Base) savePreset(name string) error {
+	if b.Prefs.Presets == nil {
+		b.Prefs.Presets = map[string]Preset{}
+	}
+	b.Prefs.Presets[name] = Preset{
+		Headers:  slices.Clone(b.Headers),
+		Mapping:  b.mapping(),
+		Template: b.Tmpl.Value(),
+		Payload:  b.Payload.Value(),
+		Publish:  b.PubOpts.Value(),
+	}
+	b.Preset.SetValue(name)
+	return SavePrefs(b.Prefs)
+}
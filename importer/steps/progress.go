@@ -0,0 +1,111 @@
+package steps
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// progressEvery is how many rows are published between saved offsets.
+const progressEvery = 100
+
+// importProgress records how many rows of a file were published. Size and
+// ModTime tell whether the file changed since.
+type importProgress struct {
+	Size    int64     `toml:"size"`
+	ModTime time.Time `toml:"mod_time"`
+	Row     int       `toml:"row"`
+}
+
+func progressFile() (string, error) {
+	fp, err := configFile()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(fp), "import-progress.toml"), nil
+}
+
+func loadProgress() map[string]importProgress {
+	out := map[string]importProgress{}
+	fp, err := progressFile()
+	if err != nil {
+		return out
+	}
+	toml.DecodeFile(fp, &out)
+	return out
+}
+
+func writeProgress(all map[string]importProgress) error {
+	fp, err := progressFile()
+	if err != nil {
+		return err
+	}
+	if len(all) == 0 {
+		if err := os.Remove(fp); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(fp), os.ModePerm); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(all); err != nil {
+		return err
+	}
+	return os.WriteFile(fp, buf.Bytes(), 0o644)
+}
+
+func progressKey(path string) (string, os.FileInfo, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", nil, err
+	}
+	fi, err := os.Stat(abs)
+	if err != nil {
+		return "", nil, err
+	}
+	return abs, fi, nil
+}
+
+// ResumeOffset returns the number of rows of path published by an
+// interrupted import, or 0 when there is none or the file changed since.
+func ResumeOffset(path string) int {
+	key, fi, err := progressKey(path)
+	if err != nil {
+		return 0
+	}
+	p, ok := loadProgress()[key]
+	if !ok || p.Size != fi.Size() || !p.ModTime.Equal(fi.ModTime()) {
+		return 0
+	}
+	return p.Row
+}
+
+// SaveProgress records that the first row rows of path were published.
+func SaveProgress(path string, row int) error {
+	key, fi, err := progressKey(path)
+	if err != nil {
+		return err
+	}
+	all := loadProgress()
+	all[key] = importProgress{Size: fi.Size(), ModTime: fi.ModTime(), Row: row}
+	return writeProgress(all)
+}
+
+// ClearProgress forgets the offset of path once its import completed.
+func ClearProgress(path string) error {
+	key, _, err := progressKey(path)
+	if err != nil {
+		return err
+	}
+	all := loadProgress()
+	if _, ok := all[key]; !ok {
+		return nil
+	}
+	delete(all, key)
+	return writeProgress(all)
+}
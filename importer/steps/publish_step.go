@@ -20,17 +20,24 @@ func NewPublishStep(b *Base) *PublishStep {
 func (s *PublishStep) Update(msg tea.Msg) (Step, tea.Cmd) {
 	switch ev := msg.(type) {
 	case PublishMsg:
-		s.Index++
-		p := float64(s.Index) / float64(len(s.Rows))
-		if p > 1 {
-			p = 1
+		if ev.Run != s.Run || s.Finished {
+			return s, nil
 		}
-		cmd := s.Progress.SetPercent(p)
-		if s.Index >= len(s.Rows) {
-			s.Finished = true
+		s.record(ev)
+		s.advance()
+		cmd := s.Progress.SetPercent(s.percent())
+		if s.Publish.TooManyErrors(s.Failed) {
+			s.Aborted = true
+			s.keep(fmt.Sprintf("aborted after %d errors", s.Failed))
+			s.finish()
 			return s, cmd
 		}
-		return s, tea.Batch(cmd, s.nextPublishCmd())
+		next := s.nextPublishCmd()
+		if next == nil {
+			s.finish()
+			return s, cmd
+		}
+		return s, tea.Batch(cmd, next)
 	case tea.KeyMsg:
 		switch ev.Type {
 		case tea.KeyCtrlN:
@@ -42,6 +49,12 @@ func (s *PublishStep) Update(msg tea.Msg) (Step, tea.Cmd) {
 				s.Finished = false
 				return NewReviewStep(s.Base), nil
 			}
+		case tea.KeyEsc:
+			if !s.Finished {
+				s.Close()
+				s.Resume = ResumeOffset(s.Path)
+				return NewReviewStep(s.Base), nil
+			}
 		}
 		cmd := s.History.Update(ev)
 		return s, cmd
@@ -52,14 +65,6 @@ func (s *PublishStep) Update(msg tea.Msg) (Step, tea.Cmd) {
 func (s *PublishStep) View(bw, wrap int) string {
 	bar := s.Progress.View()
 	lines := s.Published
-	limit := s.SampleLimit
-	if limit == 0 {
-		limit = sampleSize(len(s.Rows))
-		s.SampleLimit = limit
-	}
-	if len(lines) > limit {
-		lines = lines[len(lines)-limit:]
-	}
 	s.History.SetSize(bw, s.HistoryHeight())
 	s.History.SetLines(spacedLines(lines))
 	recent := s.History.View()
@@ -68,9 +73,22 @@ func (s *PublishStep) View(bw, wrap int) string {
 	}
 	headerLine := ""
 	if s.Finished {
-		headerLine = fmt.Sprintf("Published %d messages", len(s.Rows))
+		headerLine = fmt.Sprintf("Published %d messages", s.Index-s.Start-s.Failed)
+		if s.Failed > 0 {
+			headerLine += fmt.Sprintf(", %d failed", s.Failed)
+		}
+		if s.Aborted {
+			headerLine += fmt.Sprintf(", aborted at row %d", s.Index)
+		}
+		if s.Rejects != nil && s.Rejects.Rows > 0 {
+			headerLine += fmt.Sprintf("\nRejected rows written to %s", s.Rejects.Path)
+		}
 	} else {
-		headerLine = fmt.Sprintf("Publishing %d/%d", s.Index, len(s.Rows))
+		headerLine = fmt.Sprintf("Publishing %d/%d", s.Index, s.Total)
+		if opts := s.Publish.String(); opts != "" && !s.DryRun {
+			headerLine += "  " + opts
+		}
+		headerLine += "  [esc] pause"
 	}
 	msg := fmt.Sprintf("%s\n%s\n%s", headerLine, bar, recent)
 	msg = ansi.Wrap(msg, wrap, " ")
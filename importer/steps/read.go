@@ -0,0 +1,525 @@
+package steps
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+)
+
+// ReadOptions controls how delimited text files are parsed. Zero values are
+// detected from the file.
+type ReadOptions struct {
+	Delimiter rune
+	// Quote is the quote character; noQuote disables quoting.
+	Quote    rune
+	Encoding string
+}
+
+// noQuote disables quote handling so quote characters are kept as text.
+const noQuote rune = -1
+
+// literalQuote stands in for '"' while quoting is disabled or uses another
+// character.
+const literalQuote = '\uE000'
+
+// ParseReadOptions parses settings such as `delimiter=; quote=' encoding=latin1`.
+// Delimiter and quote accept a single character, `tab` or, for quote, `none`.
+func ParseReadOptions(s string) (ReadOptions, error) {
+	var o ReadOptions
+	for _, f := range strings.Fields(s) {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok || v == "" {
+			return o, fmt.Errorf("invalid option %q", f)
+		}
+		switch strings.ToLower(k) {
+		case "delimiter", "delim":
+			r, err := optionRune(v)
+			if err != nil {
+				return o, fmt.Errorf("delimiter: %w", err)
+			}
+			o.Delimiter = r
+		case "quote":
+			if strings.EqualFold(v, "none") {
+				o.Quote = noQuote
+				continue
+			}
+			r, err := optionRune(v)
+			if err != nil {
+				return o, fmt.Errorf("quote: %w", err)
+			}
+			o.Quote = r
+		case "encoding":
+			enc, err := normalizeEncoding(v)
+			if err != nil {
+				return o, err
+			}
+			o.Encoding = enc
+		default:
+			return o, fmt.Errorf("unknown option %q", k)
+		}
+	}
+	if o.Delimiter != 0 && o.Delimiter == o.Quote {
+		return o, fmt.Errorf("delimiter and quote must differ")
+	}
+	return o, nil
+}
+
+func optionRune(v string) (rune, error) {
+	if strings.EqualFold(v, "tab") || v == `\t` {
+		return '\t', nil
+	}
+	r, size := utf8.DecodeRuneInString(v)
+	if size != len(v) || r == utf8.RuneError || r == '\n' || r == '\r' {
+		return 0, fmt.Errorf("expected a single character, got %q", v)
+	}
+	return r, nil
+}
+
+func normalizeEncoding(v string) (string, error) {
+	switch strings.ToLower(strings.ReplaceAll(v, "_", "-")) {
+	case "utf-8", "utf8":
+		return "utf-8", nil
+	case "utf-16", "utf16", "utf-16le", "utf16le":
+		return "utf-16le", nil
+	case "utf-16be", "utf16be":
+		return "utf-16be", nil
+	case "latin1", "latin-1", "iso-8859-1", "iso8859-1":
+		return "latin1", nil
+	default:
+		return "", fmt.Errorf("unsupported encoding %q", v)
+	}
+}
+
+// sniffSize is how much of a file is inspected to detect its encoding and
+// delimiter.
+const sniffSize = 64 << 10
+
+// RowReader streams the rows of an import file.
+type RowReader struct {
+	// Headers lists the fields in file order. JSON readers add fields as
+	// rows introduce them.
+	Headers []string
+
+	f    *os.File
+	seen map[string]bool
+	next func() (map[string]string, error)
+}
+
+// OpenRows opens a CSV, TSV, JSON or NDJSON file for streaming. Nested JSON
+// fields are flattened to dotted paths such as `location.lat` or `tags.0`.
+func OpenRows(path string, opts ReadOptions) (*RowReader, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".csv", ".txt", ".tsv", ".json", ".ndjson", ".jsonl":
+	default:
+		return nil, fmt.Errorf("unsupported file type: %s", ext)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &RowReader{f: f, seen: map[string]bool{}}
+	text, err := decodeReader(f, opts.Encoding)
+	if err == nil {
+		switch ext {
+		case ".tsv":
+			if opts.Delimiter == 0 {
+				opts.Delimiter = '\t'
+			}
+			err = r.openDelimited(text, opts)
+		case ".json":
+			err = r.openJSON(text)
+		case ".ndjson", ".jsonl":
+			r.openNDJSON(text)
+		default:
+			err = r.openDelimited(text, opts)
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Next returns the next row or io.EOF after the last one.
+func (r *RowReader) Next() (map[string]string, error) { return r.next() }
+
+// Skip reads past n rows, e.g. to resume an import.
+func (r *RowReader) Skip(n int) error {
+	for i := 0; i < n; i++ {
+		if _, err := r.next(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the underlying file.
+func (r *RowReader) Close() error { return r.f.Close() }
+
+// Table is the content of an import file with headers in file order.
+type Table struct {
+	Headers []string
+	Rows    []map[string]string
+}
+
+// ReadTable reads all rows of a file into memory.
+func ReadTable(path string, opts ReadOptions) (Table, error) {
+	r, err := OpenRows(path, opts)
+	if err != nil {
+		return Table{}, err
+	}
+	defer r.Close()
+	var t Table
+	for {
+		row, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Table{}, err
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	t.Headers = r.Headers
+	return t, nil
+}
+
+// FileInfo summarizes an import file.
+type FileInfo struct {
+	Headers []string
+	Rows    int
+	Sample  []map[string]string
+}
+
+// ScanFile streams through path once to count its rows and collect the
+// headers, keeping only the first sample rows in memory.
+func ScanFile(path string, opts ReadOptions, sample int) (FileInfo, error) {
+	r, err := OpenRows(path, opts)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	defer r.Close()
+	var info FileInfo
+	for {
+		row, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return FileInfo{}, err
+		}
+		if len(info.Sample) < sample {
+			info.Sample = append(info.Sample, row)
+		}
+		info.Rows++
+	}
+	info.Headers = r.Headers
+	return info, nil
+}
+
+// decodeReader converts r to UTF-8. Without an explicit encoding a byte
+// order mark selects UTF-8 or UTF-16 and text that is not UTF-8 within the
+// first sniffSize bytes is read as Latin-1.
+func decodeReader(r io.Reader, enc string) (io.Reader, error) {
+	br := bufio.NewReaderSize(r, sniffSize)
+	head, _ := br.Peek(sniffSize)
+	switch {
+	case bytes.HasPrefix(head, []byte{0xEF, 0xBB, 0xBF}) && (enc == "" || enc == "utf-8"):
+		br.Discard(3)
+		enc = "utf-8"
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE}) && (enc == "" || enc == "utf-16le"):
+		br.Discard(2)
+		enc = "utf-16le"
+	case bytes.HasPrefix(head, []byte{0xFE, 0xFF}) && (enc == "" || enc == "utf-16be"):
+		br.Discard(2)
+		enc = "utf-16be"
+	case enc == "" && validUTF8Prefix(head):
+		enc = "utf-8"
+	case enc == "":
+		enc = "latin1"
+	}
+	switch enc {
+	case "utf-8":
+		return br, nil
+	case "utf-16le":
+		return transform.NewReader(br, unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewDecoder()), nil
+	case "utf-16be":
+		return transform.NewReader(br, unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewDecoder()), nil
+	case "latin1":
+		return transform.NewReader(br, charmap.ISO8859_1.NewDecoder()), nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", enc)
+	}
+}
+
+// validUTF8Prefix reports whether b is valid UTF-8, allowing a rune cut off
+// at its end.
+func validUTF8Prefix(b []byte) bool {
+	if utf8.Valid(b) {
+		return true
+	}
+	for i := 1; i < utf8.UTFMax && i <= len(b); i++ {
+		if !utf8.FullRune(b[len(b)-i:]) && utf8.Valid(b[:len(b)-i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectDelimiter picks the most frequent of , ; tab and | in the header
+// line, ignoring quoted text. Commas win ties.
+func detectDelimiter(line string) rune {
+	counts := map[rune]int{}
+	quoted := false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case !quoted && strings.ContainsRune(",;\t|", r):
+			counts[r]++
+		}
+	}
+	best := ','
+	for _, r := range []rune{';', '\t', '|'} {
+		if counts[r] > counts[best] {
+			best = r
+		}
+	}
+	return best
+}
+
+// swapRunes exchanges a and b.
+func swapRunes(a, b rune) func(rune) rune {
+	return func(r rune) rune {
+		switch r {
+		case a:
+			return b
+		case b:
+			return a
+		}
+		return r
+	}
+}
+
+func (r *RowReader) openDelimited(text io.Reader, opts ReadOptions) error {
+	quote := opts.Quote
+	if quote == 0 {
+		quote = '"'
+	}
+	// encoding/csv only knows '"', so the configured quote takes its place
+	// and literal '"' characters are swapped out and restored per field.
+	restore := func(s string) string { return s }
+	switch quote {
+	case '"':
+	case noQuote:
+		text = transform.NewReader(text, runes.Map(swapRunes('"', literalQuote)))
+		restore = func(s string) string { return strings.Map(swapRunes('"', literalQuote), s) }
+	default:
+		text = transform.NewReader(text, runes.Map(func(c rune) rune {
+			return swapRunes(quote, '"')(swapRunes('"', literalQuote)(c))
+		}))
+		restore = func(s string) string {
+			return strings.Map(swapRunes('"', literalQuote), strings.Map(swapRunes(quote, '"'), s))
+		}
+	}
+	br := bufio.NewReaderSize(text, sniffSize)
+	delim := opts.Delimiter
+	if delim == 0 {
+		head, _ := br.Peek(sniffSize)
+		line, _, _ := strings.Cut(string(head), "\n")
+		delim = detectDelimiter(line)
+	}
+	cr := csv.NewReader(br)
+	cr.Comma = delim
+	cr.FieldsPerRecord = -1
+	headers, err := cr.Read()
+	if err != nil && err != io.EOF {
+		return err
+	}
+	for i, h := range headers {
+		headers[i] = restore(h)
+	}
+	r.Headers = headers
+	r.next = func() (map[string]string, error) {
+		if headers == nil {
+			return nil, io.EOF
+		}
+		rec, err := cr.Read()
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(headers))
+		for i, h := range headers {
+			if i < len(rec) {
+				row[h] = restore(rec[i])
+			} else {
+				row[h] = ""
+			}
+		}
+		return row, nil
+	}
+	return nil
+}
+
+func (r *RowReader) set(row map[string]string, key, value string) {
+	if !r.seen[key] {
+		r.seen[key] = true
+		r.Headers = append(r.Headers, key)
+	}
+	row[key] = value
+}
+
+// readRow reads one JSON object from dec as a flat row.
+func (r *RowReader) readRow(dec *json.Decoder) (map[string]string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return nil, fmt.Errorf("expected JSON object, got %v", tok)
+	}
+	row := map[string]string{}
+	if _, err := r.readObject(dec, "", row); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// readObject reads the members of an object whose '{' was consumed and
+// returns their number.
+func (r *RowReader) readObject(dec *json.Decoder, prefix string, row map[string]string) (int, error) {
+	n := 0
+	for ; dec.More(); n++ {
+		name, err := dec.Token()
+		if err != nil {
+			return n, err
+		}
+		if err := r.readValue(dec, prefix+name.(string), row); err != nil {
+			return n, err
+		}
+	}
+	_, err := dec.Token()
+	return n, err
+}
+
+func (r *RowReader) readValue(dec *json.Decoder, key string, row map[string]string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		n := 0
+		if v == '{' {
+			n, err = r.readObject(dec, key+".", row)
+		} else {
+			for ; dec.More() && err == nil; n++ {
+				err = r.readValue(dec, key+"."+strconv.Itoa(n), row)
+			}
+			if err == nil {
+				_, err = dec.Token()
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			r.set(row, key, "")
+		}
+	case string:
+		r.set(row, key, v)
+	case json.Number:
+		r.set(row, key, v.String())
+	case bool:
+		r.set(row, key, strconv.FormatBool(v))
+	case nil:
+		r.set(row, key, "")
+	}
+	return nil
+}
+
+// openJSON reads an array of objects or a single object.
+func (r *RowReader) openJSON(text io.Reader) error {
+	dec := json.NewDecoder(text)
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err == io.EOF {
+		r.next = func() (map[string]string, error) { return nil, io.EOF }
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('['):
+		i := 0
+		r.next = func() (map[string]string, error) {
+			if !dec.More() {
+				return nil, io.EOF
+			}
+			row, err := r.readRow(dec)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			i++
+			return row, nil
+		}
+	case json.Delim('{'):
+		done := false
+		r.next = func() (map[string]string, error) {
+			if done {
+				return nil, io.EOF
+			}
+			done = true
+			row := map[string]string{}
+			if _, err := r.readObject(dec, "", row); err != nil {
+				return nil, err
+			}
+			return row, nil
+		}
+	default:
+		return errors.New("expected a JSON array of objects")
+	}
+	return nil
+}
+
+// openNDJSON reads one JSON object per line, skipping blank lines.
+func (r *RowReader) openNDJSON(text io.Reader) {
+	br := bufio.NewReader(text)
+	line := 0
+	r.next = func() (map[string]string, error) {
+		for {
+			s, err := br.ReadString('\n')
+			if err != nil && (err != io.EOF || s == "") {
+				return nil, err
+			}
+			line++
+			if strings.TrimSpace(s) == "" {
+				continue
+			}
+			dec := json.NewDecoder(strings.NewReader(s))
+			dec.UseNumber()
+			row, err := r.readRow(dec)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			return row, nil
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package steps
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RejectsPath returns the file rows of source that could not be imported
+// are written to, e.g. data.rejected.csv for data.json.
+func RejectsPath(source string) string {
+	return strings.TrimSuffix(source, filepath.Ext(source)) + ".rejected.csv"
+}
+
+// Rejects writes rejected rows as CSV with the source's headers so they can
+// be fixed and imported again. The file is only created for the first row.
+type Rejects struct {
+	Path    string
+	Headers []string
+	// Rows counts the rows written.
+	Rows int
+	// appendTo keeps rows of an earlier run, e.g. when resuming.
+	appendTo bool
+	f        *os.File
+	w        *csv.Writer
+}
+
+// NewRejects returns a writer for the rejected rows of source. With appendTo
+// set, rows are added to an existing file instead of replacing it.
+func NewRejects(source string, headers []string, appendTo bool) *Rejects {
+	return &Rejects{Path: RejectsPath(source), Headers: headers, appendTo: appendTo}
+}
+
+// Add writes row.
+func (r *Rejects) Add(row map[string]string) error {
+	if r.w == nil {
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+	rec := make([]string, len(r.Headers))
+	for i, h := range r.Headers {
+		rec[i] = row[h]
+	}
+	if err := r.w.Write(rec); err != nil {
+		return err
+	}
+	r.w.Flush()
+	r.Rows++
+	return r.w.Error()
+}
+
+func (r *Rejects) open() error {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	header := true
+	if r.appendTo {
+		if fi, err := os.Stat(r.Path); err == nil && fi.Size() > 0 {
+			flags, header = os.O_WRONLY|os.O_APPEND, false
+		}
+	}
+	f, err := os.OpenFile(r.Path, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	r.f, r.w = f, csv.NewWriter(f)
+	if header {
+		return r.w.Write(r.Headers)
+	}
+	return nil
+}
+
+// Close closes the file if rows were written.
+func (r *Rejects) Close() error {
+	if r == nil || r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f, r.w, r.appendTo = nil, nil, true
+	return err
+}
@@ -2,7 +2,9 @@ package steps
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/x/ansi"
 
@@ -11,7 +13,12 @@ import (
 )
 
 // ReviewStep previews messages before publishing.
-type ReviewStep struct{ *Base }
+type ReviewStep struct {
+	*Base
+	// name is set while the settings are being saved as a preset.
+	name *textinput.Model
+	err  string
+}
 
 func NewReviewStep(b *Base) *ReviewStep {
 	b.Current = Review
@@ -19,28 +26,28 @@ func NewReviewStep(b *Base) *ReviewStep {
 }
 
 func (s *ReviewStep) Update(msg tea.Msg) (Step, tea.Cmd) {
+	if s.name != nil {
+		return s.updateName(msg)
+	}
 	if km, ok := msg.(tea.KeyMsg); ok {
 		switch km.String() {
+		case constants.KeyS:
+			ti := textinput.New()
+			ti.Prompt = "Preset name: "
+			ti.SetValue(s.Preset.Value())
+			s.name, s.err = &ti, ""
+			return s, s.name.Focus()
 		case constants.KeyP:
-			s.Prefs.Mapping = s.mapping()
-			s.Prefs.Template = s.Tmpl.Value()
-			SavePrefs(s.Prefs)
-			s.DryRun = false
-			s.Index = 0
-			s.Published = nil
-			s.Finished = false
-			s.History.GotoTop()
-			return NewPublishStep(s.Base), tea.Batch(s.Progress.SetPercent(0), s.nextPublishCmd())
+			s.savePrefs()
+			return NewPublishStep(s.Base), s.startPublish(false, 0)
+		case constants.KeyR:
+			if s.Resume > 0 && s.Resume < s.Total {
+				s.savePrefs()
+				return NewPublishStep(s.Base), s.startPublish(false, s.Resume)
+			}
 		case constants.KeyD:
-			s.Prefs.Mapping = s.mapping()
-			s.Prefs.Template = s.Tmpl.Value()
-			SavePrefs(s.Prefs)
-			s.DryRun = true
-			s.Index = 0
-			s.Published = nil
-			s.Finished = false
-			s.History.GotoTop()
-			return NewPublishStep(s.Base), tea.Batch(s.Progress.SetPercent(0), s.nextPublishCmd())
+			s.savePrefs()
+			return NewPublishStep(s.Base), s.startPublish(true, 0)
 		case constants.KeyE:
 			return NewMapStep(s.Base), nil
 		case constants.KeyQ:
@@ -53,20 +60,67 @@ func (s *ReviewStep) Update(msg tea.Msg) (Step, tea.Cmd) {
 	return s, nil
 }
 
+// updateName edits the preset name and saves the preset on enter.
+func (s *ReviewStep) updateName(msg tea.Msg) (Step, tea.Cmd) {
+	if km, ok := msg.(tea.KeyMsg); ok {
+		switch km.String() {
+		case constants.KeyEsc:
+			s.name = nil
+			return s, nil
+		case constants.KeyEnter:
+			name := strings.TrimSpace(s.name.Value())
+			if name == "" || strings.EqualFold(name, "auto") {
+				s.err = "choose a name other than auto"
+				return s, nil
+			}
+			if err := s.savePreset(name); err != nil {
+				s.err = err.Error()
+				return s, nil
+			}
+			s.name, s.err = nil, ""
+			s.PresetNote = "saved preset " + name
+			return s, nil
+		}
+	}
+	var cmd tea.Cmd
+	*s.name, cmd = s.name.Update(msg)
+	return s, cmd
+}
+
+// savePrefs remembers the mapping and template for the next import.
+func (s *ReviewStep) savePrefs() {
+	s.Prefs.Mapping = s.mapping()
+	s.Prefs.Template = s.Tmpl.Value()
+	s.Prefs.Publish = s.PubOpts.Value()
+	s.Prefs.Payload = s.Payload.Value()
+	SavePrefs(s.Prefs)
+}
+
 func (s *ReviewStep) View(bw, wrap int) string {
-	topic := s.Tmpl.Value()
-	mapping := s.mapping()
+	enc := s.encoder()
 	previews := ""
-	max := 3
-	if len(s.Rows) < max {
-		max = len(s.Rows)
-	}
-	for i := 0; i < max; i++ {
-		t := BuildTopic(topic, renameFields(s.Rows[i], mapping))
-		p, _ := RowToJSON(s.Rows[i], mapping)
-		line := fmt.Sprintf("%s -> %s", t, string(p))
+	for _, row := range s.Sample {
+		t, p, err := enc.Encode(row)
+		line := ""
+		if err != nil {
+			line = fmt.Sprintf("%s -> error: %v", t, err)
+		} else {
+			line = fmt.Sprintf("%s -> %s", t, string(p))
+		}
 		previews += ansi.Wrap(line, wrap, " ") + "\n"
 	}
-	out := fmt.Sprintf("Rows: %d\n%s\n[p] publish  [d] dry run  [e] edit  [ctrl+p] back  [q] quit", len(s.Rows), previews)
+	keys := "[p] publish  [d] dry run  [s] save preset  [e] edit  [ctrl+p] back  [q] quit"
+	if s.Resume > 0 && s.Resume < s.Total {
+		keys = fmt.Sprintf("[r] resume at row %d  %s", s.Resume+1, keys)
+	}
+	out := fmt.Sprintf("Rows: %d\n%s\n%s", s.Total, previews, keys)
+	if s.name != nil {
+		out += "\n" + s.name.View() + "  [enter] save  [esc] cancel"
+		if s.err != "" {
+			out += "\n" + ui.FormError.Render(s.err)
+		}
+	} else if s.PresetNote != "" {
+		out += "\n" + ui.InfoStyle.Render(s.PresetNote)
+	}
 	return ui.LegendBox(out, "Review", bw, 0, ui.ColBlue, true, -1)
 }
@@ -24,5 +24,12 @@ const (
 
 var Names = []string{"File", "Map", "Template", "Review", "Publish", "Done"}
 
-// PublishMsg signals that the next row should be processed during publishing.
-type PublishMsg struct{}
+// PublishMsg reports a processed row of publishing run Run and signals that
+// the next row should be processed. Messages of an earlier run are ignored.
+type PublishMsg struct {
+	Run int
+	// Line is the output of the row, Failed the row that could not be
+	// published.
+	Line   string
+	Failed map[string]string
+}
@@ -1,39 +1,93 @@
 package steps
 
 import (
+	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/x/ansi"
 
 	"github.com/marang/emqutiti/ui"
 )
 
-// TemplateStep collects the topic template.
+// TemplateStep collects the topic template and publish options.
 type TemplateStep struct{ *Base }
 
 func NewTemplateStep(b *Base) *TemplateStep {
 	b.Current = Template
+	b.PubOpts.Blur()
+	b.Payload.Blur()
 	b.Tmpl.Focus()
 	return &TemplateStep{Base: b}
 }
 
+// inputs lists the step's inputs in focus order.
+func (s *TemplateStep) inputs() []*textinput.Model {
+	return []*textinput.Model{&s.Tmpl, &s.Payload, &s.PubOpts}
+}
+
 func (s *TemplateStep) Update(msg tea.Msg) (Step, tea.Cmd) {
+	inputs := s.inputs()
+	focus := 0
+	for i, in := range inputs {
+		if in.Focused() {
+			focus = i
+		}
+	}
+	if km, ok := msg.(tea.KeyMsg); ok && (km.Type == tea.KeyTab || km.Type == tea.KeyShiftTab) {
+		inputs[focus].Blur()
+		if km.Type == tea.KeyTab {
+			focus = (focus + 1) % len(inputs)
+		} else {
+			focus = (focus + len(inputs) - 1) % len(inputs)
+		}
+		return s, inputs[focus].Focus()
+	}
 	var cmd tea.Cmd
-	s.Tmpl, cmd = s.Tmpl.Update(msg)
+	*inputs[focus], cmd = inputs[focus].Update(msg)
 	if km, ok := msg.(tea.KeyMsg); ok {
 		switch km.Type {
 		case tea.KeyCtrlP:
 			return NewMapStep(s.Base), cmd
 		case tea.KeyCtrlN, tea.KeyEnter:
-			if strings.TrimSpace(s.Tmpl.Value()) != "" {
-				return NewReviewStep(s.Base), cmd
+			if strings.TrimSpace(s.Tmpl.Value()) == "" {
+				return s, cmd
 			}
+			opts, err := s.publishOptions()
+			if err != nil {
+				s.PubOpts.SetValue(s.PubOpts.Value() + " (" + err.Error() + ")")
+				return s, cmd
+			}
+			s.Publish = opts
+			s.payloadTmpl = nil
+			if src := s.Payload.Value(); strings.TrimSpace(src) != "" {
+				t, err := ParsePayloadTemplate(src)
+				if err != nil {
+					s.Payload.SetValue(src + " (" + err.Error() + ")")
+					return s, cmd
+				}
+				s.payloadTmpl = t
+			}
+			return NewReviewStep(s.Base), cmd
 		}
 	}
 	return s, cmd
 }
 
+// publishOptions parses the publish options and checks the pacing column.
+func (s *TemplateStep) publishOptions() (PublishOptions, error) {
+	opts, err := ParsePublishOptions(s.PubOpts.Value())
+	if err != nil {
+		return opts, err
+	}
+	if opts.PaceBy != "" && !slices.Contains(s.Headers, opts.PaceBy) {
+		return opts, fmt.Errorf("pace: unknown column %q", opts.PaceBy)
+	}
+	return opts, nil
+}
+
 func (s *TemplateStep) View(bw, wrap int) string {
 	names := make([]string, len(s.Headers))
 	for i, h := range s.Headers {
@@ -41,6 +95,6 @@ func (s *TemplateStep) View(bw, wrap int) string {
 	}
 	help := "Available fields: " + strings.Join(names, " ")
 	help = ansi.Wrap(help, wrap, " ")
-	content := s.Tmpl.View() + "\n" + help + "\n[enter] continue  [ctrl+n] next  [ctrl+p] back"
+	content := s.Tmpl.View() + "\n" + s.Payload.View() + "\n" + s.PubOpts.View() + "\n" + help + "\n[enter] continue  [tab] payload/publish options  [ctrl+n] next  [ctrl+p] back"
 	return ui.LegendBox(content, "Topic Template", bw, 0, ui.ColBlue, true, -1)
 }
@@ -0,0 +1,242 @@
+// Package jsonpath evaluates a small subset of JSONPath and jq-style
+// expressions against values decoded by encoding/json.
+//
+// Supported syntax: an optional leading "$", ".name", "['name']", "[n]"
+// (negative indexes count from the end), "[start:end]", "[*]", "[]", ".*"
+// and recursive descent with "..name" or "..*".
+package jsonpath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type stepKind int
+
+const (
+	stepName stepKind = iota
+	stepIndex
+	stepSlice
+	stepWildcard
+)
+
+type step struct {
+	kind      stepKind
+	name      string
+	index     int
+	start     *int
+	end       *int
+	recursive bool
+}
+
+// Path is a compiled expression.
+type Path struct {
+	expr  string
+	steps []step
+}
+
+// String returns the source expression.
+func (p Path) String() string { return p.expr }
+
+// Compile parses expr into a Path.
+func Compile(expr string) (Path, error) {
+	src := strings.TrimSpace(expr)
+	p := Path{expr: src}
+	s := strings.TrimPrefix(src, "$")
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '.':
+			recursive := false
+			i++
+			if i < len(s) && s[i] == '.' {
+				recursive = true
+				i++
+			}
+			if i >= len(s) {
+				if recursive {
+					return Path{}, fmt.Errorf("jsonpath: missing name after '..'")
+				}
+				continue // "." alone selects the root
+			}
+			if s[i] == '[' {
+				if recursive {
+					return Path{}, fmt.Errorf("jsonpath: unsupported '..[' at %d", i)
+				}
+				continue
+			}
+			j := i
+			for j < len(s) && s[j] != '.' && s[j] != '[' {
+				j++
+			}
+			name := s[i:j]
+			if name == "" {
+				return Path{}, fmt.Errorf("jsonpath: empty name at %d", i)
+			}
+			st := step{kind: stepName, name: name, recursive: recursive}
+			if name == "*" {
+				st.kind = stepWildcard
+			}
+			p.steps = append(p.steps, st)
+			i = j
+		case '[':
+			j := strings.IndexByte(s[i:], ']')
+			if j < 0 {
+				return Path{}, fmt.Errorf("jsonpath: unterminated '[' at %d", i)
+			}
+			st, err := parseBracket(s[i+1 : i+j])
+			if err != nil {
+				return Path{}, err
+			}
+			p.steps = append(p.steps, st)
+			i += j + 1
+		default:
+			if i == 0 {
+				// allow "a.b" without a leading dot
+				s = "." + s
+				continue
+			}
+			return Path{}, fmt.Errorf("jsonpath: unexpected %q at %d", s[i], i)
+		}
+	}
+	return p, nil
+}
+
+func parseBracket(in string) (step, error) {
+	in = strings.TrimSpace(in)
+	switch {
+	case in == "" || in == "*":
+		return step{kind: stepWildcard}, nil
+	case len(in) >= 2 && (in[0] == '\'' || in[0] == '"') && in[len(in)-1] == in[0]:
+		return step{kind: stepName, name: in[1 : len(in)-1]}, nil
+	case strings.Contains(in, ":"):
+		parts := strings.SplitN(in, ":", 2)
+		st := step{kind: stepSlice}
+		for i, part := range parts {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return step{}, fmt.Errorf("jsonpath: invalid slice %q", in)
+			}
+			if i == 0 {
+				st.start = &n
+			} else {
+				st.end = &n
+			}
+		}
+		return st, nil
+	}
+	n, err := strconv.Atoi(in)
+	if err != nil {
+		return step{}, fmt.Errorf("jsonpath: invalid index %q", in)
+	}
+	return step{kind: stepIndex, index: n}, nil
+}
+
+// Eval applies the path to doc and returns all matching values.
+func (p Path) Eval(doc interface{}) []interface{} {
+	cur := []interface{}{doc}
+	for _, st := range p.steps {
+		var next []interface{}
+		for _, v := range cur {
+			if st.recursive {
+				walk(v, func(n interface{}) { next = append(next, apply(st, n)...) })
+			} else {
+				next = append(next, apply(st, v)...)
+			}
+		}
+		cur = next
+	}
+	return cur
+}
+
+// Eval compiles expr and applies it to doc.
+func Eval(doc interface{}, expr string) ([]interface{}, error) {
+	p, err := Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p.Eval(doc), nil
+}
+
+func apply(st step, v interface{}) []interface{} {
+	switch st.kind {
+	case stepName:
+		if m, ok := v.(map[string]interface{}); ok {
+			if c, ok := m[st.name]; ok {
+				return []interface{}{c}
+			}
+		}
+	case stepIndex:
+		if a, ok := v.([]interface{}); ok {
+			i := st.index
+			if i < 0 {
+				i += len(a)
+			}
+			if i >= 0 && i < len(a) {
+				return []interface{}{a[i]}
+			}
+		}
+	case stepSlice:
+		if a, ok := v.([]interface{}); ok {
+			start, end := 0, len(a)
+			if st.start != nil {
+				start = clamp(*st.start, len(a))
+			}
+			if st.end != nil {
+				end = clamp(*st.end, len(a))
+			}
+			if start < end {
+				return append([]interface{}(nil), a[start:end]...)
+			}
+		}
+	case stepWildcard:
+		return children(v)
+	}
+	return nil
+}
+
+func clamp(i, n int) int {
+	if i < 0 {
+		i += n
+	}
+	if i < 0 {
+		return 0
+	}
+	if i > n {
+		return n
+	}
+	return i
+}
+
+// children returns the direct children of v with object keys sorted.
+func children(v interface{}) []interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make([]interface{}, 0, len(keys))
+		for _, k := range keys {
+			out = append(out, t[k])
+		}
+		return out
+	case []interface{}:
+		return append([]interface{}(nil), t...)
+	}
+	return nil
+}
+
+// walk visits v and all of its descendants depth first.
+func walk(v interface{}, fn func(interface{})) {
+	fn(v)
+	for _, c := range children(v) {
+		walk(c, fn)
+	}
+}
@@ -0,0 +1,71 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const sample = `{
+	"device": {"id": "gw-1", "tags": ["a", "b", "c"]},
+	"readings": [
+		{"name": "temp", "value": 21.5},
+		{"name": "hum", "value": 40}
+	]
+}`
+
+func decode(t *testing.T) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(sample), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestEval(t *testing.T) {
+	doc := decode(t)
+	tests := []struct {
+		expr string
+		want []interface{}
+	}{
+		{"$.device.id", []interface{}{"gw-1"}},
+		{".device.id", []interface{}{"gw-1"}},
+		{"device.id", []interface{}{"gw-1"}},
+		{"$['device']['id']", []interface{}{"gw-1"}},
+		{"$.device.tags[1]", []interface{}{"b"}},
+		{"$.device.tags[-1]", []interface{}{"c"}},
+		{"$.device.tags[0:2]", []interface{}{"a", "b"}},
+		{"$.readings[*].name", []interface{}{"temp", "hum"}},
+		{".readings[].value", []interface{}{21.5, 40.0}},
+		{"$..value", []interface{}{21.5, 40.0}},
+		{"$.missing", nil},
+	}
+	for _, tt := range tests {
+		got, err := Eval(doc, tt.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.expr, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s: got %v want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestEvalRoot(t *testing.T) {
+	doc := decode(t)
+	for _, expr := range []string{"$", ".", ""} {
+		got, err := Eval(doc, expr)
+		if err != nil || len(got) != 1 || !reflect.DeepEqual(got[0], doc) {
+			t.Fatalf("%q: expected root, got %v %v", expr, got, err)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, expr := range []string{"$.a[", "$.a[x]", "$..", "$.a..[0]", "$.a.[1:x]"} {
+		if _, err := Compile(expr); err == nil {
+			t.Fatalf("%q: expected error", expr)
+		}
+	}
+}
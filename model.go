@@ -4,8 +4,10 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/marang/emqutiti/charts"
 	"github.com/marang/emqutiti/confirm"
 	"github.com/marang/emqutiti/connections"
+	"github.com/marang/emqutiti/filepublish"
 	"github.com/marang/emqutiti/focus"
 	"github.com/marang/emqutiti/help"
 	"github.com/marang/emqutiti/history"
@@ -13,6 +15,7 @@ import (
 	"github.com/marang/emqutiti/logs"
 	"github.com/marang/emqutiti/message"
 	"github.com/marang/emqutiti/payloads"
+	"github.com/marang/emqutiti/stats"
 	"github.com/marang/emqutiti/topics"
 	"github.com/marang/emqutiti/traces"
 
@@ -112,6 +115,9 @@ type model struct {
 	help        *help.Component
 	logs        *logs.Component
 	importer    *importer.Model
+	filePublish *filepublish.Component
+	chart       *charts.Component
+	stats       *stats.Component
 
 	ui uiState
 
@@ -126,6 +132,9 @@ type model struct {
 
 	focusables map[string]focus.Focusable
 	focus      *focus.FocusMap
+
+	// startProfile is connected on start instead of the default profile.
+	startProfile *connections.Profile
 }
 
 // Focusables returns the base focusable elements managed by the model.
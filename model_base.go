@@ -1,10 +1,13 @@
 package emqutiti
 
 import (
+	"github.com/marang/emqutiti/charts"
 	"github.com/marang/emqutiti/constants"
+	"github.com/marang/emqutiti/filepublish"
 	"github.com/marang/emqutiti/help"
 	"github.com/marang/emqutiti/message"
 	"github.com/marang/emqutiti/payloads"
+	"github.com/marang/emqutiti/stats"
 	"github.com/marang/emqutiti/traces"
 )
 
@@ -35,4 +38,8 @@ var focusByMode = map[constants.AppMode][]string{
 	constants.ModeHistoryDetail:  {idHelp},
 	constants.ModeHelp:           {idHelp},
 	constants.ModeLogs:           {idHelp},
+	constants.ModePublishFile:    {filepublish.ID, idHelp},
+	constants.ModeHistoryColumns: {idHelp},
+	constants.ModeChart:          {charts.ID, idHelp},
+	constants.ModeStats:          {stats.ID, idHelp},
 }
@@ -8,14 +8,17 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/marang/emqutiti/charts"
 	"github.com/marang/emqutiti/confirm"
 	"github.com/marang/emqutiti/connections"
 	"github.com/marang/emqutiti/constants"
+	"github.com/marang/emqutiti/filepublish"
 	"github.com/marang/emqutiti/help"
 	"github.com/marang/emqutiti/history"
 	"github.com/marang/emqutiti/logs"
 	"github.com/marang/emqutiti/message"
 	"github.com/marang/emqutiti/payloads"
+	"github.com/marang/emqutiti/stats"
 	"github.com/marang/emqutiti/topics"
 	"github.com/marang/emqutiti/traces"
 	"github.com/marang/emqutiti/ui"
@@ -82,6 +85,9 @@ func initialModel(conns *connections.Connections) (*model, error) {
 	m.topics = topics.New(m)
 	m.payloads = payloads.New(m, &m.connections)
 	m.traces = traces.NewComponent(m, tr, m.tracesStore())
+	m.filePublish = filepublish.New(m)
+	m.chart = charts.New(m)
+	m.stats = stats.New(m)
 	initComponents(m, order, connComp)
 	m.SetFocus(idTopics)
 	if err := initImporter(m); err != nil {
@@ -94,7 +100,9 @@ func initialModel(conns *connections.Connections) (*model, error) {
 // Init enables initial Tea behavior such as mouse support.
 func (m *model) Init() tea.Cmd {
 	cmds := []tea.Cmd{tea.EnableMouseCellMotion}
-	if profileName == "" {
+	if m.startProfile != nil {
+		cmds = append(cmds, m.Connect(*m.startProfile))
+	} else if profileName == "" {
 		if name := m.connections.Manager.DefaultProfileName; name != "" {
 			for _, p := range m.connections.Manager.Profiles {
 				if p.Name == name {
@@ -40,7 +40,7 @@ func initConnections(conns *connections.Connections) (connections.State, error)
 
 // initComponents registers focusable elements and mode components.
 func initComponents(m *model, order []string, connComp Component) {
-	providers := []focus.FocusableSet{m, m.topics, m.message, m.payloads, m.traces, m.help}
+	providers := []focus.FocusableSet{m, m.topics, m.message, m.payloads, m.traces, m.filePublish, m.chart, m.stats, m.help}
 	m.focusables = map[string]focus.Focusable{}
 	for _, p := range providers {
 		for id, f := range p.Focusables() {
@@ -67,5 +67,9 @@ func initComponents(m *model, order []string, connComp Component) {
 		constants.ModeHistoryDetail:  component{update: m.history.UpdateDetail, view: m.history.ViewDetail},
 		constants.ModeHelp:           m.help,
 		constants.ModeLogs:           m.logs,
+		constants.ModePublishFile:    m.filePublish,
+		constants.ModeHistoryColumns: component{update: m.history.UpdateColumns, view: m.history.ViewColumns},
+		constants.ModeChart:          m.chart,
+		constants.ModeStats:          m.stats,
 	}
 }
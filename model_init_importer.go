@@ -35,7 +35,10 @@ func initImporter(m *model) error {
 	if p == nil {
 		return nil
 	}
-	cfg := *p
+	cfg, err := connections.ResolveProfile(*p, m.connections.Manager.Profiles)
+	if err != nil {
+		return err
+	}
 	if cfg.FromEnv {
 		connections.ApplyEnvVars(&cfg)
 	}
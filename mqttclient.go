@@ -43,8 +43,19 @@ func waitToken(token mqtt.Token, timeout time.Duration, action string) error {
 }
 
 // NewMQTTClient creates and configures a new MQTT client based on the profile
-// details. Status updates are delivered via the provided callback.
+// details. Status updates are delivered via the provided callback. Password
+// references and commands are resolved first; token auth modes generate a
+// fresh password for every connection attempt.
 func NewMQTTClient(p connections.Profile, fn statusFunc) (*MQTTClient, error) {
+	token, err := connections.TokenSource(p)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		if err := connections.ResolvePassword(&p); err != nil {
+			return nil, err
+		}
+	}
 	opts := mqtt.NewClientOptions()
 
 	// Build option list from profile.
@@ -57,6 +68,14 @@ func NewMQTTClient(p connections.Profile, fn statusFunc) (*MQTTClient, error) {
 		mqttclient.WithWill(p.LastWillEnabled, p.LastWillTopic, p.LastWillPayload, p.LastWillQos, p.LastWillRetain),
 	}
 
+	if token != nil {
+		opt, err := mqttclient.WithTokenAuth(p.Username, token)
+		if err != nil {
+			return nil, err
+		}
+		optionFns = append(optionFns, opt)
+	}
+
 	if opt, err := mqttclient.WithVersion(p.MQTTVersion); err != nil {
 		return nil, err
 	} else {
@@ -1,11 +1,18 @@
 package mqttclient
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"sync"
+	"syscall"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
@@ -41,6 +48,68 @@ func WithAuth(user, pass string) ClientOption {
 	}
 }
 
+// WithTokenAuth authenticates with a password from token, fetched again for
+// every connection attempt so that reconnects send a fresh token. The first
+// token is fetched right away to report errors. When a later token cannot be
+// fetched the attempt fails before connecting and is retried like any other
+// failed reconnect.
+func WithTokenAuth(user string, token func() (string, error)) (ClientOption, error) {
+	first, err := token()
+	if err != nil {
+		return nil, fmt.Errorf("auth token: %w", err)
+	}
+	var (
+		mu     sync.Mutex
+		failed error
+	)
+	check := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		return failed
+	}
+	return func(o *mqtt.ClientOptions) {
+		o.SetCredentialsProvider(func() (string, string) {
+			mu.Lock()
+			defer mu.Unlock()
+			pw := first
+			first, failed = "", nil
+			if pw == "" {
+				var err error
+				if pw, err = token(); err != nil {
+					failed = fmt.Errorf("auth token: %w", err)
+					log.Print(failed)
+				}
+			}
+			return user, pw
+		})
+		// the credentials are read before dialing, so a failed token stops
+		// the attempt at the dialer of TCP, TLS and WebSocket connections
+		dialer := net.Dialer{Timeout: 30 * time.Second}
+		if o.Dialer != nil {
+			dialer = *o.Dialer
+		}
+		dialer.ControlContext = func(context.Context, string, string, syscall.RawConn) error {
+			return check()
+		}
+		o.SetDialer(&dialer)
+		ws := mqtt.WebsocketOptions{}
+		if o.WebsocketOptions != nil {
+			ws = *o.WebsocketOptions
+		}
+		proxy := ws.Proxy
+		if proxy == nil {
+			proxy = http.ProxyFromEnvironment
+		}
+		ws.Proxy = func(req *http.Request) (*url.URL, error) {
+			if err := check(); err != nil {
+				return nil, err
+			}
+			return proxy(req)
+		}
+		o.SetWebsocketOptions(&ws)
+	}, nil
+}
+
 // WithVersion sets the MQTT protocol version if specified.
 func WithVersion(ver string) (ClientOption, error) {
 	if ver == "" {
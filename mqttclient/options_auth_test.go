@@ -0,0 +1,81 @@
+package mqttclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+func TestWithTokenAuthRefreshes(t *testing.T) {
+	n := 0
+	token := func() (string, error) {
+		n++
+		return fmt.Sprintf("tok%d", n), nil
+	}
+	opt, err := WithTokenAuth("dev", token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := mqtt.NewClientOptions()
+	opt(o)
+	for _, want := range []string{"tok1", "tok2", "tok3"} {
+		if user, pw := o.CredentialsProvider(); user != "dev" || pw != want {
+			t.Fatalf("expected dev/%s, got %s/%s", want, user, pw)
+		}
+	}
+	if _, err := WithTokenAuth("dev", func() (string, error) { return "", errors.New("boom") }); err == nil {
+		t.Fatalf("expected error from the first token")
+	}
+}
+
+func TestWithTokenAuthFailsAttempt(t *testing.T) {
+	fail := false
+	token := func() (string, error) {
+		if fail {
+			return "", errors.New("signer gone")
+		}
+		return "tok", nil
+	}
+	opt, err := WithTokenAuth("dev", token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := mqtt.NewClientOptions()
+	opt(o)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	req, _ := http.NewRequest(http.MethodGet, "ws://"+ln.Addr().String(), nil)
+
+	o.CredentialsProvider() // first token
+	fail = true
+	if _, pw := o.CredentialsProvider(); pw != "" {
+		t.Fatalf("unexpected password %q", pw)
+	}
+	if _, err := o.Dialer.Dial("tcp", ln.Addr().String()); err == nil || !strings.Contains(err.Error(), "signer gone") {
+		t.Fatalf("expected the attempt to fail with the token error, got %v", err)
+	}
+	if _, err := o.WebsocketOptions.Proxy(req); err == nil {
+		t.Fatalf("expected the websocket attempt to fail")
+	}
+
+	fail = false
+	if _, pw := o.CredentialsProvider(); pw != "tok" {
+		t.Fatalf("expected a fresh token, got %q", pw)
+	}
+	conn, err := o.Dialer.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial after a good token: %v", err)
+	}
+	conn.Close()
+	if _, err := o.WebsocketOptions.Proxy(req); err != nil {
+		t.Fatalf("websocket proxy: %v", err)
+	}
+}
@@ -436,6 +436,7 @@ type StatusResponse struct {
 	Writes        uint64                 `protobuf:"varint,3,opt,name=writes,proto3" json:"writes,omitempty"`
 	Deletes       uint64                 `protobuf:"varint,4,opt,name=deletes,proto3" json:"deletes,omitempty"`
 	Clients       int64                  `protobuf:"varint,5,opt,name=clients,proto3" json:"clients,omitempty"`
+	Traces        []*TraceStatus         `protobuf:"bytes,6,rep,name=traces,proto3" json:"traces,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -505,6 +506,97 @@ func (x *StatusResponse) GetClients() int64 {
 	return 0
 }
 
+func (x *StatusResponse) GetTraces() []*TraceStatus {
+	if x != nil {
+		return x.Traces
+	}
+	return nil
+}
+
+type TraceStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Profile       string                 `protobuf:"bytes,2,opt,name=profile,proto3" json:"profile,omitempty"`
+	State         string                 `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	Messages      int64                  `protobuf:"varint,4,opt,name=messages,proto3" json:"messages,omitempty"`
+	Next          int64                  `protobuf:"varint,5,opt,name=next,proto3" json:"next,omitempty"`
+	Error         string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TraceStatus) Reset() {
+	*x = TraceStatus{}
+	mi := &file_proxy_proxy_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TraceStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TraceStatus) ProtoMessage() {}
+
+func (x *TraceStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_proxy_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TraceStatus.ProtoReflect.Descriptor instead.
+func (*TraceStatus) Descriptor() ([]byte, []int) {
+	return file_proxy_proxy_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TraceStatus) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *TraceStatus) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *TraceStatus) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *TraceStatus) GetMessages() int64 {
+	if x != nil {
+		return x.Messages
+	}
+	return 0
+}
+
+func (x *TraceStatus) GetNext() int64 {
+	if x != nil {
+		return x.Next
+	}
+	return 0
+}
+
+func (x *TraceStatus) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
 var File_proxy_proxy_proto protoreflect.FileDescriptor
 
 const file_proxy_proxy_proto_rawDesc = "" +
@@ -532,13 +624,21 @@ const file_proxy_proxy_proto_rawDesc = "" +
 	"\aprofile\x18\x01 \x01(\tR\aprofile\x12\x16\n" +
 	"\x06bucket\x18\x02 \x01(\tR\x06bucket\x12\x12\n" +
 	"\x04size\x18\x03 \x01(\x04R\x04size\x12\x18\n" +
-	"\aentries\x18\x04 \x01(\x04R\aentries\"\x93\x01\n" +
+	"\aentries\x18\x04 \x01(\x04R\aentries\"\xbf\x01\n" +
 	"\x0eStatusResponse\x12\x1f\n" +
 	"\x03dbs\x18\x01 \x03(\v2\r.proxy.DBInfoR\x03dbs\x12\x14\n" +
 	"\x05reads\x18\x02 \x01(\x04R\x05reads\x12\x16\n" +
 	"\x06writes\x18\x03 \x01(\x04R\x06writes\x12\x18\n" +
 	"\adeletes\x18\x04 \x01(\x04R\adeletes\x12\x18\n" +
-	"\aclients\x18\x05 \x01(\x03R\aclients2\xdc\x01\n" +
+	"\aclients\x18\x05 \x01(\x03R\aclients\x12*\n" +
+	"\x06traces\x18\x06 \x03(\v2\x12.proxy.TraceStatusR\x06traces\"\x95\x01\n" +
+	"\vTraceStatus\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\aprofile\x18\x02 \x01(\tR\aprofile\x12\x14\n" +
+	"\x05state\x18\x03 \x01(\tR\x05state\x12\x1a\n" +
+	"\bmessages\x18\x04 \x01(\x03R\bmessages\x12\x12\n" +
+	"\x04next\x18\x05 \x01(\x03R\x04next\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error2\xdc\x01\n" +
 	"\aDBProxy\x122\n" +
 	"\x05Write\x12\x13.proxy.WriteRequest\x1a\x14.proxy.WriteResponse\x12/\n" +
 	"\x04Read\x12\x12.proxy.ReadRequest\x1a\x13.proxy.ReadResponse\x125\n" +
@@ -557,7 +657,7 @@ func file_proxy_proxy_proto_rawDescGZIP() []byte {
 	return file_proxy_proxy_proto_rawDescData
 }
 
-var file_proxy_proxy_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_proxy_proxy_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_proxy_proxy_proto_goTypes = []any{
 	(*WriteRequest)(nil),   // 0: proxy.WriteRequest
 	(*WriteResponse)(nil),  // 1: proxy.WriteResponse
@@ -568,22 +668,24 @@ var file_proxy_proxy_proto_goTypes = []any{
 	(*StatusRequest)(nil),  // 6: proxy.StatusRequest
 	(*DBInfo)(nil),         // 7: proxy.DBInfo
 	(*StatusResponse)(nil), // 8: proxy.StatusResponse
+	(*TraceStatus)(nil),    // 9: proxy.TraceStatus
 }
 var file_proxy_proxy_proto_depIdxs = []int32{
 	7, // 0: proxy.StatusResponse.dbs:type_name -> proxy.DBInfo
-	0, // 1: proxy.DBProxy.Write:input_type -> proxy.WriteRequest
-	2, // 2: proxy.DBProxy.Read:input_type -> proxy.ReadRequest
-	4, // 3: proxy.DBProxy.Delete:input_type -> proxy.DeleteRequest
-	6, // 4: proxy.DBProxy.Status:input_type -> proxy.StatusRequest
-	1, // 5: proxy.DBProxy.Write:output_type -> proxy.WriteResponse
-	3, // 6: proxy.DBProxy.Read:output_type -> proxy.ReadResponse
-	5, // 7: proxy.DBProxy.Delete:output_type -> proxy.DeleteResponse
-	8, // 8: proxy.DBProxy.Status:output_type -> proxy.StatusResponse
-	5, // [5:9] is the sub-list for method output_type
-	1, // [1:5] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	9, // 1: proxy.StatusResponse.traces:type_name -> proxy.TraceStatus
+	0, // 2: proxy.DBProxy.Write:input_type -> proxy.WriteRequest
+	2, // 3: proxy.DBProxy.Read:input_type -> proxy.ReadRequest
+	4, // 4: proxy.DBProxy.Delete:input_type -> proxy.DeleteRequest
+	6, // 5: proxy.DBProxy.Status:input_type -> proxy.StatusRequest
+	1, // 6: proxy.DBProxy.Write:output_type -> proxy.WriteResponse
+	3, // 7: proxy.DBProxy.Read:output_type -> proxy.ReadResponse
+	5, // 8: proxy.DBProxy.Delete:output_type -> proxy.DeleteResponse
+	8, // 9: proxy.DBProxy.Status:output_type -> proxy.StatusResponse
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_proxy_proxy_proto_init() }
@@ -597,7 +699,7 @@ func file_proxy_proxy_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proxy_proxy_proto_rawDesc), len(file_proxy_proxy_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   9,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -29,6 +29,8 @@ type Proxy struct {
 	writes  uint64
 	deletes uint64
 	clients int64
+
+	traces func() []*TraceStatus
 }
 
 var (
@@ -70,6 +72,14 @@ func (p *Proxy) Stop() {
 	proxyMu.Unlock()
 }
 
+// SetTraceStatus registers fn to report the traces run by this process in
+// Status responses.
+func (p *Proxy) SetTraceStatus(fn func() []*TraceStatus) {
+	p.mu.Lock()
+	p.traces = fn
+	p.mu.Unlock()
+}
+
 // Addr returns the listening address.
 func (p *Proxy) Addr() string { return p.lis.Addr().String() }
 
@@ -168,8 +178,17 @@ func (p *Proxy) Delete(ctx context.Context, req *DeleteRequest) (*DeleteResponse
 	return &DeleteResponse{}, nil
 }
 
-// Status reports usage and database size metrics.
+// Status reports usage and database size metrics and the traces run by the
+// proxy process.
 func (p *Proxy) Status(ctx context.Context, _ *StatusRequest) (*StatusResponse, error) {
+	// the callback takes the locks of its tracers, so call it unlocked
+	p.mu.Lock()
+	traceStatus := p.traces
+	p.mu.Unlock()
+	var traces []*TraceStatus
+	if traceStatus != nil {
+		traces = traceStatus()
+	}
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	infos := make([]*DBInfo, 0, len(p.dbs))
@@ -201,6 +220,7 @@ func (p *Proxy) Status(ctx context.Context, _ *StatusRequest) (*StatusResponse,
 		Writes:  atomic.LoadUint64(&p.writes),
 		Deletes: atomic.LoadUint64(&p.deletes),
 		Clients: atomic.LoadInt64(&p.clients),
+		Traces:  traces,
 	}, nil
 }
 
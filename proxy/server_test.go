@@ -3,6 +3,7 @@ package proxy
 import (
 	"context"
 	"testing"
+	"time"
 )
 
 func TestWriteRead(t *testing.T) {
@@ -70,3 +71,53 @@ func TestProxySingleInstance(t *testing.T) {
 		t.Fatalf("expected error starting second proxy")
 	}
 }
+
+func TestStatusTraces(t *testing.T) {
+	p, err := StartProxy("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("start proxy: %v", err)
+	}
+	defer p.Stop()
+	p.SetTraceStatus(func() []*TraceStatus {
+		return []*TraceStatus{{Key: "night", Profile: "local", State: "running", Messages: 3}}
+	})
+	client, conn, err := NewClient(p.Addr())
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	defer conn.Close()
+	st, err := client.Status(context.Background(), &StatusRequest{})
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	tr := st.GetTraces()
+	if len(tr) != 1 || tr[0].GetKey() != "night" || tr[0].GetState() != "running" || tr[0].GetMessages() != 3 {
+		t.Fatalf("unexpected traces: %+v", tr)
+	}
+}
+
+func TestStatusCallsTracesUnlocked(t *testing.T) {
+	p, err := StartProxy("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("start proxy: %v", err)
+	}
+	defer p.Stop()
+	// a tracer writing while its status is collected needs the proxy lock
+	p.SetTraceStatus(func() []*TraceStatus {
+		p.Write(context.Background(), &WriteRequest{Profile: "local", Bucket: "traces", Key: "k", Value: []byte("v")})
+		return nil
+	})
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Status(context.Background(), &StatusRequest{})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("status: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("status deadlocked")
+	}
+}
@@ -1,6 +1,7 @@
 package emqutiti
 
 import (
+	"context"
 	"log"
 	"net"
 	"time"
@@ -36,3 +37,32 @@ func realInitProxy() (string, *proxy.Proxy) {
 }
 
 var initProxy = realInitProxy
+
+// proxyCheck is how often watchProxy probes the proxy address.
+var proxyCheck = 5 * time.Second
+
+// watchProxy starts a proxy on addr once the process serving it exits and
+// passes it to started. It returns when ctx ends or a proxy was started.
+func watchProxy(ctx context.Context, addr string, started func(*proxy.Proxy)) {
+	ticker := time.NewTicker(proxyCheck)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if conn, err := net.DialTimeout("tcp", addr, 100*time.Millisecond); err == nil {
+			conn.Close()
+			continue
+		}
+		p, err := proxy.StartProxy(addr)
+		if err != nil {
+			log.Printf("proxy start failed: %v", err)
+			continue
+		}
+		log.Printf("serving proxy at %s", addr)
+		started(p)
+		return
+	}
+}
@@ -3,19 +3,26 @@ package emqutiti
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	connections "github.com/marang/emqutiti/connections"
 	history "github.com/marang/emqutiti/history"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/term"
 
 	cfg "github.com/marang/emqutiti/cmd"
 	"github.com/marang/emqutiti/constants"
+	"github.com/marang/emqutiti/filepublish"
 	"github.com/marang/emqutiti/importer"
 	"github.com/marang/emqutiti/importer/steps"
+	"github.com/marang/emqutiti/proxy"
 	"github.com/marang/emqutiti/traces"
 )
 
@@ -48,6 +55,12 @@ type mqttClient interface {
 
 type ModeRunner func(*appDeps) error
 
+// daemon runs planned traces headlessly and reports their state.
+type daemon interface {
+	Run(context.Context) error
+	Status() []*proxy.TraceStatus
+}
+
 type appDeps struct {
 	importFile  string
 	profileName string
@@ -56,8 +69,17 @@ type appDeps struct {
 	traceStart  string
 	traceEnd    string
 
+	trigger traces.Trigger
+	publish filepublish.Options
+
 	traceStore traces.Store
-	traceRun   func(context.Context, string, string, string, string, string) error
+	traceRun   func(context.Context, string, string, string, string, string, traces.Trigger) error
+	newDaemon  func() daemon
+	annotate   func(key, profile, text string, at time.Time) error
+	search     func(traces.SearchQuery) ([]traces.SearchResult, error)
+	batch      func(context.Context, steps.Publisher, importer.Options, io.Writer) (importer.Summary, error)
+
+	filePublish func(context.Context, filepublish.Publisher, filepublish.Options, io.Writer) error
 
 	loadProfile   func(string, string) (*connections.Profile, error)
 	newMQTTClient func(connections.Profile, statusFunc) (mqttClient, error)
@@ -67,14 +89,36 @@ type appDeps struct {
 
 	runners map[string]ModeRunner
 
-	proxyAddr string
-	timeout   time.Duration
+	// profiles command settings; passphrase asks for the secrets key.
+	action        string
+	args          []string
+	bundleFile    string
+	bundleSecrets bool
+	onConflict    string
+	profileFormat string
+	allowCommands bool
+	passphrase    func(prompt string) (string, error)
+
+	command    string
+	connectURL string
+	batchOpts  importer.Options
+	exit       func(int)
+	note       string
+	noteAt     string
+	proxy      *proxy.Proxy
+	proxyAddr  string
+	timeout    time.Duration
 }
 
 func newAppDeps() *appDeps {
 	d := &appDeps{
 		traceStore:    traces.FileStore{},
 		traceRun:      traces.Run,
+		newDaemon:     func() daemon { return traces.NewDaemon() },
+		annotate:      traces.Annotate,
+		search:        traces.Search,
+		batch:         importer.Run,
+		filePublish:   filepublish.Run,
 		loadProfile:   connections.LoadProfile,
 		newMQTTClient: func(p connections.Profile, fn statusFunc) (mqttClient, error) { return NewMQTTClient(p, fn) },
 		newImporter:   importer.New,
@@ -82,11 +126,19 @@ func newAppDeps() *appDeps {
 		newProgram: func(m tea.Model, opts ...tea.ProgramOption) program {
 			return tea.NewProgram(m, opts...)
 		},
+		passphrase: readPassphrase,
+		exit:       os.Exit,
 	}
 	d.runners = map[string]ModeRunner{
-		"trace":  runTrace,
-		"import": runImport,
-		"ui":     runUI,
+		"trace":    runTrace,
+		"import":   runImport,
+		"publish":  runPublishFile,
+		"ui":       runUI,
+		"daemon":   runDaemon,
+		"annotate": runAnnotate,
+		"search":   runSearch,
+		"batch":    runBatchImport,
+		"profiles": runProfiles,
 	}
 	return d
 }
@@ -112,17 +164,73 @@ func runMain(d *appDeps, c cfg.AppConfig) {
 	d.traceStart = c.TraceStart
 	d.traceEnd = c.TraceEnd
 	d.timeout = c.Timeout
+	d.command = c.Command
+	d.connectURL = c.ConnectURL
+	d.note = c.Note
+	d.action = c.Action
+	d.args = c.Args
+	d.bundleFile = c.BundleFile
+	d.bundleSecrets = c.BundleSecrets
+	d.onConflict = c.OnConflict
+	d.profileFormat = c.ProfileFormat
+	d.allowCommands = c.AllowCommands
+	d.noteAt = c.NoteAt
+	d.trigger = traces.Trigger{
+		StartTopic:  c.TriggerTopic,
+		StartWhen:   c.TriggerWhen,
+		StopTopic:   c.StopTopic,
+		StopWhen:    c.StopWhen,
+		StopAfter:   c.StopAfter,
+		MaxDuration: c.MaxDuration,
+		PreTrigger:  c.PreTrigger,
+	}
+	d.batchOpts = importer.Options{
+		File:           c.PublishFile,
+		MappingFile:    c.ImportMapping,
+		Preset:         c.ImportPreset,
+		Template:       c.ImportTemplate,
+		Payload:        c.ImportPayload,
+		ReadOptions:    c.ReadOptions,
+		PublishOptions: c.PublishOptions,
+		DryRun:         c.DryRun,
+	}
+	if d.batchOpts.File == "" {
+		d.batchOpts.File = c.ImportFile
+	}
+	if c.QoSSet {
+		qos := byte(c.QoS)
+		d.batchOpts.QoS = &qos
+	}
+	if c.RetainSet {
+		d.batchOpts.Retain = &c.Retain
+	}
+	d.publish = filepublish.Options{
+		Path:       c.PublishFile,
+		Topic:      c.PublishTopic,
+		ChunkSize:  c.ChunkSize,
+		ChunkTopic: c.ChunkTopic,
+		QoS:        byte(c.QoS),
+		Retain:     c.Retain,
+	}
 
-	addr, _ := initProxy()
+	addr, p := initProxy()
 	history.SetProxyAddr(addr)
 	traces.SetProxyAddr(addr)
 	d.proxyAddr = addr
+	d.proxy = p
 
 	mode := "ui"
-	if d.traceKey != "" {
+	if d.command == "import" {
+		// the import command runs without the wizard
+		mode = "batch"
+	} else if d.command != "" {
+		mode = d.command
+	} else if d.traceKey != "" {
 		mode = "trace"
 	} else if d.importFile != "" {
 		mode = "import"
+	} else if d.publish.Path != "" {
+		mode = "publish"
 	}
 
 	if runner, ok := d.runners[mode]; ok {
@@ -131,6 +239,9 @@ func runMain(d *appDeps, c cfg.AppConfig) {
 				log.Fatalf("Error running program: %v", err)
 			}
 			log.Println(err)
+			if d.exit != nil {
+				d.exit(1)
+			}
 		}
 	}
 }
@@ -163,6 +274,9 @@ func runTrace(d *appDeps) error {
 			return fmt.Errorf("trace end time already passed")
 		}
 	}
+	if err := d.trigger.Validate(); err != nil {
+		return fmt.Errorf("invalid trigger: %w", err)
+	}
 	exists, err := d.traceStore.HasData(d.profileName, d.traceKey)
 	if err != nil {
 		return fmt.Errorf("trace data check failed: %w", err)
@@ -176,11 +290,192 @@ func runTrace(d *appDeps) error {
 		Start:   start,
 		End:     end,
 		Key:     d.traceKey,
+		Trigger: d.trigger,
 	}
 	if err := d.traceStore.AddTrace(cfg); err != nil {
 		return err
 	}
-	return d.traceRun(ctx, d.traceKey, d.traceTopics, d.profileName, d.traceStart, d.traceEnd)
+	return d.traceRun(ctx, d.traceKey, d.traceTopics, d.profileName, d.traceStart, d.traceEnd, d.trigger)
+}
+
+// runDaemon runs all planned traces until interrupted. The daemon serves
+// the DB proxy so the UI can show its traces; when another process owns the
+// proxy the daemon takes over once that process exits.
+func runDaemon(d *appDeps) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if d.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+	dm := d.newDaemon()
+	report := func(p *proxy.Proxy) { p.SetTraceStatus(dm.Status) }
+	if d.proxy != nil {
+		report(d.proxy)
+	} else {
+		log.Printf("proxy at %s is served by another process, daemon traces are not reported", d.proxyAddr)
+		go watchProxy(ctx, d.proxyAddr, report)
+	}
+	return dm.Run(ctx)
+}
+
+// runAnnotate adds an annotation to a trace, e.g. while it runs headless.
+func runAnnotate(d *appDeps) error {
+	var at time.Time
+	if d.noteAt != "" {
+		var err error
+		at, err = time.Parse(time.RFC3339, d.noteAt)
+		if err != nil {
+			return fmt.Errorf("invalid annotation time: %w", err)
+		}
+	}
+	if err := d.annotate(d.traceKey, d.profileName, d.note, at); err != nil {
+		return fmt.Errorf("annotate: %w", err)
+	}
+	return nil
+}
+
+// runSearch prints the trace messages matching the query, grouped by trace.
+func runSearch(d *appDeps) error {
+	results, err := d.search(traces.ParseSearch(d.note))
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+	if len(results) == 0 {
+		fmt.Println("no matching messages")
+		return nil
+	}
+	traces.WriteResults(os.Stdout, results)
+	return nil
+}
+
+// runBatchImport imports a file without the wizard, e.g. in pipelines. Dry
+// runs do not connect to the broker.
+func runBatchImport(d *appDeps) error {
+	if d.batchOpts.File == "" {
+		return fmt.Errorf("import: --file is required")
+	}
+	var client steps.Publisher
+	if !d.batchOpts.DryRun {
+		p, err := d.loadProfile(d.profileName, "")
+		if err != nil {
+			return fmt.Errorf("error loading profile: %w", err)
+		}
+		connections.ApplyDefaultPassword(p)
+		c, err := d.newMQTTClient(*p, nil)
+		if err != nil {
+			return fmt.Errorf("connect error: %w", err)
+		}
+		defer c.Disconnect()
+		client = c
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if _, err := d.batch(ctx, client, d.batchOpts, os.Stdout); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	return nil
+}
+
+// runProfiles exports profiles to a bundle file or imports one.
+func runProfiles(d *appDeps) error {
+	switch d.action {
+	case "export":
+		return exportProfiles(d)
+	case "import":
+		return importProfiles(d)
+	}
+	return fmt.Errorf("profiles: unknown action %q, use export or import", d.action)
+}
+
+func exportProfiles(d *appDeps) error {
+	c, err := connections.LoadConfig("")
+	if err != nil {
+		return fmt.Errorf("profiles: %w", err)
+	}
+	var pass string
+	if d.bundleSecrets {
+		if pass, err = d.passphrase("Passphrase for the exported passwords: "); err != nil {
+			return fmt.Errorf("profiles: %w", err)
+		}
+		if pass == "" {
+			return fmt.Errorf("profiles: --secrets needs a passphrase")
+		}
+	}
+	b, err := connections.NewBundle(c.Profiles, connections.LoadState(), d.args, pass)
+	if err != nil {
+		return fmt.Errorf("profiles: %w", err)
+	}
+	if err := connections.WriteBundle(d.bundleFile, b); err != nil {
+		return fmt.Errorf("profiles: %w", err)
+	}
+	fmt.Printf("exported %d profiles to %s\n", len(b.Profiles), d.bundleFile)
+	return nil
+}
+
+// importProfiles imports a bundle or another client's connections, printing
+// a preview first. Dry runs stop after the preview. Password commands are
+// only imported with --allow-commands.
+func importProfiles(d *appDeps) error {
+	if len(d.args) != 1 {
+		return fmt.Errorf("profiles: import needs exactly one file")
+	}
+	policy, err := connections.ParseConflict(d.onConflict)
+	if err != nil {
+		return fmt.Errorf("profiles: %w", err)
+	}
+	b, notes, err := connections.ReadProfiles(d.args[0], d.profileFormat)
+	if err != nil {
+		return fmt.Errorf("profiles: %w", err)
+	}
+	if b.HasSecrets() && !d.batchOpts.DryRun {
+		pass, err := d.passphrase("Passphrase of the bundle: ")
+		if err != nil {
+			return fmt.Errorf("profiles: %w", err)
+		}
+		if err := b.Decrypt(pass); err != nil {
+			return fmt.Errorf("profiles: %w", err)
+		}
+	}
+	for _, line := range b.Preview() {
+		fmt.Println(line)
+	}
+	for _, n := range notes {
+		fmt.Println("note: " + n)
+	}
+	if cmds := b.Commands(); len(cmds) > 0 && !d.allowCommands {
+		for _, c := range cmds {
+			fmt.Println("note: " + c + ", left out without --allow-commands")
+		}
+	}
+	if d.batchOpts.DryRun {
+		fmt.Printf("dry run, %d profiles not saved\n", len(b.Profiles))
+		return nil
+	}
+	results, err := connections.ImportBundle(b, policy, d.allowCommands)
+	if err != nil {
+		return fmt.Errorf("profiles: %w", err)
+	}
+	for _, r := range results {
+		fmt.Println(r)
+	}
+	return nil
+}
+
+// readPassphrase takes the bundle passphrase from EMQUTITI_BUNDLE_PASSPHRASE
+// or asks for it on the terminal.
+func readPassphrase(prompt string) (string, error) {
+	if p := os.Getenv("EMQUTITI_BUNDLE_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no passphrase, set EMQUTITI_BUNDLE_PASSPHRASE")
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	b, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return string(b), err
 }
 
 // runImport launches the interactive import wizard using the provided file
@@ -200,17 +495,61 @@ func runImport(d *appDeps) error {
 
 	w := d.newImporter(client, d.importFile)
 	prog := d.newProgram(importerTeaModel{w}, tea.WithAltScreen())
+	defer w.Close()
 	if _, err := prog.Run(); err != nil {
 		return fmt.Errorf("import error: %w", err)
 	}
 	return nil
 }
 
+// runPublishFile publishes a file to the broker without starting the UI.
+func runPublishFile(d *appDeps) error {
+	if d.publish.QoS > 2 {
+		return fmt.Errorf("invalid qos %d", d.publish.QoS)
+	}
+	p, err := d.loadProfile(d.profileName, "")
+	if err != nil {
+		return fmt.Errorf("error loading profile: %w", err)
+	}
+	connections.ApplyDefaultPassword(p)
+
+	client, err := d.newMQTTClient(*p, nil)
+	if err != nil {
+		return fmt.Errorf("connect error: %w", err)
+	}
+	defer client.Disconnect()
+
+	ctx := context.Background()
+	if d.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+	if err := d.filePublish(ctx, client, d.publish, os.Stdout); err != nil {
+		return fmt.Errorf("publish error: %w", err)
+	}
+	return nil
+}
+
+// runUI starts the interactive program. A broker URL given on the command
+// line is connected with an unsaved profile instead of the default one.
 func runUI(d *appDeps) error {
+	var adhoc connections.Profile
+	var topics []connections.TopicSnapshot
+	if d.connectURL != "" {
+		var err error
+		if adhoc, topics, err = connections.ParseURL(d.connectURL); err != nil {
+			return err
+		}
+	}
 	initial, err := d.initialModel(nil)
 	if err != nil {
 		log.Printf("Warning: %v", err)
 	}
+	if d.connectURL != "" {
+		p := initial.connections.AddEphemeral(adhoc, topics)
+		initial.startProfile = &p
+	}
 	log.SetFlags(0)
 	log.SetOutput(initial.logs)
 	stop := startProxyStatusLogger(d.proxyAddr)
@@ -3,18 +3,23 @@ package emqutiti
 import (
 	"context"
 	"errors"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	cfg "github.com/marang/emqutiti/cmd"
 	connections "github.com/marang/emqutiti/connections"
+	"github.com/marang/emqutiti/filepublish"
 	"github.com/marang/emqutiti/help"
 	"github.com/marang/emqutiti/history"
 	"github.com/marang/emqutiti/importer"
 	"github.com/marang/emqutiti/importer/steps"
 	"github.com/marang/emqutiti/proxy"
 	"github.com/marang/emqutiti/traces"
+	"github.com/zalando/go-keyring"
 )
 
 type stubTraceStore struct {
@@ -73,7 +78,7 @@ func TestRunTrace(t *testing.T) {
 		traceTopics: "a,b",
 		profileName: "p",
 		traceStore:  st,
-		traceRun: func(ctx context.Context, k, tp, pf, stt, end string) error {
+		traceRun: func(ctx context.Context, k, tp, pf, stt, end string, _ traces.Trigger) error {
 			called = true
 			if k != "k" || tp != "a,b" || pf != "p" {
 				t.Fatalf("unexpected args %v %v %v", k, tp, pf)
@@ -100,7 +105,7 @@ func TestRunTraceEndPast(t *testing.T) {
 		profileName: "p",
 		traceEnd:    past,
 		traceStore:  &stubTraceStore{},
-		traceRun:    func(context.Context, string, string, string, string, string) error { return nil },
+		traceRun:    func(context.Context, string, string, string, string, string, traces.Trigger) error { return nil },
 	}
 	if err := runTrace(d); err == nil {
 		t.Fatalf("expected error for past end time")
@@ -114,7 +119,7 @@ func TestRunTraceTimeout(t *testing.T) {
 		traceTopics: "t",
 		profileName: "p",
 		traceStore:  st,
-		traceRun: func(ctx context.Context, k, tp, pf, stt, end string) error {
+		traceRun: func(ctx context.Context, k, tp, pf, stt, end string, _ traces.Trigger) error {
 			<-ctx.Done()
 			return ctx.Err()
 		},
@@ -220,6 +225,56 @@ func TestRunImport(t *testing.T) {
 	}
 }
 
+func TestMainDispatchPublishFile(t *testing.T) {
+	orig := initProxy
+	initProxy = func() (string, *proxy.Proxy) { return "", nil }
+	defer func() { initProxy = orig }()
+	called := false
+	d := newAppDeps()
+	d.runners["publish"] = func(ad *appDeps) error {
+		called = true
+		if ad.publish.Path != "fw.bin" || ad.publish.Topic != "dev/fw" || ad.publish.ChunkSize != 4 || ad.publish.QoS != 1 {
+			t.Fatalf("unexpected params %+v", ad.publish)
+		}
+		return nil
+	}
+	d.runners["import"] = func(*appDeps) error { t.Fatalf("runImport called"); return nil }
+	d.runners["ui"] = func(*appDeps) error { t.Fatalf("runUI called"); return nil }
+	runMain(d, cfg.AppConfig{PublishFile: "fw.bin", PublishTopic: "dev/fw", ChunkSize: 4, QoS: 1})
+	if !called {
+		t.Fatalf("runPublishFile not called")
+	}
+}
+
+func TestRunPublishFile(t *testing.T) {
+	client := &stubMQTTClient{}
+	var got filepublish.Options
+	d := &appDeps{
+		profileName: "pr",
+		publish:     filepublish.Options{Path: "fw.bin", Topic: "dev/fw"},
+		loadProfile: func(string, string) (*connections.Profile, error) {
+			return &connections.Profile{}, nil
+		},
+		newMQTTClient: func(connections.Profile, statusFunc) (mqttClient, error) { return client, nil },
+		filePublish: func(_ context.Context, p filepublish.Publisher, o filepublish.Options, _ io.Writer) error {
+			if p != client {
+				t.Fatalf("unexpected publisher")
+			}
+			got = o
+			return nil
+		},
+	}
+	if err := runPublishFile(d); err != nil {
+		t.Fatalf("runPublishFile error: %v", err)
+	}
+	if got.Path != "fw.bin" || got.Topic != "dev/fw" {
+		t.Fatalf("unexpected options %+v", got)
+	}
+	if !client.disconnected {
+		t.Fatalf("client not disconnected")
+	}
+}
+
 func TestRunUI(t *testing.T) {
 	st := &stubHistoryStore{}
 	d := &appDeps{
@@ -240,3 +295,235 @@ func TestRunUI(t *testing.T) {
 		t.Fatalf("store not closed")
 	}
 }
+
+func TestRunUIConnectURL(t *testing.T) {
+	var started *model
+	d := &appDeps{
+		connectURL: "mqtt://alice@broker.local/?topics=a/#",
+		initialModel: func(*connections.Connections) (*model, error) {
+			m := &model{help: &help.Component{}, history: history.NewComponent(nil, &stubHistoryStore{})}
+			m.connections.Manager = connections.NewConnectionsModel()
+			return m, nil
+		},
+		newProgram: func(m tea.Model, opts ...tea.ProgramOption) program {
+			started = m.(*model)
+			return stubProgram{run: func() (tea.Model, error) { return m, nil }}
+		},
+	}
+	if err := runUI(d); err != nil {
+		t.Fatalf("runUI error: %v", err)
+	}
+	p := started.startProfile
+	if p == nil || p.Name != "alice@broker.local" || !p.Ephemeral || len(started.connections.Manager.Profiles) != 1 {
+		t.Fatalf("unexpected start profile %+v", p)
+	}
+	if ts, _ := started.connections.RestoreState(p.Name); len(ts) != 1 || ts[0].Title != "a/#" {
+		t.Fatalf("unexpected topics %+v", ts)
+	}
+	d.connectURL = "mqtt://broker.local/?bogus=1"
+	if err := runUI(d); err == nil {
+		t.Fatalf("expected url error")
+	}
+}
+
+type stubDaemon struct{ ran bool }
+
+func (s *stubDaemon) Run(context.Context) error { s.ran = true; return nil }
+func (s *stubDaemon) Status() []*proxy.TraceStatus {
+	return []*proxy.TraceStatus{{Key: "night", State: traces.DaemonRunning}}
+}
+
+func TestMainDispatchDaemon(t *testing.T) {
+	orig := initProxy
+	initProxy = func() (string, *proxy.Proxy) { return "", nil }
+	defer func() { initProxy = orig }()
+	called := false
+	d := newAppDeps()
+	d.runners["daemon"] = func(ad *appDeps) error { called = true; return nil }
+	d.runners["trace"] = func(*appDeps) error { t.Fatalf("runTrace called"); return nil }
+	d.runners["ui"] = func(*appDeps) error { t.Fatalf("runUI called"); return nil }
+	runMain(d, cfg.AppConfig{Command: "daemon", TraceKey: "k"})
+	if !called {
+		t.Fatalf("runDaemon not called")
+	}
+}
+
+func TestRunDaemonReportsStatus(t *testing.T) {
+	p, err := proxy.StartProxy("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("start proxy: %v", err)
+	}
+	defer p.Stop()
+	dm := &stubDaemon{}
+	d := &appDeps{proxy: p, proxyAddr: p.Addr(), newDaemon: func() daemon { return dm }}
+	if err := runDaemon(d); err != nil {
+		t.Fatalf("runDaemon: %v", err)
+	}
+	if !dm.ran {
+		t.Fatalf("daemon not run")
+	}
+	st, err := p.Status(context.Background(), &proxy.StatusRequest{})
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if len(st.GetTraces()) != 1 || st.GetTraces()[0].GetKey() != "night" {
+		t.Fatalf("unexpected traces %+v", st.GetTraces())
+	}
+}
+
+func TestRunAnnotate(t *testing.T) {
+	var gotKey, gotProfile, gotText string
+	var gotAt time.Time
+	d := &appDeps{
+		traceKey: "run1", profileName: "local", note: "door opened", noteAt: "2026-10-19T08:00:00Z",
+		annotate: func(key, profile, text string, at time.Time) error {
+			gotKey, gotProfile, gotText, gotAt = key, profile, text, at
+			return nil
+		},
+	}
+	if err := runAnnotate(d); err != nil {
+		t.Fatalf("runAnnotate: %v", err)
+	}
+	if gotKey != "run1" || gotProfile != "local" || gotText != "door opened" || !gotAt.Equal(time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected call %q %q %q %v", gotKey, gotProfile, gotText, gotAt)
+	}
+	d.noteAt = "soon"
+	if err := runAnnotate(d); err == nil {
+		t.Fatalf("expected error for invalid time")
+	}
+}
+
+func TestRunSearch(t *testing.T) {
+	var got traces.SearchQuery
+	d := &appDeps{
+		note: "tag=plant valve",
+		search: func(q traces.SearchQuery) ([]traces.SearchResult, error) {
+			got = q
+			return nil, nil
+		},
+	}
+	if err := runSearch(d); err != nil {
+		t.Fatalf("runSearch: %v", err)
+	}
+	if len(got.Tags) != 1 || got.Tags[0] != "plant" || got.Text != "valve" {
+		t.Fatalf("unexpected query %+v", got)
+	}
+}
+
+func TestRunBatchImport(t *testing.T) {
+	connected := false
+	var got importer.Options
+	d := &appDeps{
+		batchOpts: importer.Options{File: "data.csv", Template: "dev/{id}", DryRun: true},
+		newMQTTClient: func(connections.Profile, statusFunc) (mqttClient, error) {
+			connected = true
+			return &stubMQTTClient{}, nil
+		},
+		batch: func(_ context.Context, cl steps.Publisher, o importer.Options, _ io.Writer) (importer.Summary, error) {
+			if cl != nil {
+				t.Fatalf("dry run got a client")
+			}
+			got = o
+			return importer.Summary{}, nil
+		},
+	}
+	if err := runBatchImport(d); err != nil {
+		t.Fatalf("runBatchImport: %v", err)
+	}
+	if connected || got.Template != "dev/{id}" {
+		t.Fatalf("unexpected run: connected %v opts %+v", connected, got)
+	}
+	d.batchOpts.File = ""
+	if err := runBatchImport(d); err == nil {
+		t.Fatalf("expected error without file")
+	}
+}
+
+func TestMainDispatchImportCommandExitCode(t *testing.T) {
+	orig := initProxy
+	initProxy = func() (string, *proxy.Proxy) { return "", nil }
+	defer func() { initProxy = orig }()
+	d := newAppDeps()
+	code := 0
+	d.exit = func(c int) { code = c }
+	d.runners["import"] = func(*appDeps) error { t.Fatalf("wizard started"); return nil }
+	d.runners["batch"] = func(ad *appDeps) error {
+		if ad.batchOpts.File != "data.csv" || !ad.batchOpts.DryRun {
+			t.Fatalf("unexpected options %+v", ad.batchOpts)
+		}
+		return errors.New("2 of 10 rows failed")
+	}
+	runMain(d, cfg.AppConfig{Command: "import", PublishFile: "data.csv", DryRun: true})
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+}
+
+func TestRunProfilesRoundTrip(t *testing.T) {
+	keyring.MockInit()
+	src := t.TempDir()
+	t.Setenv("HOME", src)
+	cfgDir := filepath.Join(src, ".config", "emqutiti")
+	os.MkdirAll(cfgDir, 0o755)
+	data := "[[profiles]]\nname = \"lab\"\nhost = \"lab.local\"\nusername = \"u\"\npassword = \"pw\"\n"
+	if err := os.WriteFile(filepath.Join(cfgDir, "config.toml"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bundle := filepath.Join(t.TempDir(), "team.toml")
+	d := newAppDeps()
+	d.passphrase = func(string) (string, error) { return "pass", nil }
+	d.action, d.bundleFile, d.bundleSecrets = "export", bundle, true
+	if err := runProfiles(d); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	d.action, d.args, d.onConflict = "import", []string{bundle}, "skip"
+	if err := runProfiles(d); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	p, err := connections.LoadProfile("lab", "")
+	if err != nil || p.Host != "lab.local" || p.Password != "pw" {
+		t.Fatalf("unexpected profile %+v %v", p, err)
+	}
+	d.passphrase = func(string) (string, error) { return "wrong", nil }
+	if err := runProfiles(d); err == nil {
+		t.Fatalf("expected wrong passphrase error")
+	}
+	d.action = "list"
+	if err := runProfiles(d); err == nil {
+		t.Fatalf("expected unknown action error")
+	}
+}
+
+func TestRunProfilesImportDryRun(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("HOME", t.TempDir())
+	src := filepath.Join(t.TempDir(), "mqttx.json")
+	data := `[{"name": "cloud", "host": "broker.example.com", "port": 8883, "protocol": "mqtts", "password": "pw"}]`
+	if err := os.WriteFile(src, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	d := newAppDeps()
+	d.action, d.args, d.onConflict = "import", []string{src}, "skip"
+	d.batchOpts.DryRun = true
+	if err := runProfiles(d); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if _, err := connections.LoadProfile("cloud", ""); err == nil {
+		t.Fatalf("dry run must not save profiles")
+	}
+	d.batchOpts.DryRun = false
+	d.profileFormat = "mqttx"
+	if err := runProfiles(d); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	p, err := connections.LoadProfile("cloud", "")
+	if err != nil || p.BrokerURL() != "mqtts://broker.example.com:8883" || p.Password != "pw" {
+		t.Fatalf("unexpected profile %+v %v", p, err)
+	}
+	d.profileFormat = "putty"
+	if err := runProfiles(d); err == nil {
+		t.Fatalf("expected unknown format error")
+	}
+}
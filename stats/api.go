@@ -0,0 +1,20 @@
+package stats
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// ID identifies the statistics view.
+const ID = "stats"
+
+// API defines the dependencies Component requires from the host model.
+type API interface {
+	SetModeClient() tea.Cmd
+	SubscribedTopics() []string
+	ActiveConnection() string
+	LogHistory(topic, payload, kind string, retained bool, text string)
+	FocusedID() string
+	ResetElemPos()
+	SetElemPos(id string, pos int)
+	OverlayHelp(view string) string
+	Width() int
+	Height() int
+}
@@ -0,0 +1,204 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/marang/emqutiti/history"
+)
+
+// Windows lists the selectable rolling windows.
+var Windows = []time.Duration{10 * time.Second, time.Minute, 5 * time.Minute}
+
+// maxEvents bounds the number of events kept for the rolling windows.
+const maxEvents = 200000
+
+// sizeBounds are the upper limits of the payload size buckets in bytes.
+var sizeBounds = []int{64, 256, 1024, 4096, 16384, 65536}
+
+// Event is a received message reduced to what the statistics need.
+type Event struct {
+	Time     time.Time
+	Topic    string
+	Size     int
+	Retained bool
+}
+
+// Collector aggregates incoming messages into rolling windows and totals.
+type Collector struct {
+	events []Event
+	start  time.Time
+	total  Totals
+}
+
+// Totals counts all messages since the collector was started or reset.
+type Totals struct {
+	Since    time.Time `json:"since"`
+	Messages int       `json:"messages"`
+	Bytes    int       `json:"bytes"`
+	Retained int       `json:"retained"`
+}
+
+// Rate is a message and byte count with per-second rates.
+type Rate struct {
+	Messages    int     `json:"messages"`
+	Bytes       int     `json:"bytes"`
+	Retained    int     `json:"retained"`
+	MsgsPerSec  float64 `json:"msgs_per_sec"`
+	BytesPerSec float64 `json:"bytes_per_sec"`
+}
+
+// FilterStat is the rate of messages matching a topic filter.
+type FilterStat struct {
+	Filter string `json:"filter"`
+	Rate
+}
+
+// TopicStat is the rate of messages on a single topic.
+type TopicStat struct {
+	Topic string `json:"topic"`
+	Rate
+}
+
+// SizeBucket counts payloads up to Max bytes; Max is 0 for the last bucket.
+type SizeBucket struct {
+	Label string `json:"label"`
+	Max   int    `json:"max,omitempty"`
+	Count int    `json:"count"`
+}
+
+// Snapshot is the computed statistics for one window.
+type Snapshot struct {
+	Time    time.Time    `json:"time"`
+	Window  string       `json:"window"`
+	Overall Rate         `json:"overall"`
+	Total   Totals       `json:"total"`
+	Filters []FilterStat `json:"filters"`
+	Topics  []TopicStat  `json:"top_topics"`
+	Sizes   []SizeBucket `json:"payload_sizes"`
+}
+
+// NewCollector returns an empty collector starting at now.
+func NewCollector(now time.Time) *Collector {
+	return &Collector{start: now, total: Totals{Since: now}}
+}
+
+// Add records a message and drops events older than the largest window.
+func (c *Collector) Add(e Event) {
+	c.events = append(c.events, e)
+	c.total.Messages++
+	c.total.Bytes += e.Size
+	if e.Retained {
+		c.total.Retained++
+	}
+	c.trim(e.Time)
+}
+
+// Reset clears all collected data.
+func (c *Collector) Reset(now time.Time) {
+	*c = *NewCollector(now)
+}
+
+func (c *Collector) trim(now time.Time) {
+	cutoff := now.Add(-Windows[len(Windows)-1])
+	i := sort.Search(len(c.events), func(i int) bool { return !c.events[i].Time.Before(cutoff) })
+	if n := len(c.events) - maxEvents; n > i {
+		i = n
+	}
+	if i > 0 {
+		// reslicing keeps Add cheap; append moves the live events to a new
+		// array once the capacity is used up, dropping the old prefix
+		clear(c.events[:i])
+		c.events = c.events[i:]
+	}
+}
+
+// Snapshot computes statistics over window for the given filters and the
+// top n topics.
+func (c *Collector) Snapshot(now time.Time, window time.Duration, filters []string, n int) Snapshot {
+	c.trim(now)
+	cutoff := now.Add(-window)
+	i := sort.Search(len(c.events), func(i int) bool { return c.events[i].Time.After(cutoff) })
+	events := c.events[i:]
+
+	// rates use the elapsed time while the window is still filling up
+	secs := window.Seconds()
+	if elapsed := now.Sub(c.start).Seconds(); elapsed < secs {
+		secs = elapsed
+	}
+	secs = max(secs, 1)
+
+	s := Snapshot{Time: now, Window: window.String(), Total: c.total}
+	fs := make([]FilterStat, len(filters))
+	for i, f := range filters {
+		fs[i].Filter = f
+	}
+	topics := map[string]*TopicStat{}
+	s.Sizes = sizeBuckets()
+	for _, e := range events {
+		count(&s.Overall, e)
+		for i := range fs {
+			if history.MatchFilter(fs[i].Filter, e.Topic) {
+				count(&fs[i].Rate, e)
+			}
+		}
+		ts, ok := topics[e.Topic]
+		if !ok {
+			ts = &TopicStat{Topic: e.Topic}
+			topics[e.Topic] = ts
+		}
+		count(&ts.Rate, e)
+		s.Sizes[bucket(e.Size)].Count++
+	}
+	s.Overall.rates(secs)
+	for i := range fs {
+		fs[i].rates(secs)
+	}
+	s.Filters = fs
+	for _, ts := range topics {
+		ts.rates(secs)
+		s.Topics = append(s.Topics, *ts)
+	}
+	sort.Slice(s.Topics, func(i, j int) bool {
+		a, b := s.Topics[i], s.Topics[j]
+		if a.Messages != b.Messages {
+			return a.Messages > b.Messages
+		}
+		return a.Topic < b.Topic
+	})
+	if len(s.Topics) > n {
+		s.Topics = s.Topics[:n]
+	}
+	return s
+}
+
+func count(r *Rate, e Event) {
+	r.Messages++
+	r.Bytes += e.Size
+	if e.Retained {
+		r.Retained++
+	}
+}
+
+func (r *Rate) rates(secs float64) {
+	r.MsgsPerSec = float64(r.Messages) / secs
+	r.BytesPerSec = float64(r.Bytes) / secs
+}
+
+func sizeBuckets() []SizeBucket {
+	out := make([]SizeBucket, 0, len(sizeBounds)+1)
+	for _, b := range sizeBounds {
+		out = append(out, SizeBucket{Label: "≤" + FormatBytes(float64(b)), Max: b})
+	}
+	last := sizeBounds[len(sizeBounds)-1]
+	return append(out, SizeBucket{Label: ">" + FormatBytes(float64(last))})
+}
+
+func bucket(size int) int {
+	for i, b := range sizeBounds {
+		if size <= b {
+			return i
+		}
+	}
+	return len(sizeBounds)
+}
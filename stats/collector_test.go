@@ -0,0 +1,100 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCollectorSnapshot(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewCollector(start)
+	// an old event that falls outside the 10s window
+	c.Add(Event{Time: start, Topic: "a/old", Size: 10})
+	now := start.Add(time.Minute)
+	for i := 0; i < 20; i++ {
+		c.Add(Event{Time: now.Add(-time.Duration(i) * 100 * time.Millisecond), Topic: "a/busy", Size: 100})
+	}
+	c.Add(Event{Time: now, Topic: "b/quiet", Size: 5000, Retained: true})
+
+	s := c.Snapshot(now, 10*time.Second, []string{"a/#", "b/+"}, 1)
+	if s.Overall.Messages != 21 || s.Overall.Retained != 1 {
+		t.Fatalf("unexpected overall: %+v", s.Overall)
+	}
+	if s.Overall.MsgsPerSec != 2.1 {
+		t.Fatalf("expected 2.1 msg/s, got %v", s.Overall.MsgsPerSec)
+	}
+	if s.Total.Messages != 22 || s.Total.Bytes != 10+2000+5000 {
+		t.Fatalf("unexpected totals: %+v", s.Total)
+	}
+	if s.Filters[0].Messages != 20 || s.Filters[1].Messages != 1 {
+		t.Fatalf("unexpected filter stats: %+v", s.Filters)
+	}
+	if len(s.Topics) != 1 || s.Topics[0].Topic != "a/busy" {
+		t.Fatalf("unexpected top topics: %+v", s.Topics)
+	}
+	if s.Sizes[1].Count != 20 || s.Sizes[4].Count != 1 {
+		t.Fatalf("unexpected size buckets: %+v", s.Sizes)
+	}
+
+	s = c.Snapshot(now, 5*time.Minute, nil, 10)
+	if s.Overall.Messages != 22 {
+		t.Fatalf("expected all events in 5m window, got %d", s.Overall.Messages)
+	}
+	// the window is still filling up, so the rate uses the elapsed minute
+	if s.Overall.MsgsPerSec != 22.0/60 {
+		t.Fatalf("unexpected rate %v", s.Overall.MsgsPerSec)
+	}
+}
+
+func TestCollectorTrimsOldEvents(t *testing.T) {
+	start := time.Now()
+	c := NewCollector(start)
+	c.Add(Event{Time: start, Topic: "t"})
+	c.Add(Event{Time: start.Add(10 * time.Minute), Topic: "t"})
+	if len(c.events) != 1 {
+		t.Fatalf("expected old event to be trimmed, have %d", len(c.events))
+	}
+	if c.total.Messages != 2 {
+		t.Fatalf("totals must include trimmed events")
+	}
+}
+
+func TestCollectorTrimsFullWindowCheaply(t *testing.T) {
+	start := time.Now()
+	c := NewCollector(start)
+	for i := 0; i < maxEvents; i++ {
+		c.Add(Event{Time: start, Topic: "t"})
+	}
+	n := 0
+	allocs := testing.AllocsPerRun(1000, func() {
+		n++
+		c.Add(Event{Time: start.Add(time.Duration(n) * time.Millisecond), Topic: "t"})
+	})
+	if allocs >= 1 {
+		t.Fatalf("expected no copy of the window per message, got %v allocations", allocs)
+	}
+	if len(c.events) != maxEvents || c.events[len(c.events)-1].Time != start.Add(time.Duration(n)*time.Millisecond) {
+		t.Fatalf("unexpected window of %d events", len(c.events))
+	}
+}
+
+func BenchmarkCollectorAddFullWindow(b *testing.B) {
+	start := time.Now()
+	c := NewCollector(start)
+	for i := 0; i < maxEvents; i++ {
+		c.Add(Event{Time: start, Topic: "t"})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Add(Event{Time: start.Add(time.Duration(i) * time.Microsecond), Topic: "t"})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	cases := map[float64]string{0: "0B", 512: "512B", 1536: "1.5KiB", 3 << 20: "3.0MiB"}
+	for in, want := range cases {
+		if got := FormatBytes(in); got != want {
+			t.Fatalf("FormatBytes(%v) = %q, want %q", in, got, want)
+		}
+	}
+}
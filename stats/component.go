@@ -0,0 +1,132 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/marang/emqutiti/constants"
+	"github.com/marang/emqutiti/focus"
+	"github.com/marang/emqutiti/ui"
+)
+
+// defaultTopN is the initial number of busiest topics shown.
+const defaultTopN = 10
+
+// tickMsg refreshes the rates while the view is open.
+type tickMsg struct{ seq int }
+
+// Component shows traffic statistics computed from incoming messages.
+type Component struct {
+	api    API
+	col    *Collector
+	window int
+	topN   int
+	paused bool
+	status string
+	seq    int
+	now    func() time.Time
+}
+
+// New creates a statistics component that starts collecting immediately.
+func New(api API) *Component {
+	c := &Component{api: api, window: 1, topN: defaultTopN, now: time.Now}
+	c.col = NewCollector(c.now())
+	return c
+}
+
+// Add records a received message.
+func (c *Component) Add(e Event) {
+	if c.paused {
+		return
+	}
+	c.col.Add(e)
+}
+
+// Open starts refreshing the view once per second.
+func (c *Component) Open() tea.Cmd {
+	c.seq++
+	c.status = ""
+	return c.tick()
+}
+
+func (c *Component) tick() tea.Cmd {
+	seq := c.seq
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return tickMsg{seq: seq} })
+}
+
+// Snapshot computes the statistics for the selected window.
+func (c *Component) Snapshot() Snapshot {
+	return c.col.Snapshot(c.now(), Windows[c.window], c.api.SubscribedTopics(), c.topN)
+}
+
+func (c *Component) export() {
+	s := c.Snapshot()
+	path := ExportPath(c.api.ActiveConnection(), s.Time)
+	msg := fmt.Sprintf("Exported statistics to %s", path)
+	if err := Export(path, s); err != nil {
+		msg = fmt.Sprintf("export error: %v", err)
+	}
+	c.status = msg
+	c.api.LogHistory("", msg, "log", false, msg)
+}
+
+func (c *Component) Init() tea.Cmd { return nil }
+
+func (c *Component) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case tickMsg:
+		if msg.seq == c.seq {
+			return c.tick()
+		}
+	case tea.KeyMsg:
+		switch msg.String() {
+		case constants.KeyCtrlD:
+			return tea.Quit
+		case constants.KeyEsc:
+			c.seq++
+			return c.api.SetModeClient()
+		case constants.KeyW:
+			c.window = (c.window + 1) % len(Windows)
+		case "+", "=":
+			c.topN = min(c.topN+1, 50)
+		case "-":
+			c.topN = max(c.topN-1, 1)
+		case constants.KeyP:
+			c.paused = !c.paused
+		case constants.KeyR:
+			c.col.Reset(c.now())
+			c.status = ""
+		case constants.KeyX:
+			c.export()
+		}
+	}
+	return nil
+}
+
+func (c *Component) View() string {
+	c.api.ResetElemPos()
+	c.api.SetElemPos(ID, 1)
+	width := c.api.Width() - 2
+	content := Render(c.Snapshot(), width-2)
+	help := fmt.Sprintf("[w] window  [+/-] top %d  [p] pause  [r] reset  [x] export  [esc] back", c.topN)
+	if c.paused {
+		help = "paused  " + help
+	}
+	if c.status != "" {
+		content += "\n" + ui.InfoSubtleStyle.Render(c.status)
+	}
+	content += "\n" + ui.InfoStyle.Render(help)
+	view := ui.LegendBox(content, "Statistics", width, 0, ui.ColBlue, c.api.FocusedID() == ID, -1)
+	return c.api.OverlayHelp(view)
+}
+
+func (c *Component) Focus() tea.Cmd { return nil }
+
+func (c *Component) Blur() {}
+
+// Focusables exposes focusable elements for the statistics component.
+func (c *Component) Focusables() map[string]focus.Focusable {
+	return map[string]focus.Focusable{ID: &focus.NullFocusable{}}
+}
@@ -0,0 +1,87 @@
+package stats
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+type stubAPI struct{ logs []string }
+
+func (*stubAPI) SetModeClient() tea.Cmd         { return nil }
+func (*stubAPI) SubscribedTopics() []string     { return []string{"sensors/#"} }
+func (*stubAPI) ActiveConnection() string       { return "test" }
+func (*stubAPI) FocusedID() string              { return ID }
+func (*stubAPI) ResetElemPos()                  {}
+func (*stubAPI) SetElemPos(string, int)         {}
+func (*stubAPI) OverlayHelp(view string) string { return view }
+func (*stubAPI) Width() int                     { return 100 }
+func (*stubAPI) Height() int                    { return 30 }
+func (s *stubAPI) LogHistory(_, _, _ string, _ bool, text string) {
+	s.logs = append(s.logs, text)
+}
+
+func TestComponentViewAndExport(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	api := &stubAPI{}
+	c := New(api)
+	c.Add(Event{Time: time.Now(), Topic: "sensors/a", Size: 42})
+	c.Add(Event{Time: time.Now(), Topic: "sensors/b", Size: 42, Retained: true})
+
+	out := ansi.Strip(c.View())
+	for _, want := range []string{"Last 1m0s", "sensors/#", "sensors/a", "Payload sizes"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in view:\n%s", want, out)
+		}
+	}
+
+	c.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	if Windows[c.window] != 5*time.Minute {
+		t.Fatalf("expected window to cycle to 5m")
+	}
+
+	c.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if len(api.logs) != 1 {
+		t.Fatalf("expected export to be logged")
+	}
+	path := strings.TrimPrefix(api.logs[0], "Exported statistics to ")
+	if filepath.Ext(path) != ".json" {
+		t.Fatalf("unexpected export message %q", api.logs[0])
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("decode export: %v", err)
+	}
+	if s.Overall.Messages != 2 || s.Overall.Retained != 1 || s.Filters[0].Filter != "sensors/#" {
+		t.Fatalf("unexpected exported snapshot: %+v", s)
+	}
+
+	c.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	c.Add(Event{Time: time.Now(), Topic: "sensors/a"})
+	c.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	if c.Snapshot().Total.Messages != 0 {
+		t.Fatalf("expected reset to clear totals")
+	}
+}
+
+func TestComponentTickStopsAfterClose(t *testing.T) {
+	c := New(&stubAPI{})
+	c.Open()
+	if c.Update(tickMsg{seq: c.seq}) == nil {
+		t.Fatalf("expected tick to reschedule while open")
+	}
+	c.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if c.Update(tickMsg{seq: c.seq - 1}) != nil {
+		t.Fatalf("stale tick should not reschedule")
+	}
+}
@@ -0,0 +1,29 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/marang/emqutiti/internal/files"
+)
+
+// ExportPath returns a timestamped JSON path under the profile's exports dir.
+func ExportPath(profile string, now time.Time) string {
+	file := fmt.Sprintf("stats-%s.json", now.Format("20060102-150405"))
+	return filepath.Join(files.DataDir(profile), "exports", file)
+}
+
+// Export writes the snapshot as indented JSON to path.
+func Export(path string, s Snapshot) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,92 @@
+package stats
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/marang/emqutiti/ui"
+)
+
+// FormatBytes renders a byte count with a binary unit.
+func FormatBytes(b float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB"}
+	i := 0
+	for b >= 1024 && i < len(units)-1 {
+		b /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f%s", b, units[i])
+	}
+	return fmt.Sprintf("%.1f%s", b, units[i])
+}
+
+var headStyle = lipgloss.NewStyle().Foreground(ui.ColCyan).Bold(true)
+
+// Render draws a snapshot as text limited to width columns.
+func Render(s Snapshot, width int) string {
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		b.WriteString(ansi.Truncate(fmt.Sprintf(format, args...), width, "…"))
+		b.WriteByte('\n')
+	}
+	o := s.Overall
+	line("%s  %.1f msg/s  %s/s  %d msgs  %s  %d retained",
+		headStyle.Render("Last "+s.Window), o.MsgsPerSec, FormatBytes(o.BytesPerSec),
+		o.Messages, FormatBytes(float64(o.Bytes)), o.Retained)
+	t := s.Total
+	line("%s  %d msgs  %s  %d retained  since %s",
+		headStyle.Render("Total"), t.Messages, FormatBytes(float64(t.Bytes)), t.Retained,
+		t.Since.Format("15:04:05"))
+
+	if len(s.Filters) > 0 {
+		b.WriteByte('\n')
+		line("%s", headStyle.Render("Topic filters"))
+		rateTable(&b, width, len(s.Filters), func(i int) (string, Rate) {
+			return s.Filters[i].Filter, s.Filters[i].Rate
+		})
+	}
+
+	b.WriteByte('\n')
+	line("%s", headStyle.Render("Busiest topics"))
+	if len(s.Topics) == 0 {
+		line("  no messages in window")
+	}
+	rateTable(&b, width, len(s.Topics), func(i int) (string, Rate) {
+		return s.Topics[i].Topic, s.Topics[i].Rate
+	})
+
+	b.WriteByte('\n')
+	line("%s", headStyle.Render("Payload sizes"))
+	most := 0
+	for _, sb := range s.Sizes {
+		most = max(most, sb.Count)
+	}
+	barWidth := max(width-20, 1)
+	for _, sb := range s.Sizes {
+		bar := 0
+		if most > 0 {
+			bar = sb.Count * barWidth / most
+		}
+		if sb.Count > 0 && bar == 0 {
+			bar = 1
+		}
+		line("  %-9s %6d %s", sb.Label, sb.Count,
+			lipgloss.NewStyle().Foreground(ui.ColPurple).Render(strings.Repeat("█", bar)))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func rateTable(b *strings.Builder, width, n int, row func(int) (string, Rate)) {
+	const stats = 40
+	nameWidth := max(width-stats-2, 8)
+	for i := 0; i < n; i++ {
+		name, r := row(i)
+		name = ansi.Truncate(name, nameWidth, "…")
+		fmt.Fprintf(b, "  %-*s %8.1f/s %10s/s %7d %4dR\n", nameWidth, name,
+			r.MsgsPerSec, FormatBytes(r.BytesPerSec), r.Messages, r.Retained)
+	}
+}
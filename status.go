@@ -3,10 +3,13 @@ package emqutiti
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/marang/emqutiti/charts"
 	connections "github.com/marang/emqutiti/connections"
+	"github.com/marang/emqutiti/stats"
 )
 
 // handleStatusMessage processes broker status updates.
@@ -26,6 +29,9 @@ func (m *model) handleStatusMessage(msg connections.StatusMessage) tea.Cmd {
 // handleMQTTMessage appends received MQTT messages to history.
 func (m *model) handleMQTTMessage(msg MQTTMessage) tea.Cmd {
 	m.history.Append(msg.Topic, msg.Payload, "sub", msg.Retained, fmt.Sprintf("Received on %s: %s", msg.Topic, msg.Payload))
+	now := time.Now()
+	m.chart.Add(charts.Sample{Time: now, Topic: msg.Topic, Payload: msg.Payload})
+	m.stats.Add(stats.Event{Time: now, Topic: msg.Topic, Size: len(msg.Payload), Retained: msg.Retained})
 	return listenMessages(m.mqttClient.MessageChan)
 }
 
@@ -0,0 +1,98 @@
+package traces
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/marang/emqutiti/history"
+	"github.com/marang/emqutiti/proxy"
+)
+
+// annotationKind is the history kind of annotations shown in the viewer.
+const annotationKind = "note"
+
+// Annotation marks a moment of a trace. Topic and Message optionally link it
+// to the recorded message it refers to.
+type Annotation struct {
+	Timestamp time.Time
+	Text      string
+	Topic     string
+	Message   time.Time
+}
+
+func annotationPrefix(key string) string { return fmt.Sprintf("annotation/%s/", key) }
+
+// AddAnnotation stores a with the trace key.
+func AddAnnotation(profile, key string, a Annotation) error {
+	cl, conn, err := proxy.NewClient(addr())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	val, err := jsonMarshal(a)
+	if err != nil {
+		return err
+	}
+	// the creation time keeps several notes on the same message apart
+	dbKey := fmt.Sprintf("%s%020d/%020d", annotationPrefix(key), a.Timestamp.UnixNano(), time.Now().UnixNano())
+	_, err = cl.Write(context.Background(), &proxy.WriteRequest{
+		Profile: profile,
+		Bucket:  "traces",
+		Key:     dbKey,
+		Value:   val,
+	})
+	return err
+}
+
+// Annotations returns the annotations of the trace key in time order.
+func Annotations(profile, key string) ([]Annotation, error) {
+	cl, conn, err := proxy.NewClient(addr())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	resp, err := cl.Read(context.Background(), &proxy.ReadRequest{
+		Profile: profile,
+		Bucket:  "traces",
+		Key:     annotationPrefix(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Annotation, 0, len(resp.Values))
+	for _, v := range resp.Values {
+		var a Annotation
+		if err := json.Unmarshal(v, &a); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// item converts the annotation for display between trace messages.
+func (a Annotation) item() history.Item {
+	return history.Item{Timestamp: a.Timestamp, Topic: a.Topic, Payload: a.Text, Kind: annotationKind}
+}
+
+// Annotate stores text as annotation of a trace from the command line. The
+// profile defaults to the one the trace is configured with; at defaults to
+// now.
+func Annotate(key, profile, text string, at time.Time) error {
+	if key == "" || text == "" {
+		return fmt.Errorf("-trace and annotation text are required")
+	}
+	if profile == "" {
+		cfg, ok := loadTraces()[key]
+		if !ok {
+			return fmt.Errorf("unknown trace %q, pass -p to select the profile", key)
+		}
+		profile = cfg.Profile
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	return AddAnnotation(profile, key, Annotation{Timestamp: at, Text: text})
+}
@@ -0,0 +1,101 @@
+package traces
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestAnnotationsRoundTrip(t *testing.T) {
+	startTestProxy(t)
+	at := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	for _, a := range []Annotation{
+		{Timestamp: at.Add(time.Second), Text: "later"},
+		{Timestamp: at, Text: "door opened", Topic: "door", Message: at},
+		{Timestamp: at, Text: "second note"},
+	} {
+		if err := AddAnnotation("p", "k", a); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	notes, err := Annotations("p", "k")
+	if err != nil {
+		t.Fatalf("annotations: %v", err)
+	}
+	if len(notes) != 3 || notes[2].Text != "later" || notes[0].Topic != "door" || !notes[0].Message.Equal(at) {
+		t.Fatalf("unexpected notes %+v", notes)
+	}
+	if err := tracerClearData("p", "k"); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	if notes, _ := Annotations("p", "k"); len(notes) != 0 {
+		t.Fatalf("annotations not cleared: %+v", notes)
+	}
+}
+
+func TestAnnotateUsesTraceProfile(t *testing.T) {
+	startTestProxy(t)
+	if err := addTrace(TracerConfig{Key: "k", Profile: "p1", Topics: []string{"a"}}); err != nil {
+		t.Fatalf("add trace: %v", err)
+	}
+	if err := Annotate("k", "", "valve closed", time.Time{}); err != nil {
+		t.Fatalf("annotate: %v", err)
+	}
+	notes, err := Annotations("p1", "k")
+	if err != nil || len(notes) != 1 || notes[0].Text != "valve closed" || notes[0].Timestamp.IsZero() {
+		t.Fatalf("unexpected notes %+v %v", notes, err)
+	}
+	if err := Annotate("unknown", "", "x", time.Time{}); err == nil {
+		t.Fatalf("expected error for unknown trace")
+	}
+}
+
+func TestViewerNote(t *testing.T) {
+	startTestProxy(t)
+	base := time.Now().Add(-time.Minute)
+	for i, topic := range []string{"a", "b"} {
+		msg := TracerMessage{Timestamp: base.Add(time.Duration(i) * time.Second), Topic: topic, Payload: "1", Kind: "trace"}
+		if err := tracerAdd("p", "k", msg); err != nil {
+			t.Fatalf("add message: %v", err)
+		}
+	}
+	c := NewComponent(&testAPI{}, State{}, &noopStore{})
+	c.items = []*traceItem{{key: "k", cfg: TracerConfig{Key: "k", Profile: "p", Topics: []string{"a", "b"}}}}
+	c.loadTraceMessages(0)
+	c.Component.List().Select(1)
+
+	c.UpdateView(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if c.note == nil {
+		t.Fatalf("note prompt not opened")
+	}
+	c.UpdateView(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("door opened")})
+	c.UpdateView(tea.KeyMsg{Type: tea.KeyEnter})
+	if c.note != nil {
+		t.Fatalf("note prompt still open")
+	}
+
+	items := c.Component.Items()
+	if len(items) != 3 || items[2].Kind != annotationKind || items[2].Topic != "b" || items[2].Payload != "door opened" {
+		t.Fatalf("note not shown inline: %+v", items)
+	}
+	notes, err := Annotations("p", "k")
+	if err != nil || len(notes) != 1 || notes[0].Topic != "b" {
+		t.Fatalf("note not stored: %+v %v", notes, err)
+	}
+
+	// reopening the trace shows the stored note after its message
+	c.loadTraceMessages(0)
+	if items := c.Component.Items(); len(items) != 3 || items[2].Kind != annotationKind {
+		t.Fatalf("stored note not loaded: %+v", items)
+	}
+	var buf bytes.Buffer
+	if err := c.Component.WriteCSV(&buf, c.Component.Items()); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	if !strings.Contains(buf.String(), ",note,b,false,door opened") {
+		t.Fatalf("note missing from export:\n%s", buf.String())
+	}
+}
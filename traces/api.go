@@ -2,6 +2,7 @@ package traces
 
 import (
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/marang/emqutiti/charts"
 	"github.com/marang/emqutiti/confirm"
 	"github.com/marang/emqutiti/connections"
 )
@@ -34,6 +35,7 @@ type API interface {
 	Width() int
 	Height() int
 	NewClient(connections.Profile) (Client, error)
+	ShowTraceChart(key string, topics []string, samples []charts.Sample) tea.Cmd
 }
 
 // Store defines persistence and messaging operations for traces.
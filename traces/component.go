@@ -2,6 +2,7 @@ package traces
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/marang/emqutiti/constants"
 	"github.com/marang/emqutiti/focus"
 	"github.com/marang/emqutiti/history"
+	"github.com/marang/emqutiti/proxy"
 	"github.com/marang/emqutiti/ui"
 )
 
@@ -23,16 +25,31 @@ type traceItem struct {
 	key    string
 	cfg    TracerConfig
 	tracer *Tracer
+	series *Series
+	parent string // key of the recurring trace this occurrence belongs to
 	counts map[string]int
 	loaded bool
+	remote *proxy.TraceStatus // set while the trace runs in the daemon
 }
 
-func (t *traceItem) FilterValue() string { return t.key }
-func (t *traceItem) Title() string       { return t.key }
+func (t *traceItem) FilterValue() string {
+	return strings.Join(append([]string{t.key, t.cfg.Description}, t.cfg.Tags...), " ")
+}
+func (t *traceItem) Title() string { return t.key }
 func (t *traceItem) Description() string {
+	if t.tracer == nil && t.series == nil && t.remoteActive() {
+		return t.daemonDescription()
+	}
+	if t.cfg.Repeat != "" {
+		return t.recurringDescription()
+	}
 	status := "stopped"
-	if t.tracer != nil {
-		if t.tracer.Running() {
+	if t.parent != "" {
+		status = "run of " + t.parent
+	} else if t.tracer != nil {
+		if t.tracer.Armed() && t.tracer.Running() {
+			status = "armed"
+		} else if t.tracer.Running() {
 			status = "running"
 		} else if t.tracer.Planned() {
 			status = "planned"
@@ -67,9 +84,49 @@ func (t *traceItem) Description() string {
 	if len(times) > 0 {
 		status += " " + strings.Join(times, " -> ")
 	}
+	if len(t.cfg.Tags) > 0 {
+		status += " #" + strings.Join(t.cfg.Tags, " #")
+	}
 	return status
 }
 
+// active reports whether the trace records, waits for its start or is
+// scheduled to recur.
+func (t *traceItem) active() bool {
+	if t.series != nil {
+		return t.series.Scheduled()
+	}
+	return t.tracer != nil && (t.tracer.Running() || t.tracer.Planned())
+}
+
+// recurringDescription summarizes a recurring trace and its next occurrence.
+func (t *traceItem) recurringDescription() string {
+	status := "stopped"
+	var parts []string
+	if s := t.series; s != nil {
+		switch {
+		case s.Armed():
+			status = "armed"
+		case s.Running():
+			status = "running"
+		case s.Scheduled():
+			status = "scheduled"
+		}
+		if s.Running() {
+			counts := s.Counts()
+			for _, tp := range t.cfg.Topics {
+				parts = append(parts, fmt.Sprintf("%s:%d", tp, counts[tp]))
+			}
+		}
+		if next := s.Next(); !next.IsZero() {
+			parts = append(parts, "next "+next.Format(occurrenceLayout))
+		}
+	}
+	parts = append([]string{status, "repeat " + t.cfg.Repeat}, parts...)
+	parts = append(parts, fmt.Sprintf("%d run(s)", len(t.cfg.Runs)))
+	return strings.Join(parts, " ")
+}
+
 // state groups state related to tracing functionality.
 type State struct {
 	list  list.Model
@@ -78,6 +135,17 @@ type State struct {
 	*history.Component
 	viewKey string
 	hmodel  *histModel
+	// diffBase is the trace marked as the first side of a diff.
+	diffBase string
+	diff     *diffView
+	// daemonAt is when the daemon status was last queried.
+	daemonAt time.Time
+	// note is the annotation being typed in the trace viewer.
+	note *textinput.Model
+	// search is the open search prompt; searchQuery is the query whose
+	// results the viewer shows.
+	search      *textinput.Model
+	searchQuery string
 }
 
 // Component implements the traces interface for managing traces. It owns the
@@ -130,6 +198,7 @@ func (h *histModel) OverlayHelp(v string) string { return h.api.OverlayHelp(v) }
 func NewComponent(api API, ts State, store Store) *Component {
 	hm := &histModel{api: api, cur: constants.ModeViewTrace, prev: constants.ModeTracer}
 	ts.Component = history.NewComponent(hm, nil)
+	ts.Component.SkipColumnKinds(annotationKind)
 	ts.hmodel = hm
 	c := &Component{State: &ts, api: api, store: store}
 	c.actions = map[string]KeyAction{
@@ -160,7 +229,7 @@ func NewComponent(api API, ts State, store Store) *Component {
 			i := c.list.Index()
 			if i >= 0 && i < len(c.items) {
 				it := c.items[i]
-				if it.tracer != nil && (it.tracer.Running() || it.tracer.Planned()) {
+				if it.active() {
 					c.stopTrace(i)
 				} else {
 					c.startTrace(i)
@@ -175,6 +244,15 @@ func NewComponent(api API, ts State, store Store) *Component {
 			}
 			return nil
 		},
+		constants.KeyG: func(tea.KeyMsg) tea.Cmd {
+			return c.chartTrace(c.list.Index())
+		},
+		constants.KeyD: func(tea.KeyMsg) tea.Cmd {
+			return c.markDiff(c.list.Index())
+		},
+		constants.KeyF: func(tea.KeyMsg) tea.Cmd {
+			return c.startSearch()
+		},
 		constants.KeyDelete: func(tea.KeyMsg) tea.Cmd {
 			i := c.list.Index()
 			if i >= 0 && i < len(c.items) {
@@ -188,17 +266,24 @@ func NewComponent(api API, ts State, store Store) *Component {
 					rf,
 					func() tea.Cmd {
 						c.stopTrace(i)
-						c.items = append(c.items[:i], c.items[i+1:]...)
-						items := make([]list.Item, len(c.items))
-						for idx, itm := range c.items {
-							items[idx] = itm
-						}
-						c.list.SetItems(items)
-						if err := c.store.RemoveTrace(key); err != nil {
+						// recorded occurrences of a recurring trace go too
+						c.items = slices.DeleteFunc(c.items, func(o *traceItem) bool {
+							return o == it || (it.parent == "" && o.parent == key)
+						})
+						c.refreshList()
+						if it.parent != "" {
+							c.removeRun(it.parent, key)
+						} else if err := c.store.RemoveTrace(key); err != nil {
 							c.api.LogHistory("", err.Error(), "log", false, err.Error())
 						}
-						if err := c.store.ClearData(cfg.Profile, key); err != nil {
-							c.api.LogHistory("", err.Error(), "log", false, err.Error())
+						keys := []string{key}
+						if it.parent == "" {
+							keys = append(keys, cfg.Runs...)
+						}
+						for _, k := range keys {
+							if err := c.store.ClearData(cfg.Profile, k); err != nil {
+								c.api.LogHistory("", err.Error(), "log", false, err.Error())
+							}
 						}
 						if c.anyTraceRunning() {
 							return traceTicker()
@@ -228,7 +313,12 @@ func NewComponent(api API, ts State, store Store) *Component {
 
 func (t *Component) Init() tea.Cmd { return nil }
 
-func (t *Component) View() string { return t.viewTraces() }
+func (t *Component) View() string {
+	if t.diff != nil {
+		return t.viewDiff()
+	}
+	return t.viewTraces()
+}
 
 func (t *Component) Focus() tea.Cmd { return nil }
 
@@ -275,9 +365,15 @@ func (t *Component) startFilter() tea.Cmd {
 
 // Update manages the traces list and responds to key presses.
 func (t *Component) Update(msg tea.Msg) tea.Cmd {
+	if t.diff != nil {
+		return t.updateDiff(msg)
+	}
+	if t.search != nil {
+		return t.updateSearch(msg)
+	}
 	switch msg := msg.(type) {
 	case traceTickMsg:
-		// refresh
+		t.syncRuns()
 	case tea.KeyMsg:
 		if act, ok := t.actions[msg.String()]; ok {
 			return act(msg)
@@ -287,6 +383,7 @@ func (t *Component) Update(msg tea.Msg) tea.Cmd {
 }
 
 func (t *Component) listUpdate(msg tea.Msg) tea.Cmd {
+	t.refreshDaemon(time.Now())
 	var cmd tea.Cmd
 	t.list, cmd = t.list.Update(msg)
 	if t.anyTraceRunning() {
@@ -317,13 +414,20 @@ func (t *Component) UpdateForm(msg tea.Msg) tea.Cmd {
 				t.form.errMsg = "key, profile and topics required"
 				return nil
 			}
-			if cfg.Start.IsZero() {
+			if err := t.form.Validate(); err != nil {
+				t.form.errMsg = err.Error()
+				return nil
+			}
+			if cfg.Repeat != "" {
+				// occurrences take their times from the schedule
+				cfg.Start, cfg.End = time.Time{}, time.Time{}
+			} else if cfg.Start.IsZero() {
 				cfg.Start = time.Now().Round(time.Second)
 				if tf, ok := t.form.Fields[idxTraceStart].(*ui.TextField); ok {
 					tf.SetValue(cfg.Start.Format(time.RFC3339))
 				}
 			}
-			if cfg.End.IsZero() {
+			if cfg.Repeat == "" && cfg.End.IsZero() {
 				cfg.End = cfg.Start.Add(time.Hour)
 				if tf, ok := t.form.Fields[idxTraceEnd].(*ui.TextField); ok {
 					tf.SetValue(cfg.End.Format(time.RFC3339))
@@ -348,6 +452,7 @@ func (t *Component) UpdateForm(msg tea.Msg) tea.Cmd {
 				return nil
 			}
 			client.Disconnect()
+			cfg.Broker = p.BrokerURL()
 			newItem := &traceItem{key: cfg.Key, cfg: cfg}
 			t.items = append(t.items, newItem)
 			items := t.list.Items()
@@ -371,6 +476,9 @@ func (t *Component) UpdateForm(msg tea.Msg) tea.Cmd {
 
 // UpdateView displays messages captured for a trace.
 func (t *Component) UpdateView(msg tea.Msg) tea.Cmd {
+	if t.note != nil {
+		return t.updateNote(msg)
+	}
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -390,6 +498,14 @@ func (t *Component) UpdateView(msg tea.Msg) tea.Cmd {
 			return nil
 		case constants.KeySlash:
 			return t.startFilter()
+		case constants.KeyS:
+			t.Component.CycleSort()
+			return nil
+		case constants.KeyX:
+			t.exportMessages()
+			return nil
+		case constants.KeyN:
+			return t.startNote()
 		}
 	}
 	return t.Component.Update(msg)
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/marang/emqutiti/charts"
 	connections "github.com/marang/emqutiti/connections"
 	"github.com/marang/emqutiti/constants"
 )
@@ -30,6 +31,7 @@ func (t *testAPI) SetTraceHeight(int)
 func (t *testAPI) Width() int                                                          { return 80 }
 func (t *testAPI) Height() int                                                         { return 24 }
 func (t *testAPI) NewClient(connections.Profile) (Client, error)                       { return nil, nil }
+func (t *testAPI) ShowTraceChart(string, []string, []charts.Sample) tea.Cmd            { return nil }
 
 type noopStore struct{}
 
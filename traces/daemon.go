@@ -0,0 +1,437 @@
+package traces
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	connections "github.com/marang/emqutiti/connections"
+	"github.com/marang/emqutiti/proxy"
+)
+
+// Daemon trace states reported through the proxy Status call.
+const (
+	DaemonConnecting = "connecting"
+	DaemonWaiting    = "waiting"
+	DaemonArmed      = "armed"
+	DaemonRunning    = "running"
+	DaemonScheduled  = "scheduled"
+	DaemonFinished   = "finished"
+	DaemonFailed     = "failed"
+)
+
+// maxRetry caps the delay between connection attempts.
+const maxRetry = time.Minute
+
+// Daemon runs the planned and recurring traces from config.toml without the
+// UI. Connection failures are retried with growing delays.
+type Daemon struct {
+	load    func() map[string]TracerConfig
+	connect func(profile string) (Client, error)
+	save    func(TracerConfig) error
+	retry   time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]*daemonJob
+}
+
+// NewDaemon returns a daemon for the traces stored in config.toml.
+func NewDaemon() *Daemon {
+	return &Daemon{
+		load:    loadTraces,
+		connect: connectProfile,
+		save:    saveStats,
+		retry:   2 * time.Second,
+		jobs:    map[string]*daemonJob{},
+	}
+}
+
+// connectProfile connects with the named profile and restores
+// subscriptions after reconnects.
+func connectProfile(profile string) (Client, error) {
+	p, err := loadProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+	return dialDaemonClient(*p)
+}
+
+// daemonSkip returns why the daemon does not run cfg at now, or "" when it
+// does: recurring traces, one-shot traces whose window has not ended yet and
+// traces without an end, e.g. armed by a trigger, that recorded nothing yet.
+func daemonSkip(cfg TracerConfig, now time.Time) string {
+	switch {
+	case cfg.Repeat != "":
+		return ""
+	case !cfg.End.IsZero():
+		if now.Before(cfg.End) {
+			return ""
+		}
+		return "ended at " + cfg.End.Format(time.RFC3339)
+	case now.Before(cfg.Start) || cfg.Messages == 0:
+		return ""
+	}
+	return "already recorded and has no end"
+}
+
+// sameTrace compares two configurations ignoring recorded occurrences.
+func sameTrace(a, b TracerConfig) bool {
+	return a.Key == b.Key && a.Profile == b.Profile && a.Repeat == b.Repeat &&
+		a.Trigger == b.Trigger && slices.Equal(a.Topics, b.Topics) &&
+		a.Start.Equal(b.Start) && a.End.Equal(b.End)
+}
+
+// Reload applies config.toml: removed or changed traces stop and new ones
+// start. Unchanged traces keep running.
+func (d *Daemon) Reload() {
+	cfgs := d.load()
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, j := range d.jobs {
+		if cfg, ok := cfgs[key]; !ok || !sameTrace(cfg, j.cfg) {
+			j.stop()
+			delete(d.jobs, key)
+		}
+	}
+	for key, cfg := range cfgs {
+		if _, ok := d.jobs[key]; ok {
+			continue
+		}
+		if why := daemonSkip(cfg, now); why != "" {
+			log.Printf("trace %s: not run, %s", key, why)
+			continue
+		}
+		d.jobs[key] = d.start(cfg)
+	}
+}
+
+// Stop ends all traces.
+func (d *Daemon) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, j := range d.jobs {
+		j.stop()
+		delete(d.jobs, key)
+	}
+}
+
+// Status describes the traces run by the daemon, sorted by key.
+func (d *Daemon) Status() []*proxy.TraceStatus {
+	d.mu.Lock()
+	jobs := make([]*daemonJob, 0, len(d.jobs))
+	for _, j := range d.jobs {
+		jobs = append(jobs, j)
+	}
+	d.mu.Unlock()
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].cfg.Key < jobs[k].cfg.Key })
+	out := make([]*proxy.TraceStatus, len(jobs))
+	for i, j := range jobs {
+		out[i] = j.status()
+	}
+	return out
+}
+
+func (d *Daemon) start(cfg TracerConfig) *daemonJob {
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &daemonJob{cfg: cfg, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(j.done)
+		if cfg.Repeat != "" {
+			d.runSeries(ctx, j)
+		} else {
+			d.runOnce(ctx, j)
+		}
+	}()
+	return j
+}
+
+// runOnce records a one-shot trace, reconnecting until its end when the
+// tracer stops early.
+func (d *Daemon) runOnce(ctx context.Context, j *daemonJob) {
+	cfg := j.cfg
+	if !cfg.End.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, cfg.End)
+		defer cancel()
+	}
+	for {
+		client, err := d.dial(ctx, j)
+		if err != nil {
+			return
+		}
+		tr := newTracer(cfg, client)
+		if err := tr.Start(); err != nil {
+			client.Disconnect()
+			j.set(nil, err)
+			return
+		}
+		j.set(tr, nil)
+		select {
+		case <-tr.done:
+		case <-ctx.Done():
+			tr.Stop()
+		}
+		cfg = tr.Config()
+		if err := d.save(cfg); err != nil {
+			log.Printf("trace %s: %v", cfg.Key, err)
+		}
+		if tr.completed() || ctx.Err() != nil || (!cfg.End.IsZero() && !time.Now().Before(cfg.End)) {
+			return
+		}
+		j.set(tr, fmt.Errorf("trace stopped early"))
+		if !sleepCtx(ctx, d.retry) {
+			return
+		}
+	}
+}
+
+// runSeries schedules a recurring trace until ctx ends.
+func (d *Daemon) runSeries(ctx context.Context, j *daemonJob) {
+	s, err := newSeries(j.cfg, func() (Client, error) { return d.dial(ctx, j) })
+	if err == nil {
+		err = s.Start()
+	}
+	if err != nil {
+		j.set(nil, err)
+		return
+	}
+	j.mu.Lock()
+	j.series = s
+	j.mu.Unlock()
+	for {
+		select {
+		case err := <-s.report:
+			log.Printf("trace %s: %v", j.cfg.Key, err)
+		case <-ctx.Done():
+			s.Stop()
+			return
+		}
+	}
+}
+
+// dial connects for j, retrying with growing delays until ctx ends.
+func (d *Daemon) dial(ctx context.Context, j *daemonJob) (Client, error) {
+	delay := d.retry
+	j.mu.Lock()
+	j.connecting = true
+	j.mu.Unlock()
+	defer func() {
+		j.mu.Lock()
+		j.connecting = false
+		j.mu.Unlock()
+	}()
+	for {
+		c, err := d.connect(j.cfg.Profile)
+		if err == nil {
+			j.set(nil, nil)
+			return c, nil
+		}
+		j.set(nil, err)
+		log.Printf("trace %s: %v, retrying in %s", j.cfg.Key, err, delay)
+		if !sleepCtx(ctx, delay) {
+			return nil, ctx.Err()
+		}
+		delay = min(2*delay, maxRetry)
+	}
+}
+
+// sleepCtx waits for d and reports false when ctx ended first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// daemonJob is a trace run by the daemon.
+type daemonJob struct {
+	cfg    TracerConfig
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu         sync.Mutex
+	tracer     *Tracer
+	series     *Series
+	connecting bool
+	err        error
+}
+
+// set records the current tracer, keeping the previous one when tr is nil,
+// and the last error.
+func (j *daemonJob) set(tr *Tracer, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if tr != nil {
+		j.tracer = tr
+	}
+	j.err = err
+}
+
+func (j *daemonJob) stop() {
+	j.cancel()
+	<-j.done
+}
+
+func (j *daemonJob) status() *proxy.TraceStatus {
+	j.mu.Lock()
+	tr, s, connecting, err := j.tracer, j.series, j.connecting, j.err
+	j.mu.Unlock()
+	st := &proxy.TraceStatus{Key: j.cfg.Key, Profile: j.cfg.Profile}
+	if err != nil {
+		st.Error = err.Error()
+	}
+	var counts map[string]int
+	var next time.Time
+	finished := false
+	select {
+	case <-j.done:
+		finished = true
+	default:
+	}
+	switch {
+	case finished:
+		st.State = DaemonFinished
+		if err != nil {
+			st.State = DaemonFailed
+		}
+	case connecting:
+		st.State = DaemonConnecting
+	case s != nil:
+		next = s.Next()
+		counts = s.Counts()
+		switch {
+		case s.Armed():
+			st.State = DaemonArmed
+		case s.Running():
+			st.State = DaemonRunning
+		default:
+			st.State = DaemonScheduled
+		}
+	case tr != nil:
+		switch {
+		case tr.Planned():
+			st.State = DaemonWaiting
+			next = j.cfg.Start
+		case tr.Armed():
+			st.State = DaemonArmed
+		case tr.Running():
+			st.State = DaemonRunning
+		default:
+			// stopped early, a new connection follows
+			st.State = DaemonConnecting
+		}
+	default:
+		st.State = DaemonConnecting
+	}
+	if tr != nil && s == nil {
+		counts = tr.Counts()
+	}
+	for _, c := range counts {
+		st.Messages += int64(c)
+	}
+	if !next.IsZero() {
+		st.Next = next.Unix()
+	}
+	return st
+}
+
+// DaemonActive reports whether a daemon trace state means it still records
+// or waits to record.
+func DaemonActive(state string) bool {
+	return state != "" && state != DaemonFinished && state != DaemonFailed
+}
+
+// daemonClient is an MQTT client that reconnects on its own and restores
+// its subscriptions once the broker is reachable again.
+type daemonClient struct {
+	mqttClient
+	mu   sync.Mutex
+	subs map[string]mqtt.MessageHandler
+}
+
+func dialDaemonClient(p connections.Profile) (*daemonClient, error) {
+	opts, err := clientOptions(p)
+	if err != nil {
+		return nil, err
+	}
+	dc := &daemonClient{subs: map[string]mqtt.MessageHandler{}}
+	opts.SetAutoReconnect(true)
+	opts.SetMaxReconnectInterval(maxRetry)
+	opts.SetOnConnectHandler(func(mqtt.Client) { dc.resubscribe() })
+	opts.SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+		log.Printf("connection to %s lost: %v", p.BrokerURL(), err)
+	})
+	dc.client = mqtt.NewClient(opts)
+	if token := dc.client.Connect(); token.Wait() && token.Error() != nil {
+		return nil, fmt.Errorf("failed to connect: %w", token.Error())
+	}
+	return dc, nil
+}
+
+// Subscribe subscribes and remembers the handler for reconnects.
+func (c *daemonClient) Subscribe(topic string, qos byte, cb mqtt.MessageHandler) error {
+	c.mu.Lock()
+	c.subs[topic] = cb
+	c.mu.Unlock()
+	return c.mqttClient.Subscribe(topic, qos, cb)
+}
+
+// Unsubscribe unsubscribes and forgets the handler.
+func (c *daemonClient) Unsubscribe(topic string) error {
+	c.mu.Lock()
+	delete(c.subs, topic)
+	c.mu.Unlock()
+	return c.mqttClient.Unsubscribe(topic)
+}
+
+func (c *daemonClient) resubscribe() {
+	c.mu.Lock()
+	subs := make(map[string]mqtt.MessageHandler, len(c.subs))
+	for k, v := range c.subs {
+		subs[k] = v
+	}
+	c.mu.Unlock()
+	for topic, cb := range subs {
+		if err := c.mqttClient.Subscribe(topic, 0, cb); err != nil {
+			log.Printf("resubscribe %s: %v", topic, err)
+		}
+	}
+}
+
+// Run starts all traces from config.toml and keeps them running until ctx
+// ends or the process is interrupted. SIGHUP reloads the configuration.
+func (d *Daemon) Run(ctx context.Context) error {
+	d.Reload()
+	defer d.Stop()
+	log.Printf("daemon running %d trace(s)", len(d.Status()))
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	for {
+		select {
+		case <-hup:
+			d.Reload()
+			log.Printf("configuration reloaded, %d trace(s)", len(d.Status()))
+		case <-sig:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
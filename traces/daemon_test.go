@@ -0,0 +1,181 @@
+package traces
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marang/emqutiti/proxy"
+)
+
+func startTestProxy(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	p, err := proxy.StartProxy("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("start proxy: %v", err)
+	}
+	SetProxyAddr(p.Addr())
+	t.Cleanup(p.Stop)
+}
+
+func waitState(t *testing.T, d *Daemon, key, state string) *proxy.TraceStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		for _, st := range d.Status() {
+			if st.GetKey() == key && st.GetState() == state {
+				return st
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("trace %s never reached %s: %+v", key, state, d.Status())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDaemonRunsPlannedTraces(t *testing.T) {
+	startTestProxy(t)
+	now := time.Now()
+	cfgs := map[string]TracerConfig{
+		"live":    {Key: "live", Profile: "p", Topics: []string{"a"}, Start: now.Add(-time.Minute), End: now.Add(time.Hour)},
+		"later":   {Key: "later", Profile: "p", Topics: []string{"a"}, Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)},
+		"old":     {Key: "old", Profile: "p", Topics: []string{"a"}, Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)},
+		"nightly": {Key: "nightly", Profile: "p", Topics: []string{"a"}, Repeat: "0 0 1 1 *"},
+	}
+	fc := newFakeClient()
+	d := NewDaemon()
+	d.load = func() map[string]TracerConfig { return cfgs }
+	d.connect = func(string) (Client, error) { return fc, nil }
+	d.Reload()
+	defer d.Stop()
+
+	if n := len(d.Status()); n != 3 {
+		t.Fatalf("expected 3 traces, got %d", n)
+	}
+	waitState(t, d, "live", DaemonRunning)
+	st := waitState(t, d, "later", DaemonWaiting)
+	if st.GetNext() != cfgs["later"].Start.Unix() {
+		t.Fatalf("unexpected next %d", st.GetNext())
+	}
+	st = waitState(t, d, "nightly", DaemonScheduled)
+	if st.GetNext() == 0 {
+		t.Fatalf("expected next occurrence")
+	}
+
+	<-fc.subCh
+	var wg sync.WaitGroup
+	wg.Add(1)
+	fc.mu.Lock()
+	fc.wg = &wg
+	fc.mu.Unlock()
+	fc.publish("a", "x")
+	wg.Wait()
+	for _, st := range d.Status() {
+		if st.GetKey() == "live" && st.GetMessages() < 1 {
+			t.Fatalf("expected recorded message, got %+v", st)
+		}
+	}
+}
+
+func TestDaemonRunsTracesWithoutEnd(t *testing.T) {
+	startTestProxy(t)
+	now := time.Now()
+	cfgs := map[string]TracerConfig{
+		"armed": {Key: "armed", Profile: "p", Topics: []string{"a"}, Start: now.Add(-time.Minute), Trigger: Trigger{StartTopic: "go"}},
+		"open":  {Key: "open", Profile: "p", Topics: []string{"a"}, Start: now.Add(-time.Minute)},
+		"done":  {Key: "done", Profile: "p", Topics: []string{"a"}, Start: now.Add(-time.Hour), Messages: 3},
+		"old":   {Key: "old", Profile: "p", Topics: []string{"a"}, Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)},
+	}
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	d := NewDaemon()
+	d.load = func() map[string]TracerConfig { return cfgs }
+	d.connect = func(string) (Client, error) { return newFakeClient(), nil }
+	d.Reload()
+	log.SetOutput(os.Stderr)
+	defer d.Stop()
+
+	waitState(t, d, "armed", DaemonArmed)
+	waitState(t, d, "open", DaemonRunning)
+	if n := len(d.Status()); n != 2 {
+		t.Fatalf("expected 2 traces, got %d", n)
+	}
+	for _, want := range []string{"trace done: not run, already recorded", "trace old: not run, ended at"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Fatalf("expected %q in log:\n%s", want, logs.String())
+		}
+	}
+}
+
+func TestDaemonRetriesConnect(t *testing.T) {
+	startTestProxy(t)
+	now := time.Now()
+	cfg := TracerConfig{Key: "k", Profile: "p", Topics: []string{"a"}, Start: now.Add(-time.Minute), End: now.Add(time.Hour)}
+	var mu sync.Mutex
+	attempts := 0
+	d := NewDaemon()
+	d.retry = 5 * time.Millisecond
+	d.load = func() map[string]TracerConfig { return map[string]TracerConfig{"k": cfg} }
+	d.connect = func(string) (Client, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("refused")
+		}
+		return newFakeClient(), nil
+	}
+	d.Reload()
+	defer d.Stop()
+	st := waitState(t, d, "k", DaemonRunning)
+	if st.GetError() != "" {
+		t.Fatalf("error not cleared: %q", st.GetError())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestDaemonReload(t *testing.T) {
+	startTestProxy(t)
+	now := time.Now()
+	a := TracerConfig{Key: "a", Profile: "p", Topics: []string{"a"}, Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)}
+	b := TracerConfig{Key: "b", Profile: "p", Topics: []string{"b"}, Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)}
+	cfgs := map[string]TracerConfig{"a": a}
+	d := NewDaemon()
+	d.load = func() map[string]TracerConfig { return cfgs }
+	d.connect = func(string) (Client, error) { return newFakeClient(), nil }
+	d.Reload()
+	defer d.Stop()
+	first := d.jobs["a"]
+
+	a.Runs = []string{"ignored"}
+	cfgs = map[string]TracerConfig{"a": a, "b": b}
+	d.Reload()
+	if d.jobs["a"] != first {
+		t.Fatalf("unchanged trace was restarted")
+	}
+	if d.jobs["b"] == nil {
+		t.Fatalf("new trace not started")
+	}
+
+	cfgs = map[string]TracerConfig{"b": b}
+	d.Reload()
+	if _, ok := d.jobs["a"]; ok {
+		t.Fatalf("removed trace still running")
+	}
+	select {
+	case <-first.done:
+	default:
+		t.Fatalf("removed trace not stopped")
+	}
+}
@@ -0,0 +1,343 @@
+package traces
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marang/emqutiti/internal/files"
+)
+
+// DiffAlign selects how messages of a topic are paired between two traces.
+type DiffAlign int
+
+const (
+	// AlignSequence pairs the n-th message of a topic in both traces.
+	AlignSequence DiffAlign = iota
+	// AlignOffset pairs messages with the closest offset from the trace start.
+	AlignOffset
+)
+
+func (a DiffAlign) String() string {
+	if a == AlignOffset {
+		return "time offset"
+	}
+	return "sequence"
+}
+
+// rateTolerance is the relative rate change reported as different.
+const rateTolerance = 0.1
+
+// maxFieldExamples limits the field differences listed per topic.
+const maxFieldExamples = 20
+
+// FieldDiff describes a payload field that differs between paired messages.
+type FieldDiff struct {
+	Path    string
+	Kind    string // added, removed or changed
+	Count   int
+	Example string
+}
+
+// TopicDiff compares the messages of one topic.
+type TopicDiff struct {
+	Topic          string
+	CountA, CountB int
+	RateA, RateB   float64
+	Compared       int
+	Changed        int
+	Fields         []FieldDiff
+}
+
+// Added reports whether the topic only occurs in the second trace.
+func (d TopicDiff) Added() bool { return d.CountA == 0 }
+
+// Missing reports whether the topic only occurs in the first trace.
+func (d TopicDiff) Missing() bool { return d.CountB == 0 }
+
+// RateChanged reports whether the message rates differ noticeably.
+func (d TopicDiff) RateChanged() bool {
+	hi := math.Max(d.RateA, d.RateB)
+	return hi > 0 && math.Abs(d.RateA-d.RateB)/hi > rateTolerance
+}
+
+// TraceDiff is the comparison of two traces.
+type TraceDiff struct {
+	KeyA, KeyB string
+	Align      DiffAlign
+	SpanA      time.Duration
+	SpanB      time.Duration
+	Topics     []TopicDiff
+}
+
+// DiffTraces compares the messages of trace a with those of trace b.
+func DiffTraces(keyA string, a []TracerMessage, keyB string, b []TracerMessage, align DiffAlign) TraceDiff {
+	byA, startA, spanA := groupByTopic(a)
+	byB, startB, spanB := groupByTopic(b)
+	d := TraceDiff{KeyA: keyA, KeyB: keyB, Align: align, SpanA: spanA, SpanB: spanB}
+	var names []string
+	for topic := range byA {
+		names = append(names, topic)
+	}
+	for topic := range byB {
+		if _, ok := byA[topic]; !ok {
+			names = append(names, topic)
+		}
+	}
+	sort.Strings(names)
+	for _, topic := range names {
+		ma, mb := byA[topic], byB[topic]
+		td := TopicDiff{
+			Topic:  topic,
+			CountA: len(ma),
+			CountB: len(mb),
+			RateA:  rate(len(ma), spanA),
+			RateB:  rate(len(mb), spanB),
+		}
+		if len(ma) > 0 && len(mb) > 0 {
+			comparePayloads(&td, pairMessages(ma, startA, mb, startB, align))
+		}
+		d.Topics = append(d.Topics, td)
+	}
+	return d
+}
+
+// groupByTopic splits messages per topic ordered by time and returns the
+// first timestamp and the covered span.
+func groupByTopic(msgs []TracerMessage) (map[string][]TracerMessage, time.Time, time.Duration) {
+	out := map[string][]TracerMessage{}
+	var first, last time.Time
+	for _, m := range msgs {
+		if m.Kind == "log" {
+			continue
+		}
+		out[m.Topic] = append(out[m.Topic], m)
+		if first.IsZero() || m.Timestamp.Before(first) {
+			first = m.Timestamp
+		}
+		if m.Timestamp.After(last) {
+			last = m.Timestamp
+		}
+	}
+	for _, ms := range out {
+		sort.SliceStable(ms, func(i, j int) bool { return ms[i].Timestamp.Before(ms[j].Timestamp) })
+	}
+	return out, first, last.Sub(first)
+}
+
+func rate(n int, span time.Duration) float64 {
+	return float64(n) / math.Max(span.Seconds(), 1)
+}
+
+// pairMessages returns aligned message pairs of one topic.
+func pairMessages(a []TracerMessage, startA time.Time, b []TracerMessage, startB time.Time, align DiffAlign) [][2]TracerMessage {
+	var pairs [][2]TracerMessage
+	if align == AlignSequence {
+		for i := 0; i < len(a) && i < len(b); i++ {
+			pairs = append(pairs, [2]TracerMessage{a[i], b[i]})
+		}
+		return pairs
+	}
+	j := 0
+	for _, ma := range a {
+		off := ma.Timestamp.Sub(startA)
+		for j+1 < len(b) && absDur(b[j+1].Timestamp.Sub(startB)-off) <= absDur(b[j].Timestamp.Sub(startB)-off) {
+			j++
+		}
+		pairs = append(pairs, [2]TracerMessage{ma, b[j]})
+	}
+	return pairs
+}
+
+func absDur(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// comparePayloads records payload differences of the paired messages.
+func comparePayloads(td *TopicDiff, pairs [][2]TracerMessage) {
+	fields := map[string]*FieldDiff{}
+	for _, p := range pairs {
+		td.Compared++
+		if p[0].Payload == p[1].Payload {
+			continue
+		}
+		td.Changed++
+		fa, okA := flattenJSON(p[0].Payload)
+		fb, okB := flattenJSON(p[1].Payload)
+		if !okA || !okB {
+			addField(fields, "$", "changed", fmt.Sprintf("%s → %s", clip(p[0].Payload), clip(p[1].Payload)))
+			continue
+		}
+		for path, va := range fa {
+			vb, ok := fb[path]
+			switch {
+			case !ok:
+				addField(fields, path, "removed", va)
+			case va != vb:
+				addField(fields, path, "changed", fmt.Sprintf("%s → %s", va, vb))
+			}
+		}
+		for path, vb := range fb {
+			if _, ok := fa[path]; !ok {
+				addField(fields, path, "added", vb)
+			}
+		}
+	}
+	for _, f := range fields {
+		td.Fields = append(td.Fields, *f)
+	}
+	sort.Slice(td.Fields, func(i, j int) bool {
+		if td.Fields[i].Count != td.Fields[j].Count {
+			return td.Fields[i].Count > td.Fields[j].Count
+		}
+		if td.Fields[i].Path != td.Fields[j].Path {
+			return td.Fields[i].Path < td.Fields[j].Path
+		}
+		return td.Fields[i].Kind < td.Fields[j].Kind
+	})
+}
+
+func addField(fields map[string]*FieldDiff, path, kind, example string) {
+	k := kind + " " + path
+	if f, ok := fields[k]; ok {
+		f.Count++
+		return
+	}
+	fields[k] = &FieldDiff{Path: path, Kind: kind, Count: 1, Example: clip(example)}
+}
+
+func clip(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if r := []rune(s); len(r) > 60 {
+		return string(r[:59]) + "…"
+	}
+	return s
+}
+
+// flattenJSON maps every leaf of a JSON object or array to its path.
+func flattenJSON(payload string) (map[string]string, bool) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(payload), &v); err != nil {
+		return nil, false
+	}
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+	default:
+		return nil, false
+	}
+	out := map[string]string{}
+	flatten("$", v, out)
+	return out, true
+}
+
+func flatten(path string, v interface{}, out map[string]string) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if len(t) == 0 {
+			out[path] = "{}"
+		}
+		for k, c := range t {
+			flatten(path+"."+k, c, out)
+		}
+	case []interface{}:
+		if len(t) == 0 {
+			out[path] = "[]"
+		}
+		for i, c := range t {
+			flatten(path+"["+strconv.Itoa(i)+"]", c, out)
+		}
+	default:
+		b, _ := json.Marshal(t)
+		out[path] = string(b)
+	}
+}
+
+// WriteSummary writes a textual report of the diff.
+func (d TraceDiff) WriteSummary(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Trace diff %s → %s (aligned by %s)\n", d.KeyA, d.KeyB, d.Align)
+	fmt.Fprintf(&b, "Span: %s → %s\n", d.SpanA.Round(time.Second), d.SpanB.Round(time.Second))
+	var added, missing, rates, changed int
+	for _, t := range d.Topics {
+		switch {
+		case t.Added():
+			added++
+		case t.Missing():
+			missing++
+		}
+		if t.RateChanged() {
+			rates++
+		}
+		if t.Changed > 0 {
+			changed++
+		}
+	}
+	fmt.Fprintf(&b, "Topics: %d  added: %d  missing: %d  rate changed: %d  payload changed: %d\n",
+		len(d.Topics), added, missing, rates, changed)
+	for _, t := range d.Topics {
+		b.WriteByte('\n')
+		mark := " "
+		switch {
+		case t.Added():
+			mark = "+"
+		case t.Missing():
+			mark = "-"
+		case t.RateChanged() || t.Changed > 0:
+			mark = "~"
+		}
+		fmt.Fprintf(&b, "%s %s\n", mark, t.Topic)
+		fmt.Fprintf(&b, "    messages %d → %d  rate %.2f/s → %.2f/s\n", t.CountA, t.CountB, t.RateA, t.RateB)
+		if t.Compared > 0 {
+			fmt.Fprintf(&b, "    payloads compared %d  changed %d\n", t.Compared, t.Changed)
+		}
+		for i, f := range t.Fields {
+			if i == maxFieldExamples {
+				fmt.Fprintf(&b, "    … %d more field(s)\n", len(t.Fields)-i)
+				break
+			}
+			fmt.Fprintf(&b, "    %-7s %s ×%d  %s\n", f.Kind, f.Path, f.Count, f.Example)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Summary returns the textual report of the diff.
+func (d TraceDiff) Summary() string {
+	var b strings.Builder
+	_ = d.WriteSummary(&b)
+	return b.String()
+}
+
+// DiffExportPath returns a timestamped text path under the profile's exports dir.
+func DiffExportPath(profile, keyA, keyB string) string {
+	r := strings.NewReplacer("/", "_", ":", "-", string(filepath.Separator), "_")
+	file := fmt.Sprintf("diff-%s-%s-%s.txt", r.Replace(keyA), r.Replace(keyB), time.Now().Format("20060102-150405"))
+	return filepath.Join(files.DataDir(profile), "exports", file)
+}
+
+// ExportDiff writes the diff summary to path, creating parent directories.
+func ExportDiff(path string, d TraceDiff) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := d.WriteSummary(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
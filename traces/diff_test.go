@@ -0,0 +1,147 @@
+package traces
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func diffMsgs(start time.Time, specs ...string) []TracerMessage {
+	var out []TracerMessage
+	for i := 0; i+1 < len(specs); i += 2 {
+		out = append(out, TracerMessage{
+			Timestamp: start.Add(time.Duration(len(out)) * time.Second),
+			Topic:     specs[i],
+			Payload:   specs[i+1],
+			Kind:      "trace",
+		})
+	}
+	return out
+}
+
+func TestDiffTraces(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := diffMsgs(start,
+		"dev/state", `{"fw":"1.0","temp":20,"flags":[1]}`,
+		"dev/state", `{"fw":"1.0","temp":21,"flags":[1]}`,
+		"dev/old", "x",
+		"dev/raw", "abc",
+	)
+	b := diffMsgs(start.Add(time.Hour),
+		"dev/state", `{"fw":"2.0","temp":20,"mode":"eco"}`,
+		"dev/state", `{"fw":"2.0","temp":21,"mode":"eco"}`,
+		"dev/new", "y",
+		"dev/raw", "abd",
+	)
+	d := DiffTraces("before", a, "after", b, AlignSequence)
+	byTopic := map[string]TopicDiff{}
+	for _, td := range d.Topics {
+		byTopic[td.Topic] = td
+	}
+	if !byTopic["dev/new"].Added() || !byTopic["dev/old"].Missing() {
+		t.Fatalf("expected added/missing topics: %+v", d.Topics)
+	}
+	st := byTopic["dev/state"]
+	if st.Compared != 2 || st.Changed != 2 {
+		t.Fatalf("unexpected comparison counts: %+v", st)
+	}
+	want := map[string]string{
+		"changed $.fw":       `"1.0" → "2.0"`,
+		"removed $.flags[0]": "1",
+		"added $.mode":       `"eco"`,
+	}
+	if len(st.Fields) != len(want) {
+		t.Fatalf("unexpected fields: %+v", st.Fields)
+	}
+	for _, f := range st.Fields {
+		if ex, ok := want[f.Kind+" "+f.Path]; !ok || ex != f.Example || f.Count != 2 {
+			t.Fatalf("unexpected field diff %+v", f)
+		}
+	}
+	if raw := byTopic["dev/raw"]; len(raw.Fields) != 1 || raw.Fields[0].Example != "abc → abd" {
+		t.Fatalf("unexpected raw diff: %+v", raw.Fields)
+	}
+
+	sum := d.Summary()
+	for _, s := range []string{"+ dev/new", "- dev/old", "~ dev/state", "added: 1  missing: 1"} {
+		if !strings.Contains(sum, s) {
+			t.Fatalf("summary missing %q:\n%s", s, sum)
+		}
+	}
+}
+
+func TestDiffAlignOffset(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := []TracerMessage{
+		{Timestamp: start, Topic: "t", Payload: "0"},
+		{Timestamp: start.Add(10 * time.Second), Topic: "t", Payload: "10"},
+	}
+	// b has an extra early message that shifts the sequence
+	b := []TracerMessage{
+		{Timestamp: start, Topic: "t", Payload: "0"},
+		{Timestamp: start.Add(time.Second), Topic: "t", Payload: "1"},
+		{Timestamp: start.Add(10 * time.Second), Topic: "t", Payload: "10"},
+	}
+	if d := DiffTraces("a", a, "b", b, AlignSequence); d.Topics[0].Changed != 1 {
+		t.Fatalf("sequence alignment should report a change: %+v", d.Topics[0])
+	}
+	d := DiffTraces("a", a, "b", b, AlignOffset)
+	if td := d.Topics[0]; td.Changed != 0 || td.Compared != 2 {
+		t.Fatalf("offset alignment should match by time: %+v", td)
+	}
+	if !d.Topics[0].RateChanged() {
+		t.Fatalf("expected rate change between 2 and 3 messages")
+	}
+}
+
+type diffStore struct {
+	noopStore
+	msgs map[string][]TracerMessage
+}
+
+func (s diffStore) Messages(_ string, key string) ([]TracerMessage, error) {
+	return s.msgs[key], nil
+}
+
+func TestComponentDiffFlow(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	start := time.Now()
+	store := diffStore{msgs: map[string][]TracerMessage{
+		"a": diffMsgs(start, "t", `{"v":1}`),
+		"b": diffMsgs(start, "t", `{"v":2}`),
+	}}
+	c := NewComponent(&testAPI{}, State{}, store)
+	c.items = []*traceItem{
+		{key: "a", cfg: TracerConfig{Key: "a", Profile: "p"}},
+		{key: "b", cfg: TracerConfig{Key: "b", Profile: "p"}},
+	}
+	c.markDiff(0)
+	if c.diffBase != "a" || c.diff != nil {
+		t.Fatalf("expected trace a marked as diff base")
+	}
+	c.markDiff(1)
+	if c.diff == nil || c.diffBase != "" {
+		t.Fatalf("expected diff view to open")
+	}
+	if !strings.Contains(c.View(), "changed $.v") {
+		t.Fatalf("expected field change in view:\n%s", c.View())
+	}
+	c.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	if c.diff.diff.Align != AlignOffset {
+		t.Fatalf("expected alignment toggle")
+	}
+	path := DiffExportPath("p", "a", "b")
+	if err := ExportDiff(path, c.diff.diff); err != nil {
+		t.Fatalf("ExportDiff: %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || !strings.Contains(string(data), "Trace diff a → b") {
+		t.Fatalf("unexpected export %q: %v", data, err)
+	}
+	c.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if c.diff != nil {
+		t.Fatalf("expected esc to close the diff")
+	}
+}
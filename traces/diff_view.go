@@ -0,0 +1,147 @@
+package traces
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/marang/emqutiti/constants"
+	"github.com/marang/emqutiti/ui"
+)
+
+// diffView shows the comparison of two stored traces.
+type diffView struct {
+	profile    string
+	keyA, keyB string
+	msgsA      []TracerMessage
+	msgsB      []TracerMessage
+	diff       TraceDiff
+	vp         viewport.Model
+}
+
+var (
+	diffAdded   = lipgloss.NewStyle().Foreground(ui.ColGreen)
+	diffMissing = lipgloss.NewStyle().Foreground(ui.ColRed)
+	diffChanged = lipgloss.NewStyle().Foreground(ui.ColWarn)
+)
+
+func (d *diffView) compute(align DiffAlign) {
+	d.diff = DiffTraces(d.keyA, d.msgsA, d.keyB, d.msgsB, align)
+	lines := strings.Split(strings.TrimRight(d.diff.Summary(), "\n"), "\n")
+	for i, l := range lines {
+		switch {
+		case strings.HasPrefix(l, "+ "):
+			lines[i] = diffAdded.Render(l)
+		case strings.HasPrefix(l, "- "):
+			lines[i] = diffMissing.Render(l)
+		case strings.HasPrefix(l, "~ "):
+			lines[i] = diffChanged.Render(l)
+		}
+	}
+	d.vp.SetContent(strings.Join(lines, "\n"))
+}
+
+// markDiff remembers the trace at index as diff base or, when a base is
+// already marked, opens the diff against it.
+func (t *Component) markDiff(index int) tea.Cmd {
+	if index < 0 || index >= len(t.items) {
+		return nil
+	}
+	it := t.items[index]
+	if t.diffBase == "" || t.diffBase == it.key {
+		if t.diffBase == it.key {
+			t.diffBase = ""
+		} else {
+			t.diffBase = it.key
+		}
+		return nil
+	}
+	base := t.traceIndex(t.diffBase)
+	t.diffBase = ""
+	if base < 0 {
+		return nil
+	}
+	t.openDiff(t.items[base], it)
+	return nil
+}
+
+// openDiff loads the messages of both traces and shows their comparison.
+func (t *Component) openDiff(a, b *traceItem) {
+	msgsA, err := t.store.Messages(a.cfg.Profile, a.key)
+	if err != nil {
+		t.api.LogHistory("", err.Error(), "log", false, err.Error())
+		return
+	}
+	msgsB, err := t.store.Messages(b.cfg.Profile, b.key)
+	if err != nil {
+		t.api.LogHistory("", err.Error(), "log", false, err.Error())
+		return
+	}
+	d := &diffView{
+		profile: a.cfg.Profile,
+		keyA:    a.key,
+		keyB:    b.key,
+		msgsA:   msgsA,
+		msgsB:   msgsB,
+		vp:      viewport.New(t.api.Width()-4, max(t.api.Height()-5, 3)),
+	}
+	d.compute(AlignSequence)
+	t.diff = d
+}
+
+// exportDiff writes the diff summary to a text file.
+func (t *Component) exportDiff() {
+	d := t.diff
+	path := DiffExportPath(d.profile, d.keyA, d.keyB)
+	msg := fmt.Sprintf("Exported trace diff to %s", path)
+	if err := ExportDiff(path, d.diff); err != nil {
+		msg = fmt.Sprintf("export error: %v", err)
+	}
+	t.api.LogHistory("", msg, "log", false, msg)
+}
+
+// updateDiff handles keys while the diff is shown.
+func (t *Component) updateDiff(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case traceTickMsg:
+		if t.anyTraceRunning() {
+			return traceTicker()
+		}
+		return nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case constants.KeyCtrlD:
+			t.SavePlannedTraces()
+			return tea.Quit
+		case constants.KeyEsc:
+			t.diff = nil
+			return nil
+		case constants.KeyT:
+			t.diff.compute(1 - t.diff.diff.Align)
+			return nil
+		case constants.KeyX:
+			t.exportDiff()
+			return nil
+		}
+	}
+	var cmd tea.Cmd
+	t.diff.vp, cmd = t.diff.vp.Update(msg)
+	return cmd
+}
+
+// viewDiff renders the diff summary.
+func (t *Component) viewDiff() string {
+	t.api.ResetElemPos()
+	t.api.SetElemPos(IDList, 1)
+	d := t.diff
+	d.vp.Width = t.api.Width() - 4
+	d.vp.Height = max(t.api.Height()-5, 3)
+	help := ui.InfoStyle.Render(fmt.Sprintf("[t] align: %s  [x] export  [↑/↓] scroll  [esc] back", d.diff.Align))
+	content := lipgloss.JoinVertical(lipgloss.Left, d.vp.View(), help)
+	title := fmt.Sprintf("Diff %s → %s", d.keyA, d.keyB)
+	view := ui.LegendBox(content, title, t.api.Width()-2, 0, ui.ColBlue, true, d.vp.ScrollPercent())
+	return t.api.OverlayHelp(view)
+}
@@ -20,6 +20,19 @@ type mqttClient struct{ client mqtt.Client }
 
 // newMQTTClient establishes an MQTT connection using the provided profile.
 func newMQTTClient(p connections.Profile) (*mqttClient, error) {
+	opts, err := clientOptions(p)
+	if err != nil {
+		return nil, err
+	}
+	client := mqtt.NewClient(opts)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		return nil, fmt.Errorf("failed to connect: %w", token.Error())
+	}
+	return &mqttClient{client: client}, nil
+}
+
+// clientOptions builds the MQTT client options for profile p.
+func clientOptions(p connections.Profile) (*mqtt.ClientOptions, error) {
 	opts := mqtt.NewClientOptions()
 	opts.AddBroker(p.BrokerURL())
 	cid := p.ClientID
@@ -58,11 +71,7 @@ func newMQTTClient(p connections.Profile) (*mqttClient, error) {
 	if p.SSL {
 		opts.SetTLSConfig(&tls.Config{InsecureSkipVerify: p.SkipTLSVerify})
 	}
-	client := mqtt.NewClient(opts)
-	if token := client.Connect(); token.Wait() && token.Error() != nil {
-		return nil, fmt.Errorf("failed to connect: %w", token.Error())
-	}
-	return &mqttClient{client: client}, nil
+	return opts, nil
 }
 
 // Subscribe wraps the underlying client's Subscribe call.
@@ -86,11 +95,49 @@ func (m *mqttClient) Disconnect() {
 	}
 }
 
+// loadProfile loads the named profile with environment overrides and the
+// default password applied.
+func loadProfile(name string) (*connections.Profile, error) {
+	p, err := connections.LoadProfile(name, "")
+	if err != nil {
+		return nil, err
+	}
+	if p.FromEnv {
+		connections.ApplyEnvVars(p)
+	}
+	connections.ApplyDefaultPassword(p)
+	return p, nil
+}
+
+// saveStats updates the recorded data statistics of a configured trace,
+// leaving other settings untouched. Removed traces are not added again.
+func saveStats(cfg TracerConfig) error {
+	traces := loadTraces()
+	cur, ok := traces[cfg.Key]
+	if !ok {
+		return nil
+	}
+	cur.resetStats()
+	cur.addStats(cfg)
+	if cfg.Broker != "" {
+		cur.Broker = cfg.Broker
+	}
+	traces[cfg.Key] = cur
+	return saveTraces(traces)
+}
+
 // Run executes the tracer headlessly using configuration from config.toml.
-func Run(ctx context.Context, key, topics, profileName, startStr, endStr string) error {
+// An optional trigger delays or ends recording based on received messages.
+func Run(ctx context.Context, key, topics, profileName, startStr, endStr string, trig Trigger) error {
 	if key == "" || topics == "" {
 		return fmt.Errorf("-trace and -topics are required")
 	}
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	if err := trig.Validate(); err != nil {
+		return fmt.Errorf("invalid trigger: %w", err)
+	}
 	var start, end time.Time
 	var err error
 	if startStr != "" {
@@ -120,7 +167,10 @@ func Run(ctx context.Context, key, topics, profileName, startStr, endStr string)
 	for i := range tlist {
 		tlist[i] = strings.TrimSpace(tlist[i])
 	}
-	cfg := TracerConfig{Profile: p.Name, Topics: tlist, Start: start, End: end, Key: key}
+	cfg := TracerConfig{Profile: p.Name, Topics: tlist, Start: start, End: end, Key: key, Trigger: trig, Broker: p.BrokerURL()}
+	if prev, ok := loadTraces()[key]; ok {
+		cfg.Description, cfg.Tags = prev.Description, prev.Tags
+	}
 	if err := tracerClearData(cfg.Profile, cfg.Key); err != nil {
 		return fmt.Errorf("clear data: %w", err)
 	}
@@ -132,6 +182,10 @@ func Run(ctx context.Context, key, topics, profileName, startStr, endStr string)
 		return fmt.Errorf("add trace: %w", err)
 	}
 
+	if trig.Armed() {
+		log.Printf("trace %s armed, waiting for start condition", key)
+	}
+
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt)
 	defer signal.Stop(sig)
@@ -146,6 +200,9 @@ func Run(ctx context.Context, key, topics, profileName, startStr, endStr string)
 		}
 	}
 
+	if err := saveStats(tr.Config()); err != nil {
+		log.Printf("save trace: %v", err)
+	}
 	for t, c := range tr.Counts() {
 		log.Printf("%s: %d", t, c)
 	}
@@ -23,6 +23,10 @@ func Init() State {
 		it := &traceItem{key: k, cfg: tracesCfg[k]}
 		traceItems = append(traceItems, it)
 		traceData = append(traceData, it)
+		for _, occ := range occurrenceItems(it.cfg) {
+			traceItems = append(traceItems, occ)
+			traceData = append(traceData, occ)
+		}
 	}
 	traceList.SetItems(traceItems)
 	ts := State{
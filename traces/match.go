@@ -19,3 +19,26 @@ func tracerMatch(filter, topic string) bool {
 	}
 	return len(fp) == len(tp)
 }
+
+// filterCovers reports whether every topic matching filter f also matches
+// filter g, so a subscription to g receives all messages of f.
+func filterCovers(g, f string) bool {
+	gp := strings.Split(g, "/")
+	fp := strings.Split(f, "/")
+	for i, fl := range fp {
+		if i >= len(gp) {
+			return false
+		}
+		switch {
+		case gp[i] == "#":
+			return true
+		case fl == "#":
+			return false
+		case gp[i] == "+":
+		case fl == "+" || gp[i] != fl:
+			return false
+		}
+	}
+	// a/# also matches a
+	return len(gp) == len(fp) || len(gp) == len(fp)+1 && gp[len(fp)] == "#"
+}
@@ -22,3 +22,29 @@ func TestMatch(t *testing.T) {
 		}
 	}
 }
+
+func TestFilterCovers(t *testing.T) {
+	cases := []struct {
+		g, f string
+		want bool
+	}{
+		{"a/#", "a/b", true},
+		{"a/#", "a/+", true},
+		{"a/#", "a/#", true},
+		{"a/#", "a", true},
+		{"#", "a/#", true},
+		{"a/+", "a/b", true},
+		{"a/+", "a/+", true},
+		{"a/+", "a/#", false},
+		{"a/+", "a/b/c", false},
+		{"a/b", "a/+", false},
+		{"a/+/c", "a/#", false},
+		{"a/b/#", "a/#", false},
+		{"+/+", "a/b", true},
+	}
+	for _, c := range cases {
+		if got := filterCovers(c.g, c.f); got != c.want {
+			t.Errorf("filterCovers(%q,%q)=%v want %v", c.g, c.f, got, c.want)
+		}
+	}
+}
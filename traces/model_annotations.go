@@ -0,0 +1,99 @@
+package traces
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/marang/emqutiti/constants"
+	"github.com/marang/emqutiti/history"
+)
+
+// startNote opens the annotation prompt in the trace viewer.
+func (t *Component) startNote() tea.Cmd {
+	ti := textinput.New()
+	ti.Prompt = "Note: "
+	ti.Placeholder = "what happened here"
+	ti.CharLimit = 512
+	ti.Focus()
+	t.note = &ti
+	return textinput.Blink
+}
+
+// updateNote handles input while the annotation prompt is open.
+func (t *Component) updateNote(msg tea.Msg) tea.Cmd {
+	if km, ok := msg.(tea.KeyMsg); ok {
+		switch km.String() {
+		case constants.KeyEsc:
+			t.note = nil
+			return nil
+		case constants.KeyEnter:
+			text := strings.TrimSpace(t.note.Value())
+			t.note = nil
+			if text != "" {
+				t.saveNote(text)
+			}
+			return nil
+		}
+	}
+	var cmd tea.Cmd
+	*t.note, cmd = t.note.Update(msg)
+	return cmd
+}
+
+// saveNote stores text as annotation of the viewed trace. It marks the
+// selected message, or the current time when no message is selected.
+func (t *Component) saveNote(text string) {
+	idx := t.traceIndex(t.viewKey)
+	if idx < 0 {
+		return
+	}
+	it := t.items[idx]
+	a := Annotation{Timestamp: time.Now(), Text: text}
+	if sel, ok := t.Component.List().SelectedItem().(history.Item); ok && sel.Kind != annotationKind {
+		a.Timestamp, a.Topic, a.Message = sel.Timestamp, sel.Topic, sel.Timestamp
+	}
+	if err := AddAnnotation(it.cfg.Profile, it.key, a); err != nil {
+		t.api.LogHistory("", err.Error(), "log", false, err.Error())
+		return
+	}
+	t.insertNote(a.item())
+}
+
+// insertNote adds an annotation item to the viewer in time order.
+func (t *Component) insertNote(hi history.Item) {
+	items := t.Component.Items()
+	pos := sort.Search(len(items), func(i int) bool { return items[i].Timestamp.After(hi.Timestamp) })
+	items = slices.Insert(items, pos, hi)
+	t.Component.SetItems(items)
+	listItems := make([]list.Item, len(items))
+	for i, it := range items {
+		listItems[i] = it
+	}
+	t.Component.List().SetItems(listItems)
+	t.Component.List().Select(pos)
+	t.Component.ApplySort()
+	if st := t.Component.Store(); st != nil {
+		_ = st.Append(history.Message{Timestamp: hi.Timestamp, Topic: hi.Topic, Payload: hi.Payload, Kind: hi.Kind})
+	}
+}
+
+// withAnnotations merges the annotations of a trace into its messages in
+// time order.
+func withAnnotations(msgs []TracerMessage, notes []Annotation) []TracerMessage {
+	if len(notes) == 0 {
+		return msgs
+	}
+	out := make([]TracerMessage, 0, len(msgs)+len(notes))
+	out = append(out, msgs...)
+	for _, a := range notes {
+		out = append(out, TracerMessage{Timestamp: a.Timestamp, Topic: a.Topic, Payload: a.Text, Kind: annotationKind})
+	}
+	sort.SliceStable(out, func(i, k int) bool { return out[i].Timestamp.Before(out[k].Timestamp) })
+	return out
+}
@@ -0,0 +1,49 @@
+package traces
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// daemonRefresh is the minimum interval between daemon status queries.
+const daemonRefresh = 5 * time.Second
+
+// refreshDaemon updates which traces run in the daemon serving the proxy,
+// at most once per daemonRefresh.
+func (t *Component) refreshDaemon(now time.Time) {
+	if now.Sub(t.daemonAt) < daemonRefresh {
+		return
+	}
+	t.daemonAt = now
+	remote, err := daemonTraces()
+	if err != nil {
+		remote = nil
+	}
+	for _, it := range t.items {
+		it.remote = remote[it.key]
+	}
+}
+
+// remoteActive reports whether the daemon records or waits to record the
+// trace.
+func (t *traceItem) remoteActive() bool {
+	return t.remote != nil && DaemonActive(t.remote.GetState())
+}
+
+// daemonDescription summarizes a trace run by the daemon.
+func (t *traceItem) daemonDescription() string {
+	st := t.remote
+	parts := []string{"daemon " + st.GetState()}
+	if t.cfg.Repeat != "" {
+		parts = append(parts, "repeat "+t.cfg.Repeat)
+	}
+	parts = append(parts, fmt.Sprintf("%d msg(s)", st.GetMessages()))
+	if st.GetNext() > 0 {
+		parts = append(parts, "next "+time.Unix(st.GetNext(), 0).Format(occurrenceLayout))
+	}
+	if st.GetError() != "" {
+		parts = append(parts, "error: "+st.GetError())
+	}
+	return strings.Join(parts, " ")
+}
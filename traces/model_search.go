@@ -0,0 +1,80 @@
+package traces
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/marang/emqutiti/constants"
+	"github.com/marang/emqutiti/history"
+)
+
+// startSearch opens the prompt for a search across all traces.
+func (t *Component) startSearch() tea.Cmd {
+	ti := textinput.New()
+	ti.Prompt = "Search: "
+	ti.Placeholder = "tag=a,b topic=x/# start=RFC3339 end=RFC3339 text"
+	ti.SetValue(t.searchQuery)
+	ti.Focus()
+	t.search = &ti
+	return textinput.Blink
+}
+
+// updateSearch handles input while the search prompt is open.
+func (t *Component) updateSearch(msg tea.Msg) tea.Cmd {
+	if km, ok := msg.(tea.KeyMsg); ok {
+		switch km.String() {
+		case constants.KeyEsc:
+			t.search = nil
+			return nil
+		case constants.KeyEnter:
+			q := strings.TrimSpace(t.search.Value())
+			t.search = nil
+			return t.runSearch(q)
+		}
+	}
+	var cmd tea.Cmd
+	*t.search, cmd = t.search.Update(msg)
+	return cmd
+}
+
+// runSearch shows the messages matching q in the trace viewer, each group
+// headed by a line naming its trace.
+func (t *Component) runSearch(q string) tea.Cmd {
+	results, err := Search(ParseSearch(q))
+	if err != nil {
+		t.api.LogHistory("", err.Error(), "log", false, err.Error())
+		return nil
+	}
+	if len(results) == 0 {
+		msg := fmt.Sprintf("no trace messages match %q", q)
+		t.api.LogHistory("", msg, "log", false, msg)
+		return nil
+	}
+	var histItems []history.Item
+	var hmsgs []history.Message
+	for _, r := range results {
+		head := history.Item{Timestamp: r.Messages[0].Timestamp, Payload: resultTitle(r), Kind: "log"}
+		histItems = append(histItems, head)
+		for _, m := range r.Messages {
+			histItems = append(histItems, history.Item{Timestamp: m.Timestamp, Topic: m.Topic, Payload: m.Payload, Kind: m.Kind, Retained: m.Retained})
+			hmsgs = append(hmsgs, history.Message{Timestamp: m.Timestamp, Topic: m.Topic, Payload: m.Payload, Kind: m.Kind, Retained: m.Retained})
+		}
+	}
+	listItems := make([]list.Item, len(histItems))
+	for i, it := range histItems {
+		listItems[i] = it
+	}
+	t.Component.SetColumns(history.LoadColumns())
+	t.Component.SetItems(histItems)
+	t.Component.List().SetItems(listItems)
+	t.Component.SetStore(newMemStore(hmsgs))
+	t.Component.ApplySort()
+	t.Component.List().SetSize(t.api.Width()-4, t.api.TraceHeight())
+	t.viewKey = ""
+	t.searchQuery = q
+	return t.api.SetModeViewTrace()
+}
@@ -0,0 +1,109 @@
+package traces
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// occurrenceConfig returns the configuration of a recorded occurrence of the
+// recurring trace cfg.
+func occurrenceConfig(cfg TracerConfig, key string) TracerConfig {
+	occ := TracerConfig{Key: key, Profile: cfg.Profile, Topics: cfg.Topics, Trigger: cfg.Trigger}
+	stamp := strings.TrimPrefix(key, cfg.Key+occurrenceSep)
+	if tm, err := time.ParseInLocation(occurrenceLayout, stamp, time.Local); err == nil {
+		occ.Start = tm
+		if s, err := ParseSchedule(cfg.Repeat); err == nil {
+			occ.End = tm.Add(s.Duration)
+		}
+	}
+	return occ
+}
+
+// occurrenceItems returns list items for the recorded runs of cfg.
+func occurrenceItems(cfg TracerConfig) []*traceItem {
+	var out []*traceItem
+	for _, run := range cfg.Runs {
+		out = append(out, &traceItem{key: run, cfg: occurrenceConfig(cfg, run), parent: cfg.Key})
+	}
+	return out
+}
+
+// newClient connects with the named profile.
+func (t *Component) newClient(profile string) (Client, error) {
+	p, err := loadProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+	return t.api.NewClient(*p)
+}
+
+// startSeries schedules the recurring trace at index.
+func (t *Component) startSeries(index int) {
+	item := t.items[index]
+	profile := item.cfg.Profile
+	s, err := newSeries(item.cfg, func() (Client, error) { return t.newClient(profile) })
+	if err != nil {
+		t.api.LogHistory("", err.Error(), "log", false, err.Error())
+		return
+	}
+	if err := s.Start(); err != nil {
+		t.api.LogHistory("", err.Error(), "log", false, err.Error())
+		return
+	}
+	item.series = s
+}
+
+// syncRuns adds list items for occurrences recorded since the last refresh.
+func (t *Component) syncRuns() {
+	changed := false
+	for _, it := range slices.Clone(t.items) {
+		if it.series == nil {
+			continue
+		}
+		cfg := it.series.Config()
+		for _, run := range cfg.Runs {
+			if t.traceIndex(run) >= 0 {
+				continue
+			}
+			occ := &traceItem{key: run, cfg: occurrenceConfig(cfg, run), parent: it.key}
+			pos := t.traceIndex(it.key) + 1
+			for pos < len(t.items) && t.items[pos].parent == it.key {
+				pos++
+			}
+			t.items = slices.Insert(t.items, pos, occ)
+			changed = true
+		}
+		it.cfg.Runs = cfg.Runs
+	}
+	if changed {
+		t.refreshList()
+	}
+}
+
+// refreshList rebuilds the list items from t.items.
+func (t *Component) refreshList() {
+	items := make([]list.Item, len(t.items))
+	for i, it := range t.items {
+		items[i] = it
+	}
+	t.list.SetItems(items)
+}
+
+// removeRun forgets an occurrence of the recurring trace parent.
+func (t *Component) removeRun(parent, key string) {
+	idx := t.traceIndex(parent)
+	if idx < 0 {
+		return
+	}
+	p := t.items[idx]
+	p.cfg.Runs = slices.DeleteFunc(slices.Clone(p.cfg.Runs), func(r string) bool { return r == key })
+	if p.series != nil {
+		p.series.forget(key)
+	}
+	if err := addTrace(p.cfg); err != nil {
+		t.api.LogHistory("", err.Error(), "log", false, err.Error())
+	}
+}
@@ -7,27 +7,24 @@ import (
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 
-	connections "github.com/marang/emqutiti/connections"
+	"github.com/marang/emqutiti/charts"
 	"github.com/marang/emqutiti/history"
 )
 
 // forceStartTrace launches the tracer at index without checking existing data.
 func (t *Component) forceStartTrace(index int) {
 	item := t.items[index]
-	p, err := connections.LoadProfile(item.cfg.Profile, "")
+	p, err := loadProfile(item.cfg.Profile)
 	if err != nil {
 		t.api.LogHistory("", err.Error(), "log", false, err.Error())
 		return
 	}
-	if p.FromEnv {
-		connections.ApplyEnvVars(p)
-	}
-	connections.ApplyDefaultPassword(p)
 	client, err := t.api.NewClient(*p)
 	if err != nil {
 		t.api.LogHistory("", err.Error(), "log", false, err.Error())
 		return
 	}
+	item.cfg.Broker = p.BrokerURL()
 	tr := newTracer(item.cfg, client)
 	if err := tr.Start(); err != nil {
 		t.api.LogHistory("", err.Error(), "log", false, err.Error())
@@ -46,6 +43,18 @@ func (t *Component) startTrace(index int) {
 		return
 	}
 	item := t.items[index]
+	if item.parent != "" {
+		return
+	}
+	if item.remoteActive() {
+		msg := fmt.Sprintf("trace '%s' runs in the daemon", item.key)
+		t.api.LogHistory("", msg, "log", false, msg)
+		return
+	}
+	if item.cfg.Repeat != "" {
+		t.startSeries(index)
+		return
+	}
 	if !item.cfg.End.IsZero() && time.Now().After(item.cfg.End) {
 		t.api.LogHistory("", fmt.Sprintf("trace '%s' already finished", item.key), "log", false, fmt.Sprintf("trace '%s' already finished", item.key))
 		return
@@ -55,6 +64,7 @@ func (t *Component) startTrace(index int) {
 		rf := func() tea.Cmd { return t.api.SetFocus(t.api.FocusedID()) }
 		t.api.StartConfirm(fmt.Sprintf("Overwrite trace '%s'? [y/n]", item.key), "existing trace data will be removed", rf, func() tea.Cmd {
 			tracerClearData(item.cfg.Profile, item.key)
+			item.cfg.resetStats()
 			t.forceStartTrace(index)
 			return nil
 		}, nil)
@@ -70,13 +80,20 @@ func (t *Component) stopTrace(index int) {
 	}
 	if tr := t.items[index].tracer; tr != nil {
 		tr.Stop()
+		t.items[index].cfg = tr.Config()
+		if err := saveStats(tr.Config()); err != nil {
+			t.api.LogHistory("", err.Error(), "log", false, err.Error())
+		}
+	}
+	if s := t.items[index].series; s != nil {
+		s.Stop()
 	}
 }
 
 // anyTraceRunning reports whether any tracer is currently active or planned.
 func (t *Component) anyTraceRunning() bool {
 	for i := range t.items {
-		if tr := t.items[i].tracer; tr != nil && (tr.Running() || tr.Planned()) {
+		if t.items[i].active() || t.items[i].remoteActive() {
 			return true
 		}
 	}
@@ -97,7 +114,12 @@ func (t *Component) traceIndex(key string) int {
 func (t *Component) SavePlannedTraces() {
 	data := map[string]TracerConfig{}
 	for _, it := range t.items {
-		if it.tracer != nil {
+		if it.parent != "" {
+			continue
+		}
+		if it.series != nil {
+			data[it.key] = it.series.Config()
+		} else if it.tracer != nil {
 			data[it.key] = it.tracer.Config()
 		} else {
 			data[it.key] = it.cfg
@@ -114,11 +136,16 @@ func (t *Component) loadTraceMessages(index int) {
 		return
 	}
 	it := t.items[index]
-	msgs, err := tracerMessages(it.cfg.Profile, it.key)
+	msgs, err := seriesMessages(it.cfg.Profile, it.key, it.cfg.Runs, tracerMessages)
 	if err != nil {
 		t.api.LogHistory("", err.Error(), "log", false, err.Error())
 		return
 	}
+	notes, err := Annotations(it.cfg.Profile, it.key)
+	if err != nil {
+		t.api.LogHistory("", err.Error(), "log", false, err.Error())
+	}
+	msgs = withAnnotations(msgs, notes)
 	histItems := make([]history.Item, len(msgs))
 	listItems := make([]list.Item, len(msgs))
 	hmsgs := make([]history.Message, len(msgs))
@@ -128,10 +155,50 @@ func (t *Component) loadTraceMessages(index int) {
 		listItems[i] = hi
 		hmsgs[i] = history.Message{Timestamp: mmsg.Timestamp, Topic: mmsg.Topic, Payload: mmsg.Payload, Kind: mmsg.Kind, Archived: false, Retained: mmsg.Retained}
 	}
+	t.Component.SetColumns(history.LoadColumns())
 	t.Component.SetItems(histItems)
 	t.Component.List().SetItems(listItems)
 	t.Component.SetStore(newMemStore(hmsgs))
+	t.Component.ApplySort()
 	t.Component.List().SetSize(t.api.Width()-4, t.api.TraceHeight())
 	t.viewKey = it.key
+	t.searchQuery = ""
 	_ = t.api.SetModeViewTrace()
 }
+
+// exportMessages writes the shown trace messages with their columns to CSV.
+func (t *Component) exportMessages() {
+	var path string
+	if idx := t.traceIndex(t.viewKey); idx >= 0 {
+		it := t.items[idx]
+		path = history.ExportPath(it.cfg.Profile, "trace-"+it.key)
+	} else if t.viewKey == "" && t.searchQuery != "" {
+		path = history.ExportPath(t.api.ActiveConnection(), "trace-search")
+	} else {
+		return
+	}
+	items := t.Component.Items()
+	msg := fmt.Sprintf("Exported %d trace message(s) to %s", len(items), path)
+	if err := t.Component.Export(path, items); err != nil {
+		msg = fmt.Sprintf("export error: %v", err)
+	}
+	t.api.LogHistory("", msg, "log", false, msg)
+}
+
+// chartTrace plots the stored messages of the trace at index.
+func (t *Component) chartTrace(index int) tea.Cmd {
+	if index < 0 || index >= len(t.items) {
+		return nil
+	}
+	it := t.items[index]
+	msgs, err := t.store.Messages(it.cfg.Profile, it.key)
+	if err != nil {
+		t.api.LogHistory("", err.Error(), "log", false, err.Error())
+		return nil
+	}
+	samples := make([]charts.Sample, len(msgs))
+	for i, m := range msgs {
+		samples[i] = charts.Sample{Time: m.Timestamp, Topic: m.Topic, Payload: m.Payload}
+	}
+	return t.api.ShowTraceChart(it.key, it.cfg.Topics, samples)
+}
@@ -17,6 +17,42 @@ type TracerConfig struct {
 	Start   time.Time
 	End     time.Time
 	Key     string
+	Trigger Trigger
+	// Repeat is a recurrence schedule, see ParseSchedule. Runs lists the
+	// derived keys of recorded occurrences.
+	Repeat string
+	Runs   []string
+
+	// Metadata describing the trace and its recorded data.
+	Description string
+	Tags        []string
+	Broker      string    // broker URL when recording started
+	Messages    int       // recorded messages
+	Bytes       int64     // recorded payload bytes
+	First       time.Time // first recorded message
+	Last        time.Time // last recorded message
+}
+
+// resetStats clears the recorded data statistics.
+func (c *TracerConfig) resetStats() {
+	c.Messages, c.Bytes, c.First, c.Last = 0, 0, time.Time{}, time.Time{}
+}
+
+// addStats adds the statistics of o, e.g. of a recurring trace occurrence.
+func (c *TracerConfig) addStats(o TracerConfig) {
+	c.Messages += o.Messages
+	c.Bytes += o.Bytes
+	if !o.First.IsZero() && (c.First.IsZero() || o.First.Before(c.First)) {
+		c.First = o.First
+	}
+	if o.Last.After(c.Last) {
+		c.Last = o.Last
+	}
+}
+
+// record counts a stored message.
+func (c *TracerConfig) record(msg TracerMessage) {
+	c.addStats(TracerConfig{Messages: 1, Bytes: int64(len(msg.Payload)), First: msg.Timestamp, Last: msg.Timestamp})
 }
 
 // Client abstracts the MQTT client used by the tracer.
@@ -28,14 +64,20 @@ type Client interface {
 
 // Tracer collects MQTT messages within a time range and stores them.
 type Tracer struct {
+	// cfg is not changed after newTracer, the statistics of messages
+	// recorded since then are kept in stats.
 	cfg     TracerConfig
 	mu      sync.Mutex
 	running bool
+	stats   TracerConfig
 	counts  map[string]int
 	client  Client
 	cancel  context.CancelFunc
 	done    chan struct{}
 	report  chan error
+	rec     *recorder
+	finish  chan struct{}
+	once    sync.Once
 }
 
 // newTracer creates a new Tracer with the given config.
@@ -59,9 +101,17 @@ func (t *Tracer) Start() error {
 		t.mu.Unlock()
 		return fmt.Errorf("trace already running")
 	}
+	cfg := t.cfg
+	rec, err := newRecorder(cfg.Trigger, cfg.Topics)
+	if err != nil {
+		t.mu.Unlock()
+		return err
+	}
 	t.running = true
+	t.rec = rec
+	t.finish = make(chan struct{})
 	t.counts = make(map[string]int)
-	for _, tp := range t.cfg.Topics {
+	for _, tp := range cfg.Topics {
 		t.counts[tp] = 0
 	}
 	t.mu.Unlock()
@@ -95,7 +145,7 @@ func (t *Tracer) Start() error {
 			}
 		}()
 
-		delay := time.Until(t.cfg.Start)
+		delay := time.Until(cfg.Start)
 		if delay > 0 {
 			select {
 			case <-time.After(delay):
@@ -106,8 +156,8 @@ func (t *Tracer) Start() error {
 
 		endCh := make(<-chan time.Time)
 		var timer *time.Timer
-		if !t.cfg.End.IsZero() {
-			if d := time.Until(t.cfg.End); d > 0 {
+		if !cfg.End.IsZero() {
+			if d := time.Until(cfg.End); d > 0 {
 				timer = time.NewTimer(d)
 				endCh = timer.C
 			}
@@ -116,38 +166,82 @@ func (t *Tracer) Start() error {
 			defer timer.Stop()
 		}
 
-		for _, topic := range t.cfg.Topics {
-			if err := client.Subscribe(topic, 0, func(_ mqtt.Client, m mqtt.Message) {
+		t.mu.Lock()
+		t.rec.begin(time.Now())
+		t.mu.Unlock()
+
+		handler := func(extra bool) mqtt.MessageHandler {
+			return func(_ mqtt.Client, m mqtt.Message) {
 				ts := time.Now()
-				if !t.cfg.End.IsZero() && ts.After(t.cfg.End) {
+				if !cfg.End.IsZero() && ts.After(cfg.End) {
 					return
 				}
-				if ts.Before(t.cfg.Start) {
+				if ts.Before(cfg.Start) {
 					return
 				}
-				if err := tracerAddClient(cl, t.cfg.Profile, t.cfg.Key, TracerMessage{Timestamp: ts, Topic: m.Topic(), Payload: string(m.Payload()), Kind: "trace", Retained: m.Retained()}); err != nil {
-					t.reportErr(fmt.Errorf("tracerAdd: %w", err))
+				msg := TracerMessage{Timestamp: ts, Topic: m.Topic(), Payload: string(m.Payload()), Kind: "trace", Retained: m.Retained()}
+				t.mu.Lock()
+				if extra && t.rec.traced(msg.Topic) {
+					// delivered through a traced subscription as well
+					t.mu.Unlock()
 					return
 				}
-				t.mu.Lock()
-				for _, sub := range t.cfg.Topics {
-					if tracerMatch(sub, m.Topic()) {
-						t.counts[sub]++
+				store, done := t.rec.handle(msg)
+				t.mu.Unlock()
+				for _, sm := range store {
+					if err := tracerAddClient(cl, cfg.Profile, cfg.Key, sm); err != nil {
+						t.reportErr(fmt.Errorf("tracerAdd: %w", err))
+						return
+					}
+					t.mu.Lock()
+					for _, sub := range cfg.Topics {
+						if tracerMatch(sub, sm.Topic) {
+							t.counts[sub]++
+						}
 					}
+					t.stats.record(sm)
+					t.mu.Unlock()
 				}
-				t.mu.Unlock()
-			}); err != nil {
+				if done {
+					t.once.Do(func() { close(t.finish) })
+				}
+			}
+		}
+		for _, topic := range cfg.Topics {
+			if err := client.Subscribe(topic, 0, handler(false)); err != nil {
+				fmt.Printf("subscribe %s: %v\n", topic, err)
+				return
+			}
+		}
+		for _, topic := range triggerTopics(cfg) {
+			if err := client.Subscribe(topic, 0, handler(true)); err != nil {
 				fmt.Printf("subscribe %s: %v\n", topic, err)
 				return
 			}
 		}
 
+		var expiry <-chan time.Time
+		if cfg.Trigger.MaxDuration > 0 {
+			ticker := time.NewTicker(100 * time.Millisecond)
+			defer ticker.Stop()
+			expiry = ticker.C
+		}
+
 		for {
 			select {
 			case <-ctx.Done():
 				return
 			case <-endCh:
 				return
+			case <-t.finish:
+				return
+			case now := <-expiry:
+				t.mu.Lock()
+				expired := t.rec.expired(now)
+				t.mu.Unlock()
+				if expired {
+					return
+				}
 			}
 		}
 	}()
@@ -180,11 +274,33 @@ func (t *Tracer) Running() bool {
 	return t.running && time.Now().After(t.cfg.Start) && (t.cfg.End.IsZero() || time.Now().Before(t.cfg.End))
 }
 
+// Armed reports whether the trace is running but waits for its start
+// condition.
+func (t *Tracer) Armed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.running && t.rec != nil && !t.rec.triggered
+}
+
+// completed reports whether the trigger ended recording.
+func (t *Tracer) completed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rec != nil && (t.rec.done || t.rec.expired(time.Now()))
+}
+
 // Planned reports whether the trace start time is in the future.
 func (t *Tracer) Planned() bool { return time.Now().Before(t.cfg.Start) }
 
-// Config returns the trace configuration.
-func (t *Tracer) Config() TracerConfig { return t.cfg }
+// Config returns the trace configuration including the statistics of the
+// recorded data.
+func (t *Tracer) Config() TracerConfig {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cfg := t.cfg
+	cfg.addStats(t.stats)
+	return cfg
+}
 
 // Counts returns the per-topic message counts.
 func (t *Tracer) Counts() map[string]int {
@@ -201,3 +317,27 @@ func (t *Tracer) Counts() map[string]int {
 func (t *Tracer) Messages() ([]TracerMessage, error) {
 	return tracerMessages(t.cfg.Profile, t.cfg.Key)
 }
+
+// triggerTopics returns the trigger filters that need their own
+// subscription because no traced topic covers them.
+func triggerTopics(cfg TracerConfig) []string {
+	var out []string
+	for _, f := range []string{cfg.Trigger.StartTopic, cfg.Trigger.StopTopic} {
+		if f == "" {
+			continue
+		}
+		if !coveredBy(cfg.Topics, f) && !coveredBy(out, f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func coveredBy(filters []string, f string) bool {
+	for _, tp := range filters {
+		if filterCovers(tp, f) {
+			return true
+		}
+	}
+	return false
+}
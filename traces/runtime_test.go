@@ -35,6 +35,8 @@ func newFakeClient() *fakeClient {
 }
 
 func (f *fakeClient) Subscribe(topic string, qos byte, cb mqtt.MessageHandler) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	f.subs[topic] = func(c mqtt.Client, m mqtt.Message) {
 		cb(c, m)
 		if f.wg != nil {
@@ -55,6 +57,16 @@ func (f *fakeClient) Unsubscribe(topic string) error {
 }
 func (f *fakeClient) Disconnect() {}
 
+func (f *fakeClient) subscribed() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	var out []string
+	for k := range f.subs {
+		out = append(out, k)
+	}
+	return out
+}
+
 func (f *fakeClient) publish(topic, payload string) {
 	f.mu.RLock()
 	cb, ok := f.subs[topic]
@@ -0,0 +1,232 @@
+package traces
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultOccurrence is the length of a recurring trace without explicit end.
+const defaultOccurrence = time.Hour
+
+// occurrenceLayout formats the start time appended to derived trace keys.
+const occurrenceLayout = "2006-01-02T15:04"
+
+// Schedule describes when a recurring trace records.
+//
+// Supported forms, each optionally followed by "for <duration>":
+//
+//	daily 02:00[-03:00]
+//	weekly mon,thu 02:00[-02:30]
+//	0 2 * * 1-5          (five field cron expression)
+type Schedule struct {
+	spec     string
+	minute   []bool
+	hour     []bool
+	dom      []bool
+	month    []bool
+	dow      []bool
+	anyDom   bool
+	anyDow   bool
+	Duration time.Duration
+}
+
+// String returns the source specification.
+func (s Schedule) String() string { return s.spec }
+
+// ParseSchedule parses a recurrence specification.
+func ParseSchedule(spec string) (Schedule, error) {
+	s := Schedule{spec: strings.TrimSpace(spec), Duration: defaultOccurrence}
+	fields := strings.Fields(strings.ToLower(s.spec))
+	if n := len(fields); n >= 2 && fields[n-2] == "for" {
+		d, err := time.ParseDuration(fields[n-1])
+		if err != nil || d <= 0 {
+			return s, fmt.Errorf("invalid duration %q", fields[n-1])
+		}
+		s.Duration = d
+		fields = fields[:n-2]
+	}
+	if len(fields) == 0 {
+		return s, fmt.Errorf("empty schedule")
+	}
+	var cron []string
+	switch fields[0] {
+	case "daily", "weekly":
+		days := "*"
+		rest := fields[1:]
+		if fields[0] == "weekly" {
+			if len(rest) == 0 {
+				return s, fmt.Errorf("weekly schedule needs days")
+			}
+			days, rest = rest[0], rest[1:]
+		}
+		if len(rest) != 1 {
+			return s, fmt.Errorf("expected %s HH:MM[-HH:MM]", fields[0])
+		}
+		from, to, hasEnd := strings.Cut(rest[0], "-")
+		h, m, err := parseClock(from)
+		if err != nil {
+			return s, err
+		}
+		if hasEnd {
+			eh, em, err := parseClock(to)
+			if err != nil {
+				return s, err
+			}
+			d := time.Duration((eh*60+em)-(h*60+m)) * time.Minute
+			if d <= 0 {
+				d += 24 * time.Hour
+			}
+			s.Duration = d
+		}
+		cron = []string{strconv.Itoa(m), strconv.Itoa(h), "*", "*", days}
+	default:
+		cron = fields
+	}
+	if len(cron) != 5 {
+		return s, fmt.Errorf("cron expression needs 5 fields, got %d", len(cron))
+	}
+	var err error
+	if s.minute, err = parseCronField(cron[0], 0, 59, nil); err != nil {
+		return s, fmt.Errorf("minute: %w", err)
+	}
+	if s.hour, err = parseCronField(cron[1], 0, 23, nil); err != nil {
+		return s, fmt.Errorf("hour: %w", err)
+	}
+	if s.dom, err = parseCronField(cron[2], 1, 31, nil); err != nil {
+		return s, fmt.Errorf("day of month: %w", err)
+	}
+	if s.month, err = parseCronField(cron[3], 1, 12, monthNames); err != nil {
+		return s, fmt.Errorf("month: %w", err)
+	}
+	if s.dow, err = parseCronField(cron[4], 0, 7, dayNames); err != nil {
+		return s, fmt.Errorf("day of week: %w", err)
+	}
+	s.dow[0] = s.dow[0] || s.dow[7]
+	s.anyDom = cron[2] == "*"
+	s.anyDow = cron[4] == "*"
+	return s, nil
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+func parseClock(s string) (int, int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time %q", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// parseCronField parses lists, ranges, steps and names into a set.
+func parseCronField(field string, lo, hi int, names map[string]int) ([]bool, error) {
+	set := make([]bool, hi+1)
+	value := func(s string) (int, error) {
+		if n, ok := names[s]; ok {
+			return n, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < lo || n > hi {
+			return 0, fmt.Errorf("invalid value %q", s)
+		}
+		return n, nil
+	}
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step %q", stepStr)
+			}
+			step = n
+		}
+		from, to := lo, hi
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if from, err = value(a); err != nil {
+				return nil, err
+			}
+			to = from
+			if isRange {
+				if to, err = value(b); err != nil {
+					return nil, err
+				}
+			} else if hasStep {
+				to = hi
+			}
+			if to < from {
+				return nil, fmt.Errorf("invalid range %q", rng)
+			}
+		}
+		for i := from; i <= to; i += step {
+			set[i] = true
+		}
+	}
+	return set, nil
+}
+
+func (s Schedule) dayMatches(t time.Time) bool {
+	dom, dow := s.dom[t.Day()], s.dow[int(t.Weekday())]
+	switch {
+	case s.anyDom && s.anyDow:
+		return true
+	case s.anyDom:
+		return dow
+	case s.anyDow:
+		return dom
+	default:
+		// cron semantics: either restricted day field may match
+		return dom || dow
+	}
+}
+
+// Next returns the first occurrence start at or after t.
+func (s Schedule) Next(t time.Time) time.Time {
+	if tr := t.Truncate(time.Minute); !tr.Equal(t) {
+		t = tr.Add(time.Minute)
+	}
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !s.month[int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !s.hour[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !s.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// occurrenceSep separates the trace key from the start of an occurrence. It
+// may not appear in trace keys so the data of occurrences never falls under
+// the key prefix of their trace.
+const occurrenceSep = "@"
+
+// OccurrenceKey derives the trace key of the occurrence starting at start.
+func OccurrenceKey(key string, start time.Time) string {
+	return key + occurrenceSep + start.Format(occurrenceLayout)
+}
+
+// ValidateKey reports trace keys that cannot be used.
+func ValidateKey(key string) error {
+	if strings.Contains(key, occurrenceSep) {
+		return fmt.Errorf("trace key %q may not contain %q", key, occurrenceSep)
+	}
+	return nil
+}
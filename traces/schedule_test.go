@@ -0,0 +1,98 @@
+package traces
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/marang/emqutiti/proxy"
+)
+
+func TestScheduleNext(t *testing.T) {
+	loc := time.UTC
+	// Saturday
+	from := time.Date(2026, 10, 17, 2, 30, 0, 0, loc)
+	cases := []struct {
+		spec string
+		want time.Time
+		dur  time.Duration
+	}{
+		{"daily 02:00-03:00", time.Date(2026, 10, 18, 2, 0, 0, 0, loc), time.Hour},
+		{"daily 23:30-00:15", time.Date(2026, 10, 17, 23, 30, 0, 0, loc), 45 * time.Minute},
+		{"weekly mon,thu 02:00 for 30m", time.Date(2026, 10, 19, 2, 0, 0, 0, loc), 30 * time.Minute},
+		{"*/15 * * * *", time.Date(2026, 10, 17, 2, 30, 0, 0, loc), time.Hour},
+		{"0 2 1 * *", time.Date(2026, 11, 1, 2, 0, 0, 0, loc), time.Hour},
+		{"0 9 * jan sun", time.Date(2027, 1, 3, 9, 0, 0, 0, loc), time.Hour},
+	}
+	for _, c := range cases {
+		s, err := ParseSchedule(c.spec)
+		if err != nil {
+			t.Fatalf("%q: %v", c.spec, err)
+		}
+		if got := s.Next(from); !got.Equal(c.want) {
+			t.Fatalf("%q: next %v, want %v", c.spec, got, c.want)
+		}
+		if s.Duration != c.dur {
+			t.Fatalf("%q: duration %v, want %v", c.spec, s.Duration, c.dur)
+		}
+	}
+	for _, bad := range []string{"", "daily", "weekly 02:00", "daily 25:00", "1 2 3", "61 * * * *", "* * * * * for -1m"} {
+		if _, err := ParseSchedule(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestOccurrenceConfig(t *testing.T) {
+	cfg := TracerConfig{Key: "run1", Profile: "p", Repeat: "daily 02:00-02:30", Runs: []string{"run1@2026-10-17T02:00"}}
+	items := occurrenceItems(cfg)
+	if len(items) != 1 || items[0].parent != "run1" {
+		t.Fatalf("unexpected items %+v", items)
+	}
+	occ := items[0].cfg
+	if occ.Start.Format(occurrenceLayout) != "2026-10-17T02:00" || occ.End.Sub(occ.Start) != 30*time.Minute {
+		t.Fatalf("unexpected occurrence times %v %v", occ.Start, occ.End)
+	}
+}
+
+func TestSeriesRecordsOccurrence(t *testing.T) {
+	os.Setenv("HOME", t.TempDir())
+	p, err := proxy.StartProxy("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("start proxy: %v", err)
+	}
+	SetProxyAddr(p.Addr())
+	t.Cleanup(p.Stop)
+
+	fc := newFakeClient()
+	cfg := TracerConfig{Key: "nightly", Profile: "test", Topics: []string{"a"}, Repeat: "* * * * * for 2m"}
+	s, err := newSeries(cfg, func() (Client, error) { return fc, nil })
+	if err != nil {
+		t.Fatalf("newSeries: %v", err)
+	}
+	var saved TracerConfig
+	s.save = func(c TracerConfig) error { saved = c; return nil }
+	s.started = make(chan string, 1)
+	if err := s.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	// the occurrence of the current minute is still in progress
+	key := <-s.started
+	if key != OccurrenceKey("nightly", time.Now().Truncate(time.Minute)) &&
+		key != OccurrenceKey("nightly", time.Now().Truncate(time.Minute).Add(-time.Minute)) {
+		t.Fatalf("unexpected occurrence key %s", key)
+	}
+	<-fc.subCh
+	fc.publish("a", "hello")
+	s.Stop()
+	if s.Scheduled() {
+		t.Fatalf("expected series to stop")
+	}
+	if len(saved.Runs) != 1 || saved.Runs[0] != key || s.Config().Repeat == "" {
+		t.Fatalf("unexpected saved config %+v", saved)
+	}
+	msgs, err := tracerMessages("test", key)
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("expected message stored under %s: %v %v", key, msgs, err)
+	}
+}
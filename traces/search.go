@@ -0,0 +1,140 @@
+package traces
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/marang/emqutiti/history"
+)
+
+// SearchQuery selects messages across traces. Empty fields match anything.
+type SearchQuery struct {
+	Tags   []string // traces carrying all of these tags
+	Topics []string // topic filters, a message matches any of them
+	Start  time.Time
+	End    time.Time
+	Text   string // case-insensitive payload substring
+}
+
+// SearchResult holds the matching messages of one trace.
+type SearchResult struct {
+	Trace    TracerConfig
+	Messages []TracerMessage
+}
+
+// ParseSearch parses a query such as
+// `tag=plant,line2 topic=sensors/# start=2026-10-19T08:00:00Z valve`.
+// Besides tag= it accepts the history filter syntax.
+func ParseSearch(q string) SearchQuery {
+	var sq SearchQuery
+	var rest []string
+	for _, f := range strings.Fields(q) {
+		if v, ok := strings.CutPrefix(f, "tag="); ok {
+			sq.Tags = append(sq.Tags, splitTags(v)...)
+			continue
+		}
+		rest = append(rest, f)
+	}
+	sq.Topics, sq.Start, sq.End, sq.Text = history.ParseQuery(strings.Join(rest, " "))
+	return sq
+}
+
+// Search looks through the messages of all configured traces of all
+// profiles and returns the matches grouped by trace, ordered by key.
+func Search(q SearchQuery) ([]SearchResult, error) {
+	return searchTraces(loadTraces(), q, tracerMessages)
+}
+
+func searchTraces(cfgs map[string]TracerConfig, q SearchQuery, messages func(profile, key string) ([]TracerMessage, error)) ([]SearchResult, error) {
+	keys := make([]string, 0, len(cfgs))
+	for k := range cfgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	text := strings.ToLower(q.Text)
+	var out []SearchResult
+	for _, k := range keys {
+		cfg := cfgs[k]
+		if !hasTags(cfg.Tags, q.Tags) || !q.overlaps(cfg) {
+			continue
+		}
+		msgs, err := seriesMessages(cfg.Profile, k, cfg.Runs, messages)
+		if err != nil {
+			return nil, fmt.Errorf("trace %s: %w", k, err)
+		}
+		var hits []TracerMessage
+		for _, m := range msgs {
+			if q.matches(m, text) {
+				hits = append(hits, m)
+			}
+		}
+		if len(hits) > 0 {
+			out = append(out, SearchResult{Trace: cfg, Messages: hits})
+		}
+	}
+	return out, nil
+}
+
+// hasTags reports whether tags contains every wanted tag.
+func hasTags(tags, want []string) bool {
+	for _, w := range want {
+		if !slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, w) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// overlaps reports whether the recorded data of cfg may fall into the time
+// range. Traces without recorded timestamps are always searched.
+func (q SearchQuery) overlaps(cfg TracerConfig) bool {
+	if cfg.First.IsZero() || cfg.Last.IsZero() {
+		return true
+	}
+	if !q.Start.IsZero() && cfg.Last.Before(q.Start) {
+		return false
+	}
+	return q.End.IsZero() || !cfg.First.After(q.End)
+}
+
+func (q SearchQuery) matches(m TracerMessage, text string) bool {
+	if !q.Start.IsZero() && m.Timestamp.Before(q.Start) {
+		return false
+	}
+	if !q.End.IsZero() && m.Timestamp.After(q.End) {
+		return false
+	}
+	if len(q.Topics) > 0 && !slices.ContainsFunc(q.Topics, func(f string) bool { return tracerMatch(f, m.Topic) }) {
+		return false
+	}
+	return text == "" || strings.Contains(strings.ToLower(m.Payload), text)
+}
+
+// WriteResults prints search results grouped by trace.
+func WriteResults(w io.Writer, results []SearchResult) {
+	for i, r := range results {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, resultTitle(r))
+		for _, m := range r.Messages {
+			fmt.Fprintf(w, "  %s  %s  %s\n", m.Timestamp.Format(time.RFC3339Nano), m.Topic, m.Payload)
+		}
+	}
+}
+
+// resultTitle describes the trace of a result group.
+func resultTitle(r SearchResult) string {
+	title := fmt.Sprintf("%s (%s): %d match(es)", r.Trace.Key, r.Trace.Profile, len(r.Messages))
+	if len(r.Trace.Tags) > 0 {
+		title += " #" + strings.Join(r.Trace.Tags, " #")
+	}
+	if r.Trace.Description != "" {
+		title += " - " + r.Trace.Description
+	}
+	return title
+}
@@ -0,0 +1,69 @@
+package traces
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSearch(t *testing.T) {
+	q := ParseSearch("tag=plant,Line2 topic=sensors/# start=2026-10-19T08:00:00Z valve open")
+	if len(q.Tags) != 2 || q.Tags[1] != "Line2" {
+		t.Fatalf("unexpected tags %v", q.Tags)
+	}
+	if len(q.Topics) != 1 || q.Topics[0] != "sensors/#" {
+		t.Fatalf("unexpected topics %v", q.Topics)
+	}
+	if !q.Start.Equal(time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)) || !q.End.IsZero() {
+		t.Fatalf("unexpected range %v %v", q.Start, q.End)
+	}
+	if q.Text != "valve open" {
+		t.Fatalf("unexpected text %q", q.Text)
+	}
+}
+
+func TestSearchTraces(t *testing.T) {
+	base := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	cfgs := map[string]TracerConfig{
+		"a": {Key: "a", Profile: "p1", Tags: []string{"plant", "line2"}},
+		"b": {Key: "b", Profile: "p2", Tags: []string{"Plant"}},
+		"c": {Key: "c", Profile: "p1", Tags: []string{"lab"}},
+		"d": {Key: "d", Profile: "p2", Tags: []string{"plant"}, First: base.Add(-2 * time.Hour), Last: base.Add(-time.Hour)},
+	}
+	msgs := map[string][]TracerMessage{
+		"a": {
+			{Timestamp: base, Topic: "sensors/valve", Payload: `{"state":"OPEN"}`},
+			{Timestamp: base.Add(time.Minute), Topic: "sensors/valve", Payload: `{"state":"closed"}`},
+			{Timestamp: base.Add(-time.Minute), Topic: "sensors/valve", Payload: `{"state":"open"}`},
+		},
+		"b": {{Timestamp: base.Add(time.Second), Topic: "sensors/pump", Payload: "open"}},
+		"c": {{Timestamp: base, Topic: "sensors/valve", Payload: "open"}},
+	}
+	var read []string
+	messages := func(profile, key string) ([]TracerMessage, error) {
+		read = append(read, profile+"/"+key)
+		return msgs[key], nil
+	}
+	q := ParseSearch("tag=plant topic=sensors/# start=2026-10-19T08:00:00Z open")
+	res, err := searchTraces(cfgs, q, messages)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(res) != 2 || res[0].Trace.Key != "a" || res[1].Trace.Key != "b" {
+		t.Fatalf("unexpected results %+v", res)
+	}
+	if len(res[0].Messages) != 1 || !res[0].Messages[0].Timestamp.Equal(base) {
+		t.Fatalf("unexpected messages %+v", res[0].Messages)
+	}
+	if strings.Join(read, ",") != "p1/a,p2/b" {
+		t.Fatalf("unexpected traces read: %v", read)
+	}
+
+	var buf bytes.Buffer
+	WriteResults(&buf, res)
+	out := buf.String()
+	if !strings.Contains(out, "a (p1): 1 match(es) #plant #line2") || !strings.Contains(out, "sensors/pump  open") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,237 @@
+package traces
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Series runs a recurring trace, starting one tracer per occurrence. Each
+// occurrence stores its messages under a key derived from the trace key and
+// the scheduled start, see OccurrenceKey.
+type Series struct {
+	cfg       TracerConfig
+	sched     Schedule
+	newClient func() (Client, error)
+
+	mu      sync.Mutex
+	running bool
+	cur     *Tracer
+	next    time.Time
+	cancel  context.CancelFunc
+	done    chan struct{}
+	report  chan error
+	save    func(TracerConfig) error
+	now     func() time.Time
+	started chan string // receives occurrence keys, used by tests
+}
+
+// newSeries prepares a recurring trace; newClient connects for each occurrence.
+func newSeries(cfg TracerConfig, newClient func() (Client, error)) (*Series, error) {
+	sched, err := ParseSchedule(cfg.Repeat)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule: %w", err)
+	}
+	return &Series{
+		cfg:       cfg,
+		sched:     sched,
+		newClient: newClient,
+		report:    make(chan error, 1),
+		save:      addTrace,
+		now:       time.Now,
+	}, nil
+}
+
+func (s *Series) reportErr(err error) {
+	select {
+	case s.report <- err:
+	default:
+	}
+}
+
+// Start schedules occurrences until Stop is called.
+func (s *Series) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return fmt.Errorf("trace already running")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.running = true
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	go s.loop(ctx)
+	return nil
+}
+
+func (s *Series) loop(ctx context.Context) {
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.cur = nil
+		s.next = time.Time{}
+		s.mu.Unlock()
+		close(s.done)
+	}()
+	// an occurrence that already began is still recorded for its remainder
+	after := s.now().Add(-s.sched.Duration + time.Minute)
+	for {
+		start := s.sched.Next(after)
+		if start.IsZero() {
+			return
+		}
+		after = start.Add(time.Minute)
+		key := OccurrenceKey(s.cfg.Key, start)
+		s.mu.Lock()
+		done := slices.Contains(s.cfg.Runs, key)
+		if !done {
+			s.next = start
+		}
+		s.mu.Unlock()
+		if done {
+			continue
+		}
+		if d := start.Sub(s.now()); d > 0 {
+			select {
+			case <-time.After(d):
+			case <-ctx.Done():
+				return
+			}
+		}
+		if !s.runOccurrence(ctx, key, start) {
+			return
+		}
+	}
+}
+
+// runOccurrence records a single occurrence and reports whether the series
+// should continue.
+func (s *Series) runOccurrence(ctx context.Context, key string, start time.Time) bool {
+	occ := s.cfg
+	occ.Key = key
+	occ.Start = start
+	occ.End = start.Add(s.sched.Duration)
+	occ.Repeat = ""
+	occ.Runs = nil
+	occ.resetStats()
+	client, err := s.newClient()
+	if err != nil {
+		s.reportErr(fmt.Errorf("trace %s: %w", key, err))
+		return true
+	}
+	tr := newTracer(occ, client)
+	if err := tr.Start(); err != nil {
+		client.Disconnect()
+		s.reportErr(fmt.Errorf("trace %s: %w", key, err))
+		return true
+	}
+	s.mu.Lock()
+	s.cur = tr
+	s.next = time.Time{}
+	s.cfg.Runs = append(s.cfg.Runs, key)
+	cfg := s.cfg
+	s.mu.Unlock()
+	if err := s.save(cfg); err != nil {
+		s.reportErr(err)
+	}
+	if s.started != nil {
+		s.started <- key
+	}
+	cont := true
+	select {
+	case <-tr.done:
+	case <-ctx.Done():
+		tr.Stop()
+		cont = false
+	}
+	s.mu.Lock()
+	s.cur = nil
+	s.cfg.addStats(tr.Config())
+	cfg = s.cfg
+	s.mu.Unlock()
+	if err := s.save(cfg); err != nil {
+		s.reportErr(err)
+	}
+	return cont
+}
+
+// Stop cancels the schedule and any running occurrence.
+func (s *Series) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.cancel()
+	done := s.done
+	s.mu.Unlock()
+	<-done
+}
+
+// Scheduled reports whether the series waits for or records occurrences.
+func (s *Series) Scheduled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
+
+// Running reports whether an occurrence is currently recording.
+func (s *Series) Running() bool {
+	s.mu.Lock()
+	cur := s.cur
+	s.mu.Unlock()
+	return cur != nil && cur.Running()
+}
+
+// Armed reports whether the current occurrence waits for its start trigger.
+func (s *Series) Armed() bool {
+	s.mu.Lock()
+	cur := s.cur
+	s.mu.Unlock()
+	return cur != nil && cur.Armed()
+}
+
+// Next returns the start of the next planned occurrence, if any.
+func (s *Series) Next() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next
+}
+
+// Counts returns the per-topic counts of the current occurrence.
+func (s *Series) Counts() map[string]int {
+	s.mu.Lock()
+	cur := s.cur
+	s.mu.Unlock()
+	if cur == nil {
+		return map[string]int{}
+	}
+	return cur.Counts()
+}
+
+// Config returns the trace configuration including recorded occurrences.
+func (s *Series) Config() TracerConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cfg := s.cfg
+	cfg.Runs = slices.Clone(s.cfg.Runs)
+	return cfg
+}
+
+// forget drops a recorded occurrence from the configuration.
+func (s *Series) forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg.Runs = slices.DeleteFunc(slices.Clone(s.cfg.Runs), func(r string) bool { return r == key })
+}
+
+// NextOccurrence returns the next start of cfg's schedule after now.
+func NextOccurrence(cfg TracerConfig, now time.Time) time.Time {
+	sched, err := ParseSchedule(cfg.Repeat)
+	if err != nil {
+		return time.Time{}
+	}
+	return sched.Next(now)
+}
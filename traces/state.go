@@ -16,6 +16,71 @@ type persistedTrace struct {
 	Topics  []string `toml:"topics"`
 	Start   string   `toml:"start"`
 	End     string   `toml:"end"`
+
+	StartTopic  string `toml:"start_topic"`
+	StartWhen   string `toml:"start_when"`
+	StopTopic   string `toml:"stop_topic"`
+	StopWhen    string `toml:"stop_when"`
+	StopAfter   int    `toml:"stop_after"`
+	MaxDuration string `toml:"max_duration"`
+	PreTrigger  string `toml:"pre_trigger"`
+
+	Repeat string   `toml:"repeat"`
+	Runs   []string `toml:"runs"`
+
+	Description string   `toml:"description"`
+	Tags        []string `toml:"tags"`
+	Broker      string   `toml:"broker"`
+	Messages    int      `toml:"messages"`
+	Bytes       int64    `toml:"bytes"`
+	First       string   `toml:"first"`
+	Last        string   `toml:"last"`
+}
+
+// stats converts the persisted metadata of the recorded data.
+func (v persistedTrace) stats(key string, cfg *TracerConfig) {
+	cfg.Description, cfg.Tags, cfg.Broker = v.Description, v.Tags, v.Broker
+	cfg.Messages, cfg.Bytes = v.Messages, v.Bytes
+	for _, f := range []struct {
+		val string
+		dst *time.Time
+	}{{v.First, &cfg.First}, {v.Last, &cfg.Last}} {
+		if f.val == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, f.val)
+		if err != nil {
+			log.Printf("invalid message time for trace %q: %v", key, err)
+			continue
+		}
+		*f.dst = t
+	}
+}
+
+// trigger converts the persisted trigger options, logging invalid durations.
+func (v persistedTrace) trigger(key string) Trigger {
+	tr := Trigger{
+		StartTopic: v.StartTopic,
+		StartWhen:  v.StartWhen,
+		StopTopic:  v.StopTopic,
+		StopWhen:   v.StopWhen,
+		StopAfter:  v.StopAfter,
+	}
+	if v.MaxDuration != "" {
+		d, err := time.ParseDuration(v.MaxDuration)
+		if err != nil {
+			log.Printf("invalid max duration for trace %q: %v", key, err)
+		}
+		tr.MaxDuration = d
+	}
+	if v.PreTrigger != "" {
+		d, err := time.ParseDuration(v.PreTrigger)
+		if err != nil {
+			log.Printf("invalid pre-trigger for trace %q: %v", key, err)
+		}
+		tr.PreTrigger = d
+	}
+	return tr
 }
 
 // loadTraces retrieves planned traces from config.toml.
@@ -49,7 +114,9 @@ func loadTraces() (out map[string]TracerConfig) {
 				end = t
 			}
 		}
-		out[k] = TracerConfig{Profile: v.Profile, Topics: v.Topics, Start: start, End: end, Key: k}
+		cfg := TracerConfig{Profile: v.Profile, Topics: v.Topics, Start: start, End: end, Key: k, Trigger: v.trigger(k), Repeat: v.Repeat, Runs: v.Runs}
+		v.stats(k, &cfg)
+		out[k] = cfg
 	}
 	return
 }
@@ -71,6 +138,14 @@ func saveTraces(data map[string]TracerConfig) error {
 		if !v.End.IsZero() {
 			sub["end"] = v.End.Format(time.RFC3339)
 		}
+		addTrigger(sub, v.Trigger)
+		if v.Repeat != "" {
+			sub["repeat"] = v.Repeat
+		}
+		if len(v.Runs) > 0 {
+			sub["runs"] = v.Runs
+		}
+		addMetadata(sub, v)
 		traces[k] = sub
 	}
 	cfg["traces"] = traces
@@ -87,6 +162,52 @@ func saveTraces(data map[string]TracerConfig) error {
 	return nil
 }
 
+// addTrigger stores the set trigger options in sub.
+func addTrigger(sub map[string]interface{}, tr Trigger) {
+	for k, v := range map[string]string{
+		"start_topic": tr.StartTopic,
+		"start_when":  tr.StartWhen,
+		"stop_topic":  tr.StopTopic,
+		"stop_when":   tr.StopWhen,
+	} {
+		if v != "" {
+			sub[k] = v
+		}
+	}
+	if tr.StopAfter > 0 {
+		sub["stop_after"] = tr.StopAfter
+	}
+	if tr.MaxDuration > 0 {
+		sub["max_duration"] = tr.MaxDuration.String()
+	}
+	if tr.PreTrigger > 0 {
+		sub["pre_trigger"] = tr.PreTrigger.String()
+	}
+}
+
+// addMetadata stores the set description, tags and data statistics in sub.
+func addMetadata(sub map[string]interface{}, cfg TracerConfig) {
+	if cfg.Description != "" {
+		sub["description"] = cfg.Description
+	}
+	if len(cfg.Tags) > 0 {
+		sub["tags"] = cfg.Tags
+	}
+	if cfg.Broker != "" {
+		sub["broker"] = cfg.Broker
+	}
+	if cfg.Messages > 0 {
+		sub["messages"] = cfg.Messages
+		sub["bytes"] = cfg.Bytes
+	}
+	if !cfg.First.IsZero() {
+		sub["first"] = cfg.First.Format(time.RFC3339Nano)
+	}
+	if !cfg.Last.IsZero() {
+		sub["last"] = cfg.Last.Format(time.RFC3339Nano)
+	}
+}
+
 // addTrace merges a single trace configuration into the existing file.
 func addTrace(cfg TracerConfig) error {
 	traces := loadTraces()
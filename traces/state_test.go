@@ -112,3 +112,56 @@ func TestAddTraceWriteError(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+func TestTraceTriggerRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	trig := Trigger{
+		StartTopic:  "ctl/start",
+		StartWhen:   "$.temp > 30",
+		StopWhen:    "/done/",
+		StopAfter:   100,
+		MaxDuration: 5 * time.Minute,
+		PreTrigger:  10 * time.Second,
+	}
+	if err := addTrace(TracerConfig{Key: "t1", Profile: "p", Topics: []string{"a"}, Trigger: trig}); err != nil {
+		t.Fatalf("addTrace: %v", err)
+	}
+	got := loadTraces()["t1"].Trigger
+	if got != trig {
+		t.Fatalf("trigger mismatch: %+v != %+v", got, trig)
+	}
+}
+
+func TestTraceRepeatRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	runs := []string{"run1@2026-10-17T02:00", "run1@2026-10-18T02:00"}
+	if err := addTrace(TracerConfig{Key: "run1", Profile: "p", Topics: []string{"a"}, Repeat: "daily 02:00-03:00", Runs: runs}); err != nil {
+		t.Fatalf("addTrace: %v", err)
+	}
+	got := loadTraces()["run1"]
+	if got.Repeat != "daily 02:00-03:00" || len(got.Runs) != 2 || got.Runs[1] != runs[1] {
+		t.Fatalf("unexpected config %+v", got)
+	}
+}
+
+func TestTraceMetadataRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	first := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	cfg := TracerConfig{Key: "run1", Profile: "p", Topics: []string{"a"}, Description: "valve test", Tags: []string{"plant", "line2"}}
+	if err := addTrace(cfg); err != nil {
+		t.Fatalf("addTrace: %v", err)
+	}
+	cfg.Broker = "tcp://broker:1883"
+	cfg.record(TracerMessage{Timestamp: first, Payload: "abc"})
+	cfg.record(TracerMessage{Timestamp: first.Add(time.Minute), Payload: "de"})
+	if err := saveStats(cfg); err != nil {
+		t.Fatalf("saveStats: %v", err)
+	}
+	got := loadTraces()["run1"]
+	if got.Description != "valve test" || len(got.Tags) != 2 || got.Tags[1] != "line2" || got.Broker != cfg.Broker {
+		t.Fatalf("unexpected metadata %+v", got)
+	}
+	if got.Messages != 2 || got.Bytes != 5 || !got.First.Equal(first) || !got.Last.Equal(first.Add(time.Minute)) {
+		t.Fatalf("unexpected stats %+v", got)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"time"
 
 	connections "github.com/marang/emqutiti/connections"
 	"github.com/marang/emqutiti/proxy"
@@ -78,6 +80,24 @@ func tracerMessages(profile, key string) ([]TracerMessage, error) {
 	return tracerMessagesClient(cl, profile, key)
 }
 
+// seriesMessages returns the messages of the trace key together with those of
+// its recorded runs, ordered by time.
+func seriesMessages(profile, key string, runs []string, messages func(profile, key string) ([]TracerMessage, error)) ([]TracerMessage, error) {
+	msgs, err := messages(profile, key)
+	if err != nil || len(runs) == 0 {
+		return msgs, err
+	}
+	for _, run := range runs {
+		more, err := messages(profile, run)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, more...)
+	}
+	sort.SliceStable(msgs, func(i, j int) bool { return msgs[i].Timestamp.Before(msgs[j].Timestamp) })
+	return msgs, nil
+}
+
 func tracerHasData(profile, key string) (bool, error) {
 	cl, conn, err := proxy.NewClient(addr())
 	if err != nil {
@@ -102,11 +122,35 @@ func tracerClearData(profile, key string) error {
 		return err
 	}
 	defer conn.Close()
-	prefix := fmt.Sprintf("trace/%s/", key)
-	_, err = cl.Delete(context.Background(), &proxy.DeleteRequest{
-		Profile: profile,
-		Bucket:  "traces",
-		Key:     prefix,
-	})
-	return err
+	for _, prefix := range []string{fmt.Sprintf("trace/%s/", key), annotationPrefix(key)} {
+		if _, err := cl.Delete(context.Background(), &proxy.DeleteRequest{
+			Profile: profile,
+			Bucket:  "traces",
+			Key:     prefix,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// daemonTraces returns the traces run by the process serving the proxy,
+// keyed by trace key.
+func daemonTraces() (map[string]*proxy.TraceStatus, error) {
+	cl, conn, err := proxy.NewClient(addr())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	st, err := cl.Status(ctx, &proxy.StatusRequest{})
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]*proxy.TraceStatus, len(st.GetTraces()))
+	for _, tr := range st.GetTraces() {
+		out[tr.GetKey()] = tr
+	}
+	return out, nil
 }
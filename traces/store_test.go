@@ -46,6 +46,42 @@ func TestHasDataAndClear(t *testing.T) {
 	}
 }
 
+func TestOccurrenceDataIsSeparate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	p, err := proxy.StartProxy("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("start proxy: %v", err)
+	}
+	SetProxyAddr(p.Addr())
+	t.Cleanup(p.Stop)
+
+	start := time.Date(2026, 10, 17, 2, 0, 0, 0, time.Local)
+	run := OccurrenceKey("run1", start)
+	if err := tracerAdd("test", "run1", TracerMessage{Timestamp: start.Add(time.Hour), Topic: "a", Payload: "parent"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := tracerAdd("test", run, TracerMessage{Timestamp: start, Topic: "a", Payload: "run"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	msgs, err := tracerMessages("test", "run1")
+	if err != nil || len(msgs) != 1 || msgs[0].Payload != "parent" {
+		t.Fatalf("parent messages include the run: %+v %v", msgs, err)
+	}
+	msgs, err = seriesMessages("test", "run1", []string{run}, tracerMessages)
+	if err != nil || len(msgs) != 2 || msgs[0].Payload != "run" {
+		t.Fatalf("expected run then parent message, got %+v %v", msgs, err)
+	}
+	if err := tracerClearData("test", "run1"); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	if has, err := tracerHasData("test", run); err != nil || !has {
+		t.Fatalf("clearing the parent removed the run data, err=%v", err)
+	}
+	if err := ValidateKey(run); err == nil {
+		t.Fatalf("expected %q to be rejected as trace key", run)
+	}
+}
+
 func TestHasDataEmptyDir(t *testing.T) {
 	dir := t.TempDir()
 	t.Setenv("HOME", dir)
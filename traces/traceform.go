@@ -1,6 +1,8 @@
 package traces
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,10 +21,26 @@ const (
 	idxTraceKey = iota
 	idxTraceProfile
 	idxTraceTopics
+	idxTraceDescription
+	idxTraceTags
 	idxTraceStart
 	idxTraceEnd
+	idxTraceRepeat
+	idxTraceStartOn
+	idxTraceStartWhen
+	idxTraceStopOn
+	idxTraceStopWhen
+	idxTraceStopAfter
+	idxTraceMaxDuration
+	idxTracePreTrigger
 )
 
+var traceLabels = []string{
+	"Key", "Profile", "Topics", "Description", "Tags", "Start", "End", "Repeat",
+	"Start on", "Start when", "Stop on", "Stop when",
+	"Stop after", "Max duration", "Pre-trigger",
+}
+
 // newTraceForm builds a form for creating or editing a trace.
 func newTraceForm(profiles []string, current string, topics []string) traceForm {
 	keyField := ui.NewTextField("", "Key")
@@ -33,7 +51,20 @@ func newTraceForm(profiles []string, current string, topics []string) traceForm
 	topicsField := ui.NewTextField(strings.Join(topics, ","), "Topics")
 	startField := ui.NewTextField("", "2006-01-02T15:04:05Z")
 	endField := ui.NewTextField("", "2006-01-02T15:04:05Z")
-	fields := []ui.Field{keyField, profileField, topicsField, startField, endField}
+	fields := []ui.Field{
+		keyField, profileField, topicsField,
+		ui.NewTextField("", "what this trace records (optional)"),
+		ui.NewTextField("", "comma-separated, e.g. firmware,line2"),
+		startField, endField,
+		ui.NewTextField("", "daily 02:00-03:00, weekly mon,thu 02:00 or cron"),
+		ui.NewTextField("", "topic filter (optional)"),
+		ui.NewTextField("", `text, /regex/ or $.temp > 30`),
+		ui.NewTextField("", "topic filter (optional)"),
+		ui.NewTextField("", `text, /regex/ or $.state == "idle"`),
+		ui.NewTextField("", "messages"),
+		ui.NewTextField("", "e.g. 5m"),
+		ui.NewTextField("", "e.g. 10s"),
+	}
 	tf := traceForm{Form: ui.Form{Fields: fields, Focus: 0}}
 	if err != nil {
 		tf.errMsg = err.Error()
@@ -67,10 +98,9 @@ func (f traceForm) Update(msg tea.Msg) (traceForm, tea.Cmd) {
 
 // View renders the form interface.
 func (f traceForm) View() string {
-	labels := []string{"Key", "Profile", "Topics", "Start", "End"}
 	var b strings.Builder
 	for i, fld := range f.Fields {
-		label := labels[i]
+		label := traceLabels[i]
 		if i == f.Focus {
 			label = ui.FocusedStyle.Render(label)
 		}
@@ -114,5 +144,70 @@ func (f traceForm) Config() TracerConfig {
 			cfg.End = tm
 		}
 	}
+	cfg.Description = strings.TrimSpace(vals[idxTraceDescription])
+	cfg.Tags = splitTags(vals[idxTraceTags])
+	cfg.Repeat = strings.TrimSpace(vals[idxTraceRepeat])
+	cfg.Trigger, _ = f.trigger(vals)
 	return cfg
 }
+
+// splitTags parses a comma-separated tag list, dropping empty entries.
+func splitTags(s string) []string {
+	var tags []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// trigger parses the trigger fields.
+func (f traceForm) trigger(vals []string) (Trigger, error) {
+	tr := Trigger{
+		StartTopic: strings.TrimSpace(vals[idxTraceStartOn]),
+		StartWhen:  strings.TrimSpace(vals[idxTraceStartWhen]),
+		StopTopic:  strings.TrimSpace(vals[idxTraceStopOn]),
+		StopWhen:   strings.TrimSpace(vals[idxTraceStopWhen]),
+	}
+	if v := strings.TrimSpace(vals[idxTraceStopAfter]); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return tr, fmt.Errorf("invalid stop after: %w", err)
+		}
+		tr.StopAfter = n
+	}
+	if v := strings.TrimSpace(vals[idxTraceMaxDuration]); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return tr, fmt.Errorf("invalid max duration: %w", err)
+		}
+		tr.MaxDuration = d
+	}
+	if v := strings.TrimSpace(vals[idxTracePreTrigger]); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return tr, fmt.Errorf("invalid pre-trigger: %w", err)
+		}
+		tr.PreTrigger = d
+	}
+	return tr, tr.Validate()
+}
+
+// Validate reports an invalid key, schedule or trigger settings.
+func (f traceForm) Validate() error {
+	vals := make([]string, len(f.Fields))
+	for i, fld := range f.Fields {
+		vals[i] = fld.Value()
+	}
+	if err := ValidateKey(strings.TrimSpace(vals[idxTraceKey])); err != nil {
+		return err
+	}
+	if r := strings.TrimSpace(vals[idxTraceRepeat]); r != "" {
+		if _, err := ParseSchedule(r); err != nil {
+			return fmt.Errorf("invalid repeat: %w", err)
+		}
+	}
+	_, err := f.trigger(vals)
+	return err
+}
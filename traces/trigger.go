@@ -0,0 +1,256 @@
+package traces
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marang/emqutiti/internal/jsonpath"
+)
+
+// Trigger starts and stops recording based on received messages.
+//
+// A start or stop condition matches messages on its topic filter, or on any
+// traced topic when the filter is empty, whose payload satisfies the
+// predicate. Predicates are a substring, a /regular expression/ or a JSON
+// comparison such as `$.temp > 30`; an empty predicate matches any payload.
+type Trigger struct {
+	StartTopic  string
+	StartWhen   string
+	StopTopic   string
+	StopWhen    string
+	StopAfter   int           // stop after this many recorded messages
+	MaxDuration time.Duration // stop this long after recording began
+	PreTrigger  time.Duration // keep messages received this long before the start
+}
+
+// Armed reports whether recording waits for a start condition.
+func (t Trigger) Armed() bool { return t.StartTopic != "" || t.StartWhen != "" }
+
+// stopsOnMessage reports whether a stop condition is configured.
+func (t Trigger) stopsOnMessage() bool { return t.StopTopic != "" || t.StopWhen != "" }
+
+// IsZero reports whether no trigger option is set.
+func (t Trigger) IsZero() bool { return t == Trigger{} }
+
+// Validate checks that the predicates compile.
+func (t Trigger) Validate() error {
+	if _, err := compilePredicate(t.StartWhen); err != nil {
+		return fmt.Errorf("start condition: %w", err)
+	}
+	if _, err := compilePredicate(t.StopWhen); err != nil {
+		return fmt.Errorf("stop condition: %w", err)
+	}
+	if t.StopAfter < 0 || t.MaxDuration < 0 || t.PreTrigger < 0 {
+		return fmt.Errorf("trigger limits must not be negative")
+	}
+	return nil
+}
+
+// predicate tests a message payload.
+type predicate func(payload string) bool
+
+var comparison = regexp.MustCompile(`^(\$[^<>=!]*?)\s*(==|!=|>=|<=|>|<)\s*(.*)$`)
+
+// compilePredicate parses a payload condition.
+func compilePredicate(expr string) (predicate, error) {
+	expr = strings.TrimSpace(expr)
+	switch {
+	case expr == "":
+		return func(string) bool { return true }, nil
+	case len(expr) > 1 && strings.HasPrefix(expr, "/") && strings.HasSuffix(expr, "/"):
+		re, err := regexp.Compile(expr[1 : len(expr)-1])
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	case strings.HasPrefix(expr, "$"):
+		pathExpr, op, operand := expr, "", ""
+		if m := comparison.FindStringSubmatch(expr); m != nil {
+			pathExpr, op, operand = strings.TrimSpace(m[1]), m[2], strings.TrimSpace(m[3])
+		}
+		p, err := jsonpath.Compile(pathExpr)
+		if err != nil {
+			return nil, err
+		}
+		operand = strings.Trim(operand, `"'`)
+		return func(payload string) bool {
+			var doc interface{}
+			if json.Unmarshal([]byte(payload), &doc) != nil {
+				return false
+			}
+			for _, v := range p.Eval(doc) {
+				if op == "" {
+					if v != nil && v != false {
+						return true
+					}
+					continue
+				}
+				if compare(v, op, operand) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	default:
+		return func(payload string) bool { return strings.Contains(payload, expr) }, nil
+	}
+}
+
+// compare applies op numerically when both sides are numbers, otherwise to
+// the string forms.
+func compare(v interface{}, op, operand string) bool {
+	var s string
+	switch t := v.(type) {
+	case string:
+		s = t
+	case float64:
+		s = strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		b, _ := json.Marshal(t)
+		s = string(b)
+	}
+	a, errA := strconv.ParseFloat(s, 64)
+	b, errB := strconv.ParseFloat(operand, 64)
+	if errA == nil && errB == nil {
+		switch op {
+		case "==":
+			return a == b
+		case "!=":
+			return a != b
+		case ">":
+			return a > b
+		case ">=":
+			return a >= b
+		case "<":
+			return a < b
+		case "<=":
+			return a <= b
+		}
+	}
+	switch op {
+	case "==":
+		return s == operand
+	case "!=":
+		return s != operand
+	case ">":
+		return s > operand
+	case ">=":
+		return s >= operand
+	case "<":
+		return s < operand
+	case "<=":
+		return s <= operand
+	}
+	return false
+}
+
+// recorder decides which messages are stored for a triggered trace.
+type recorder struct {
+	trig      Trigger
+	topics    []string
+	start     predicate
+	stop      predicate
+	triggered bool
+	since     time.Time
+	recorded  int
+	done      bool
+	buffer    []TracerMessage
+}
+
+func newRecorder(trig Trigger, topics []string) (*recorder, error) {
+	start, err := compilePredicate(trig.StartWhen)
+	if err != nil {
+		return nil, fmt.Errorf("start condition: %w", err)
+	}
+	stop, err := compilePredicate(trig.StopWhen)
+	if err != nil {
+		return nil, fmt.Errorf("stop condition: %w", err)
+	}
+	return &recorder{trig: trig, topics: topics, start: start, stop: stop}, nil
+}
+
+// begin starts recording immediately unless a start condition is configured.
+func (r *recorder) begin(now time.Time) {
+	if !r.trig.Armed() {
+		r.triggered = true
+		r.since = now
+	}
+}
+
+func (r *recorder) traced(topic string) bool {
+	for _, f := range r.topics {
+		if tracerMatch(f, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *recorder) matches(filter string, cond predicate, msg TracerMessage) bool {
+	if filter != "" {
+		if !tracerMatch(filter, msg.Topic) {
+			return false
+		}
+	} else if !r.traced(msg.Topic) {
+		return false
+	}
+	return cond(msg.Payload)
+}
+
+// handle processes a received message and returns the messages to store.
+// done reports that recording has finished.
+func (r *recorder) handle(msg TracerMessage) (store []TracerMessage, done bool) {
+	if r.done {
+		return nil, true
+	}
+	traced := r.traced(msg.Topic)
+	if !r.triggered {
+		if traced && r.trig.PreTrigger > 0 {
+			r.buffer = append(r.buffer, msg)
+		}
+		r.dropBuffered(msg.Timestamp.Add(-r.trig.PreTrigger))
+		if !r.matches(r.trig.StartTopic, r.start, msg) {
+			return nil, false
+		}
+		r.triggered = true
+		r.since = msg.Timestamp
+		store = r.buffer
+		if traced && len(store) > 0 {
+			// the buffer already ends with msg
+			store = store[:len(store)-1]
+		}
+		r.buffer = nil
+	} else if r.expired(msg.Timestamp) {
+		r.done = true
+		return nil, true
+	}
+	if traced {
+		store = append(store, msg)
+		r.recorded++
+	}
+	if r.trig.StopAfter > 0 && r.recorded >= r.trig.StopAfter {
+		r.done = true
+	}
+	if r.trig.stopsOnMessage() && r.matches(r.trig.StopTopic, r.stop, msg) {
+		r.done = true
+	}
+	return store, r.done
+}
+
+// dropBuffered removes pre-trigger messages received before cutoff.
+func (r *recorder) dropBuffered(cutoff time.Time) {
+	i := 0
+	for i < len(r.buffer) && r.buffer[i].Timestamp.Before(cutoff) {
+		i++
+	}
+	r.buffer = r.buffer[i:]
+}
+
+// expired reports whether the maximum duration has passed at now.
+func (r *recorder) expired(now time.Time) bool {
+	return r.triggered && r.trig.MaxDuration > 0 && now.Sub(r.since) >= r.trig.MaxDuration
+}
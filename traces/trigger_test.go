@@ -0,0 +1,163 @@
+package traces
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marang/emqutiti/proxy"
+)
+
+func TestCompilePredicate(t *testing.T) {
+	cases := []struct {
+		expr, payload string
+		want          bool
+	}{
+		{"", "anything", true},
+		{"alarm", "fire alarm", true},
+		{"alarm", "quiet", false},
+		{"/^err(or)?$/", "error", true},
+		{"$.temp > 30", `{"temp":31.5}`, true},
+		{"$.temp > 30", `{"temp":"29"}`, false},
+		{`$.state == "idle"`, `{"state":"idle"}`, true},
+		{"$.state != idle", `{"state":"busy"}`, true},
+		{"$.flag", `{"flag":true}`, true},
+		{"$.flag", `{"flag":false}`, false},
+		{"$.temp > 30", "not json", false},
+	}
+	for _, c := range cases {
+		p, err := compilePredicate(c.expr)
+		if err != nil {
+			t.Fatalf("compile %q: %v", c.expr, err)
+		}
+		if got := p(c.payload); got != c.want {
+			t.Fatalf("%q on %q = %v, want %v", c.expr, c.payload, got, c.want)
+		}
+	}
+	if _, err := compilePredicate("/(/"); err == nil {
+		t.Fatalf("expected regex error")
+	}
+}
+
+func TestRecorderPreTriggerAndStopAfter(t *testing.T) {
+	r, err := newRecorder(Trigger{
+		StartTopic: "dev/alarm",
+		StartWhen:  "$.on",
+		StopAfter:  3,
+		PreTrigger: 2 * time.Second,
+	}, []string{"dev/data"})
+	if err != nil {
+		t.Fatalf("newRecorder: %v", err)
+	}
+	r.begin(time.Now())
+	base := time.Now()
+	msg := func(sec int, topic, payload string) TracerMessage {
+		return TracerMessage{Timestamp: base.Add(time.Duration(sec) * time.Second), Topic: topic, Payload: payload}
+	}
+	for i := 0; i < 5; i++ {
+		if store, _ := r.handle(msg(i, "dev/data", "x")); store != nil {
+			t.Fatalf("stored before trigger")
+		}
+	}
+	if store, _ := r.handle(msg(5, "dev/alarm", `{"on":false}`)); store != nil {
+		t.Fatalf("start condition should not match")
+	}
+	store, done := r.handle(msg(5, "dev/alarm", `{"on":true}`))
+	// messages at 3s and 4s lie within the 2s pre-trigger window
+	if len(store) != 2 || done {
+		t.Fatalf("expected 2 buffered messages, got %d (done %v)", len(store), done)
+	}
+	for i := 6; i < 8; i++ {
+		if store, done := r.handle(msg(i, "dev/data", "y")); len(store) != 1 || done {
+			t.Fatalf("expected message %d to be stored", i)
+		}
+	}
+	if store, done := r.handle(msg(8, "dev/data", "y")); len(store) != 1 || !done {
+		t.Fatalf("expected stop after third message")
+	}
+	if store, done := r.handle(msg(9, "dev/data", "y")); store != nil || !done {
+		t.Fatalf("expected no messages after stop")
+	}
+}
+
+func TestRecorderStopConditionAndDuration(t *testing.T) {
+	r, _ := newRecorder(Trigger{StopWhen: "$.state == done", MaxDuration: time.Minute}, []string{"job/#"})
+	base := time.Now()
+	r.begin(base)
+	if store, done := r.handle(TracerMessage{Timestamp: base, Topic: "job/1", Payload: `{"state":"run"}`}); len(store) != 1 || done {
+		t.Fatalf("expected recording without start condition")
+	}
+	if store, done := r.handle(TracerMessage{Timestamp: base, Topic: "job/1", Payload: `{"state":"done"}`}); len(store) != 1 || !done {
+		t.Fatalf("expected stop message to be stored and finish the trace")
+	}
+
+	r, _ = newRecorder(Trigger{MaxDuration: time.Minute}, []string{"job/#"})
+	r.begin(base)
+	if r.expired(base.Add(30 * time.Second)) {
+		t.Fatalf("expired too early")
+	}
+	if _, done := r.handle(TracerMessage{Timestamp: base.Add(2 * time.Minute), Topic: "job/1"}); !done {
+		t.Fatalf("expected max duration to end recording")
+	}
+}
+
+func TestTriggerTopics(t *testing.T) {
+	cfg := TracerConfig{Topics: []string{"a/#"}, Trigger: Trigger{StartTopic: "a/b", StopTopic: "ctl/stop"}}
+	got := triggerTopics(cfg)
+	if len(got) != 1 || got[0] != "ctl/stop" {
+		t.Fatalf("unexpected trigger subscriptions %v", got)
+	}
+	cfg = TracerConfig{Topics: []string{"a/+"}, Trigger: Trigger{StartTopic: "a/#"}}
+	if got := triggerTopics(cfg); len(got) != 1 || got[0] != "a/#" {
+		t.Fatalf("a/+ does not cover a/#, got %v", got)
+	}
+}
+
+func TestTracerStartTrigger(t *testing.T) {
+	dir := t.TempDir()
+	os.Setenv("HOME", dir)
+	p, err := proxy.StartProxy("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("start proxy: %v", err)
+	}
+	SetProxyAddr(p.Addr())
+	t.Cleanup(p.Stop)
+
+	cfg := TracerConfig{
+		Profile: "test",
+		Topics:  []string{"data"},
+		Start:   time.Now().Add(-time.Millisecond),
+		Key:     "trig",
+		Trigger: Trigger{StartTopic: "ctl", StartWhen: "go", StopAfter: 2},
+	}
+	fc := newFakeClient()
+	var wg sync.WaitGroup
+	fc.wg = &wg
+	tr := newTracer(cfg, fc)
+	if err := tr.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	for len(fc.subscribed()) < 2 {
+		<-fc.subCh
+	}
+	wg.Add(5)
+	fc.publish("data", "ignored")
+	if !tr.Armed() {
+		t.Fatalf("expected tracer to be armed")
+	}
+	fc.publish("ctl", "go")
+	fc.publish("data", "one")
+	fc.publish("data", "two")
+	fc.publish("data", "three")
+	wg.Wait()
+	<-tr.done
+
+	msgs, err := tracerMessages("test", "trig")
+	if err != nil {
+		t.Fatalf("messages: %v", err)
+	}
+	if len(msgs) != 2 || msgs[0].Payload != "one" || msgs[1].Payload != "two" {
+		t.Fatalf("unexpected messages %+v", msgs)
+	}
+}
@@ -1,6 +1,8 @@
 package traces
 
 import (
+	"fmt"
+
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/marang/emqutiti/ui"
@@ -11,7 +13,15 @@ func (t *Component) viewTraces() string {
 	t.api.ResetElemPos()
 	t.api.SetElemPos(IDList, 1)
 	listView := t.list.View()
-	help := ui.InfoStyle.Render("[a] add  [enter] start/stop  [v] view  [del] delete  [esc] back")
+	helpText := "[a] add  [enter] start/stop  [v] view  [g] chart  [d] diff  [f] search  [del] delete  [esc] back"
+	if t.diffBase != "" {
+		helpText = fmt.Sprintf("diff base %s: press [d] on another trace  [d] again to unmark", t.diffBase)
+	}
+	help := ui.InfoStyle.Render(helpText)
+	if t.search != nil {
+		t.search.Width = t.api.Width() - 4 - lipgloss.Width(t.search.Prompt) - 1
+		help = t.search.View()
+	}
 	content := lipgloss.JoinVertical(lipgloss.Left, listView, help)
 	focused := t.api.FocusedID() == IDList
 	view := ui.LegendBox(content, "Traces", t.api.Width()-2, 0, ui.ColBlue, focused, -1)
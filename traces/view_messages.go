@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
 	"github.com/marang/emqutiti/ui"
 )
@@ -12,6 +13,9 @@ import (
 func (t *Component) ViewMessages() string {
 	t.api.ResetElemPos()
 	title := fmt.Sprintf("Trace %s", t.viewKey)
+	if t.viewKey == "" {
+		title = fmt.Sprintf("Search %s", t.searchQuery)
+	}
 	if t.FilterQuery() != "" {
 		t.Component.List().SetSize(t.api.Width()-4, t.api.TraceHeight()-1)
 	} else {
@@ -24,7 +28,15 @@ func (t *Component) ViewMessages() string {
 		filterLine = ansi.Truncate(filterLine, inner, "")
 		listLines = append([]string{filterLine}, listLines...)
 	}
-	help := ui.InfoStyle.Render("[esc] back")
+	helpText := "[/] filter  [s] sort  [n] note  [x] export  [esc] back"
+	if s := t.Component.SortLabel(); s != "" {
+		helpText = fmt.Sprintf("[/] filter  [s] sort (%s)  [n] note  [x] export  [esc] back", s)
+	}
+	help := ui.InfoStyle.Render(helpText)
+	if t.note != nil {
+		t.note.Width = t.api.Width() - 4 - lipgloss.Width(t.note.Prompt) - 1
+		help = t.note.View()
+	}
 	listLines = append(listLines, help)
 	content := strings.Join(listLines, "\n")
 	view := ui.LegendBox(content, title, t.api.Width()-2, t.api.TraceHeight(), ui.ColBlue, true, -1)
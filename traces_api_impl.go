@@ -2,6 +2,7 @@ package emqutiti
 
 import (
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/marang/emqutiti/charts"
 	"github.com/marang/emqutiti/connections"
 	"github.com/marang/emqutiti/traces"
 
@@ -42,4 +43,9 @@ func (m *model) NewClient(p connections.Profile) (traces.Client, error) {
 	return NewMQTTClient(p, nil)
 }
 
+func (m *model) ShowTraceChart(key string, topics []string, samples []charts.Sample) tea.Cmd {
+	m.chart.OpenTrace(key, topics, samples)
+	return m.SetMode(constants.ModeChart)
+}
+
 var _ traces.API = (*model)(nil)
@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// HighlightJSON renders JSON source with terminal syntax colors. Fragments
+// such as a single `"key": value` line are accepted; tokens the lexer cannot
+// place are left uncolored. The input is returned unchanged on failure.
+func HighlightJSON(src string) string {
+	lexer := lexers.Get("json")
+	if lexer == nil {
+		return src
+	}
+	it, err := lexer.Tokenise(nil, src)
+	if err != nil {
+		return src
+	}
+	var tokens []chroma.Token
+	for t := it(); t != chroma.EOF; t = it() {
+		if t.Type == chroma.Error {
+			t.Type = chroma.Text
+		}
+		tokens = append(tokens, t)
+	}
+	var b strings.Builder
+	err = formatters.TTY256.Format(&b, styles.Get("monokai"), chroma.Literator(tokens...))
+	if err != nil {
+		return src
+	}
+	return b.String()
+}
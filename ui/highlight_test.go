@@ -0,0 +1,19 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestHighlightJSONKeepsText(t *testing.T) {
+	src := `"temp": 21.5`
+	out := HighlightJSON(src)
+	if out == src || !strings.Contains(out, "\x1b[") {
+		t.Fatalf("expected colored output, got %q", out)
+	}
+	if got := ansi.Strip(out); got != src {
+		t.Fatalf("stripped output %q, want %q", got, src)
+	}
+}
@@ -3,6 +3,7 @@ package emqutiti
 import (
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/marang/emqutiti/constants"
 	"github.com/marang/emqutiti/payloads"
 	"github.com/marang/emqutiti/topics"
 )
@@ -26,6 +27,11 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if cmd, handled := m.handleKeyNav(msg); handled {
 			return m, cmd
 		}
+	case MQTTMessage:
+		// live views keep receiving messages while they are shown
+		if mode := m.CurrentMode(); mode == constants.ModeChart || mode == constants.ModeStats {
+			return m, m.handleMQTTMessage(msg)
+		}
 	}
 
 	if c, ok := m.components[m.CurrentMode()]; ok {
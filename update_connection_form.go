@@ -3,6 +3,7 @@ package emqutiti
 import (
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/marang/emqutiti/connections"
 	"github.com/marang/emqutiti/constants"
 )
 
@@ -27,6 +28,9 @@ func (m *model) updateConnectionForm(msg tea.Msg) tea.Cmd {
 			return cmd
 		case constants.KeyEnter:
 			p, err := m.connections.Form.Profile()
+			if err == nil {
+				err = connections.CheckParent(m.connections.Manager.Profiles, p, m.connections.Form.Index)
+			}
 			if err != nil {
 				m.connections.SendStatus(err.Error())
 				return m.connections.ListenStatus()
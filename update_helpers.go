@@ -147,6 +147,9 @@ func (m *model) handleKeyNav(msg tea.KeyMsg) (tea.Cmd, bool) {
 		if m.CurrentMode() == constants.ModeHistoryFilter {
 			return m.history.UpdateFilter(msg), true
 		}
+		if mode := m.CurrentMode(); mode == constants.ModePublishFile || mode == constants.ModeChart {
+			return m.components[mode].Update(msg), true
+		}
 		if m.CurrentMode() == constants.ModeEditConnection {
 			if m.connections.Form != nil {
 				m.connections.Form.CycleFocus(msg)
@@ -161,6 +164,9 @@ func (m *model) handleKeyNav(msg tea.KeyMsg) (tea.Cmd, bool) {
 		if m.CurrentMode() == constants.ModeHistoryFilter {
 			return m.history.UpdateFilter(msg), true
 		}
+		if mode := m.CurrentMode(); mode == constants.ModePublishFile || mode == constants.ModeChart {
+			return m.components[mode].Update(msg), true
+		}
 		if m.CurrentMode() == constants.ModeEditConnection {
 			if m.connections.Form != nil {
 				m.connections.Form.CycleFocus(msg)
@@ -2,6 +2,7 @@ package emqutiti
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/x/ansi"
 	"github.com/marang/emqutiti/ui"
@@ -29,6 +30,9 @@ func (m *model) renderHistorySection() string {
 	if m.history.FilterQuery() != "" && shown != total {
 		histLabel = fmt.Sprintf("History (%d/%d messages \u2013 Ctrl+C copy)", shown, total)
 	}
+	if s := m.history.SortLabel(); s != "" {
+		histLabel = strings.TrimSuffix(histLabel, ")") + " \u2013 sort " + s + ")"
+	}
 	listHeight := m.layout.history.height
 	if m.history.FilterQuery() != "" && listHeight > 0 {
 		listHeight--